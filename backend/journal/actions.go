@@ -0,0 +1,520 @@
+package journal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Action items live in the action_items table. The action_items_mine and
+// action_items_theirs columns on entries are kept as a denormalized copy,
+// rewritten from the table whenever it changes, so entry reads, prep prompts
+// and search don't need a join.
+
+const (
+	ownerManager = "manager"
+	ownerMember  = "member"
+)
+
+// ownerColumns maps an action item owner to its entries column.
+var ownerColumns = map[string]string{
+	ownerManager: "action_items_mine",
+	ownerMember:  "action_items_theirs",
+}
+
+type ActionItemRecord struct {
+	ID          string  `json:"id"`
+	EntryID     string  `json:"entry_id"`
+	EntryDate   string  `json:"entry_date"`
+	MemberID    string  `json:"member_id"`
+	MemberName  string  `json:"member_name"`
+	Owner       string  `json:"owner"`
+	Text        string  `json:"text"`
+	Status      string  `json:"status"`
+	DueDate     *string `json:"due_date"`
+	CreatedAt   string  `json:"created_at"`
+	CompletedAt *string `json:"completed_at"`
+}
+
+// dbtx is the subset of *sql.DB and *sql.Tx the action item helpers need.
+type dbtx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+var actionItemSeq atomic.Uint64
+
+func newActionItemID() string {
+	return fmt.Sprintf("action-%d-%d", time.Now().UnixMilli(), actionItemSeq.Add(1))
+}
+
+// decodeActionItems converts a request body value into action items,
+// accepting both {"text", "completed"} objects and plain strings.
+func decodeActionItems(v any) []ActionItem {
+	arr, _ := v.([]any)
+	items := []ActionItem{}
+	for _, raw := range arr {
+		switch val := raw.(type) {
+		case string:
+			if strings.TrimSpace(val) != "" {
+				items = append(items, ActionItem{Text: val})
+			}
+		case map[string]any:
+			var item ActionItem
+			b, _ := json.Marshal(val)
+			if err := json.Unmarshal(b, &item); err == nil && strings.TrimSpace(item.Text) != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// syncActionItems makes the action_items rows for one owner of an entry
+// match items, then rewrites the entry's JSON column. Rows are matched by ID
+// first and then by text, so items that survive an edit keep their ID,
+// created_at and due date. Completing an item stamps completed_at.
+func syncActionItems(tx dbtx, entryID, owner string, items []ActionItem) error {
+	var memberID string
+	if err := tx.QueryRow("SELECT member_id FROM entries WHERE id = ?", entryID).Scan(&memberID); err != nil {
+		return fmt.Errorf("read entry %s: %w", entryID, err)
+	}
+
+	type existingRow struct {
+		id, text, status string
+		used             bool
+	}
+	rows, err := tx.Query("SELECT id, text, status FROM action_items WHERE entry_id = ? AND owner = ? ORDER BY position", entryID, owner)
+	if err != nil {
+		return err
+	}
+	var existing []*existingRow
+	for rows.Next() {
+		var r existingRow
+		if err := rows.Scan(&r.id, &r.text, &r.status); err != nil {
+			rows.Close()
+			return err
+		}
+		existing = append(existing, &r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	match := func(item ActionItem) *existingRow {
+		for _, r := range existing {
+			if !r.used && item.ID != "" && r.id == item.ID {
+				return r
+			}
+		}
+		for _, r := range existing {
+			if !r.used && r.text == item.Text {
+				return r
+			}
+		}
+		return nil
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for pos, item := range items {
+		status := "open"
+		if item.Completed {
+			status = "done"
+		}
+		if r := match(item); r != nil {
+			r.used = true
+			completedAt := "completed_at"
+			if status == "done" && r.status != "done" {
+				completedAt = "?"
+			} else if status == "open" {
+				completedAt = "NULL"
+			}
+			query := fmt.Sprintf("UPDATE action_items SET text = ?, status = ?, position = ?, completed_at = %s", completedAt)
+			args := []any{item.Text, status, pos}
+			if completedAt == "?" {
+				args = append(args, now)
+			}
+			if item.DueDate != nil {
+				query += ", due_date = ?"
+				args = append(args, nullIfEmpty(*item.DueDate))
+			}
+			args = append(args, r.id)
+			if _, err := tx.Exec(query+" WHERE id = ?", args...); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var completedAt any
+		if status == "done" {
+			completedAt = now
+		}
+		var dueDate any
+		if item.DueDate != nil {
+			dueDate = nullIfEmpty(*item.DueDate)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO action_items (id, entry_id, member_id, owner, text, status, position, due_date, created_at, completed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			newActionItemID(), entryID, memberID, owner, item.Text, status, pos, dueDate, now, completedAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range existing {
+		if !r.used {
+			if _, err := tx.Exec("DELETE FROM action_items WHERE id = ?", r.id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writeActionItemsJSON(tx, entryID, owner)
+}
+
+// writeActionItemsJSON regenerates an entry's action item column from the
+// action_items table.
+func writeActionItemsJSON(tx dbtx, entryID, owner string) error {
+	rows, err := tx.Query(
+		"SELECT id, text, status, due_date FROM action_items WHERE entry_id = ? AND owner = ? ORDER BY position",
+		entryID, owner,
+	)
+	if err != nil {
+		return err
+	}
+	items := []ActionItem{}
+	for rows.Next() {
+		var item ActionItem
+		var status string
+		var due sql.NullString
+		if err := rows.Scan(&item.ID, &item.Text, &status, &due); err != nil {
+			rows.Close()
+			return err
+		}
+		item.Completed = status == "done"
+		if due.Valid {
+			item.DueDate = &due.String
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("UPDATE entries SET %s = ? WHERE id = ?", ownerColumns[owner]), jsonStringify(items), entryID)
+	return err
+}
+
+// syncEntryActionItems syncs whichever action item fields are present in an
+// entry create/update body.
+func syncEntryActionItems(tx dbtx, entryID string, body map[string]any) error {
+	for _, owner := range []string{ownerManager, ownerMember} {
+		v, ok := body[ownerColumns[owner]]
+		if !ok {
+			continue
+		}
+		if err := syncActionItems(tx, entryID, owner, decodeActionItems(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// ─── Handlers ───────────────────────────────────────────
+
+const actionItemSelect = `
+	SELECT a.id, a.entry_id, e.date, a.member_id, COALESCE(m.name, ''), a.owner, a.text, a.status,
+		a.due_date, a.created_at, a.completed_at
+	FROM action_items a
+	JOIN entries e ON e.id = a.entry_id AND e.deleted_at IS NULL
+	LEFT JOIN team_members m ON m.id = a.member_id`
+
+func scanActionItemRecord(row interface{ Scan(...any) error }) (ActionItemRecord, error) {
+	var a ActionItemRecord
+	var due, completed sql.NullString
+	err := row.Scan(&a.ID, &a.EntryID, &a.EntryDate, &a.MemberID, &a.MemberName, &a.Owner, &a.Text, &a.Status,
+		&due, &a.CreatedAt, &completed)
+	if err != nil {
+		return a, err
+	}
+	if due.Valid {
+		a.DueDate = &due.String
+	}
+	if completed.Valid {
+		a.CompletedAt = &completed.String
+	}
+	return a, nil
+}
+
+func (a *App) handleGetActionItems(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var where []string
+	var args []any
+	switch status := q.Get("status"); status {
+	case "", "open":
+		where = append(where, "a.status = 'open'")
+	case "done":
+		where = append(where, "a.status = 'done'")
+	case "all":
+	default:
+		writeJSON(w, 400, map[string]string{"error": "status must be open, done or all"})
+		return
+	}
+	if v := q.Get("member_id"); v != "" {
+		where = append(where, "a.member_id = ?")
+		args = append(args, v)
+	}
+	if v := q.Get("owner"); v != "" {
+		if _, ok := ownerColumns[v]; !ok {
+			writeJSON(w, 400, map[string]string{"error": "owner must be manager or member"})
+			return
+		}
+		where = append(where, "a.owner = ?")
+		args = append(args, v)
+	}
+
+	query := actionItemSelect
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY a.due_date IS NULL, a.due_date, e.date DESC, a.position"
+
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		a.log.Printf("Failed to list action items: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer rows.Close()
+
+	items := []ActionItemRecord{}
+	for rows.Next() {
+		item, err := scanActionItemRecord(rows)
+		if err != nil {
+			a.log.Printf("Failed to scan action item: %v", err)
+			continue
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, items)
+}
+
+func (a *App) handleCreateActionItem(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		EntryID string  `json:"entry_id"`
+		Owner   string  `json:"owner"`
+		Text    string  `json:"text"`
+		DueDate *string `json:"due_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return
+	}
+	if body.EntryID == "" || strings.TrimSpace(body.Text) == "" {
+		writeJSON(w, 400, map[string]string{"error": "entry_id and text are required"})
+		return
+	}
+	if _, ok := ownerColumns[body.Owner]; !ok {
+		writeJSON(w, 400, map[string]string{"error": "owner must be manager or member"})
+		return
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var memberID string
+	if err := tx.QueryRow("SELECT member_id FROM entries WHERE id = ? AND deleted_at IS NULL", body.EntryID).Scan(&memberID); err != nil {
+		writeJSON(w, 404, map[string]string{"error": "entry not found"})
+		return
+	}
+
+	var dueDate any
+	if body.DueDate != nil {
+		dueDate = nullIfEmpty(*body.DueDate)
+	}
+	id := newActionItemID()
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err := tx.Exec(`
+		INSERT INTO action_items (id, entry_id, member_id, owner, text, status, position, due_date, created_at)
+		VALUES (?, ?, ?, ?, ?, 'open',
+			(SELECT COALESCE(MAX(position), -1) + 1 FROM action_items WHERE entry_id = ? AND owner = ?),
+			?, ?)`,
+		id, body.EntryID, memberID, body.Owner, body.Text, body.EntryID, body.Owner, dueDate, now,
+	); err != nil {
+		a.log.Printf("Failed to create action item: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "failed to create action item"})
+		return
+	}
+	if err := a.touchEntryActionItems(tx, body.EntryID, body.Owner, now); err != nil {
+		a.log.Printf("Failed to update entry %s action items: %v", body.EntryID, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to create action item"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+
+	item, err := scanActionItemRecord(a.db.QueryRow(actionItemSelect+" WHERE a.id = ?", id))
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "failed to read created action item"})
+		return
+	}
+	writeJSON(w, 201, item)
+}
+
+func (a *App) handleUpdateActionItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var entryID, owner, status string
+	if err := tx.QueryRow(`
+		SELECT a.entry_id, a.owner, a.status FROM action_items a
+		JOIN entries e ON e.id = a.entry_id AND e.deleted_at IS NULL
+		WHERE a.id = ?`, id).Scan(&entryID, &owner, &status); err != nil {
+		writeJSON(w, 404, map[string]string{"error": "action item not found"})
+		return
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var setClauses []string
+	var values []any
+	if v, ok := body["text"]; ok {
+		text, _ := v.(string)
+		if strings.TrimSpace(text) == "" {
+			writeJSON(w, 400, map[string]string{"error": "text cannot be empty"})
+			return
+		}
+		setClauses = append(setClauses, "text = ?")
+		values = append(values, text)
+	}
+	if v, ok := body["status"]; ok {
+		newStatus, _ := v.(string)
+		if newStatus != "open" && newStatus != "done" {
+			writeJSON(w, 400, map[string]string{"error": "status must be open or done"})
+			return
+		}
+		setClauses = append(setClauses, "status = ?")
+		values = append(values, newStatus)
+		if newStatus == "done" && status != "done" {
+			setClauses = append(setClauses, "completed_at = ?")
+			values = append(values, now)
+		} else if newStatus == "open" {
+			setClauses = append(setClauses, "completed_at = NULL")
+		}
+	}
+	if v, ok := body["due_date"]; ok {
+		setClauses = append(setClauses, "due_date = ?")
+		values = append(values, nullString(v))
+	}
+
+	if len(setClauses) > 0 {
+		values = append(values, id)
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE action_items SET %s WHERE id = ?", strings.Join(setClauses, ", ")), values...); err != nil {
+			a.log.Printf("Failed to update action item %s: %v", id, err)
+			writeJSON(w, 500, map[string]string{"error": "failed to update action item"})
+			return
+		}
+		if err := a.touchEntryActionItems(tx, entryID, owner, now); err != nil {
+			a.log.Printf("Failed to update entry %s action items: %v", entryID, err)
+			writeJSON(w, 500, map[string]string{"error": "failed to update action item"})
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+
+	item, err := scanActionItemRecord(a.db.QueryRow(actionItemSelect+" WHERE a.id = ?", id))
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "failed to read updated action item"})
+		return
+	}
+	writeJSON(w, 200, item)
+}
+
+// touchEntryActionItems rewrites the entry's JSON copy after a direct change
+// to action_items and bumps updated_at so cached prep briefings refresh.
+func (a *App) touchEntryActionItems(tx dbtx, entryID, owner, now string) error {
+	before, err := a.readEntry(tx, entryID)
+	if err != nil {
+		return err
+	}
+	if err := writeActionItemsJSON(tx, entryID, owner); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE entries SET updated_at = ? WHERE id = ?", now, entryID); err != nil {
+		return err
+	}
+	return a.recordRevision(tx, before, "action item")
+}
+
+// openActionItems returns a member's open items across all their entries,
+// newest entry first.
+func (a *App) openActionItems(memberID string) (mine, theirs []PrepActionItem, err error) {
+	rows, err := a.db.Query(`
+		SELECT a.id, a.owner, a.text, e.date, a.due_date
+		FROM action_items a
+		JOIN entries e ON e.id = a.entry_id AND e.deleted_at IS NULL
+		WHERE a.member_id = ? AND a.status = 'open'
+		ORDER BY e.date DESC, a.position`, memberID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item PrepActionItem
+		var owner string
+		var due sql.NullString
+		if err := rows.Scan(&item.ID, &owner, &item.Text, &item.Date, &due); err != nil {
+			return nil, nil, err
+		}
+		if due.Valid {
+			item.DueDate = &due.String
+		}
+		if owner == ownerManager {
+			mine = append(mine, item)
+		} else {
+			theirs = append(theirs, item)
+		}
+	}
+	return mine, theirs, rows.Err()
+}
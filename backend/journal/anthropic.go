@@ -0,0 +1,145 @@
+package journal
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultAnthropicModel = "claude-sonnet-4-5-20250929"
+
+type anthropicProvider struct {
+	apiKey string
+	model  string
+	http   *httpClient
+}
+
+func newAnthropicProvider(a *App) Provider {
+	if a.cfg.AnthropicAPIKey == "" {
+		return nil
+	}
+	return &anthropicProvider{
+		apiKey: a.cfg.AnthropicAPIKey,
+		model:  cmp.Or(a.cfg.AnthropicModel, defaultAnthropicModel),
+		http:   a.http["anthropic"],
+	}
+}
+
+func (p *anthropicProvider) Name() string { return "anthropic" }
+
+func (p *anthropicProvider) do(req CompletionRequest, stream bool) (*http.Response, error) {
+	body := map[string]any{
+		"model":      req.model(p.model),
+		"max_tokens": req.maxTokens(),
+		"messages":   []map[string]string{{"role": "user", "content": req.Prompt}},
+	}
+	if stream {
+		body["stream"] = true
+	}
+	b, _ := json.Marshal(body)
+
+	httpReq, _ := http.NewRequestWithContext(req.context(), "POST", "https://api.anthropic.com/v1/messages", bytes.NewReader(b))
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	httpReq.Header.Set("content-type", "application/json")
+
+	resp, err := p.http.do(req.context(), httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic request failed: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, &ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	return resp, nil
+}
+
+// anthropicUsage is the usage block of a response. Streams report input
+// tokens in message_start and the output total in message_delta.
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (p *anthropicProvider) Complete(req CompletionRequest) (string, error) {
+	resp, err := p.do(req, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+
+	var result struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage anthropicUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to parse anthropic response: %w", err)
+	}
+	req.report("anthropic", req.model(p.model), result.Usage.InputTokens, result.Usage.OutputTokens)
+
+	var texts []string
+	for _, c := range result.Content {
+		texts = append(texts, c.Text)
+	}
+	return strings.Join(texts, ""), nil
+}
+
+func (p *anthropicProvider) Stream(req CompletionRequest, onDelta func(string) error) (string, error) {
+	resp, err := p.do(req, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	var usage anthropicUsage
+	err = readSSEData(resp.Body, func(data string) error {
+		var event struct {
+			Type    string `json:"type"`
+			Message struct {
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Usage anthropicUsage `json:"usage"`
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("failed to parse anthropic stream event: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			usage.InputTokens = event.Message.Usage.InputTokens
+		case "message_delta":
+			usage.OutputTokens = event.Usage.OutputTokens
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" {
+				return nil
+			}
+			sb.WriteString(event.Delta.Text)
+			return onDelta(event.Delta.Text)
+		case "error":
+			return fmt.Errorf("anthropic stream error: %s: %s", event.Error.Type, event.Error.Message)
+		}
+		return nil
+	})
+	req.report("anthropic", req.model(p.model), usage.InputTokens, usage.OutputTokens)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
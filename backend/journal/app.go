@@ -0,0 +1,298 @@
+package journal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// App is one People Journal: its database, configuration, AI provider, JIRA
+// client and cache. Every handler and background job is a method on it, so
+// several can run side by side in one process, each with its own database.
+type App struct {
+	cfg   Config
+	db    *sql.DB
+	log   *log.Logger
+	cache *cache
+	http  map[string]*httpClient
+
+	// provider is nil when no provider is configured; providerErr says why.
+	provider    Provider
+	providerErr error
+	// jira is nil when JIRA isn't configured.
+	jira *jiraClient
+	// fieldKey is the active cipher, nil when encryption is off.
+	fieldKey *fieldCipher
+
+	backupMu   sync.Mutex
+	lastBackup time.Time
+
+	stop      chan struct{}
+	closeOnce sync.Once
+	// testDir is the throwaway data directory of an App in test mode.
+	testDir string
+}
+
+// New checks cfg with Validate and sets up an App from it: it opens and
+// migrates the database in cfg.DataDir, loads the encryption key and picks
+// the AI provider. It doesn't start serving or any background job; see Run
+// and Start.
+func New(cfg Config) (*App, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+	var testDir string
+	if cfg.TestMode {
+		dir, err := applyTestMode(&cfg)
+		if err != nil {
+			return nil, err
+		}
+		testDir = dir
+	}
+
+	a := newApp(cfg)
+	if testDir != "" {
+		a.testDir = testDir
+		a.log.Printf("Test mode: using a throwaway database in %s", testDir)
+	}
+	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
+		a.Close()
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+	path := filepath.Join(cfg.DataDir, "people-journal.db")
+	a.log.Printf("Database: %s", path)
+
+	if err := a.open(path); err != nil {
+		a.Close()
+		return nil, fmt.Errorf("initialize database: %w", err)
+	}
+	if err := a.loadEncryption(); err != nil {
+		a.Close()
+		return nil, fmt.Errorf("load encryption key: %w", err)
+	}
+	if err := a.seedTeam(); err != nil {
+		a.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// newApp builds everything that doesn't need the database.
+func newApp(cfg Config) *App {
+	a := &App{cfg: cfg, log: cfg.Logger, stop: make(chan struct{})}
+	if a.log == nil {
+		a.log = log.Default()
+	}
+	a.http = a.newHTTPClients()
+	a.provider, a.providerErr = a.newProvider()
+	if cfg.jiraConfigured() {
+		a.jira = a.newJIRAClient()
+	}
+	return a
+}
+
+// open opens and migrates the database at path.
+func (a *App) open(path string) error {
+	db, err := openDB(path, a.log)
+	if err != nil {
+		return err
+	}
+	a.db = db
+	a.cache = &cache{db: db, ttl: time.Duration(a.cfg.CacheTTLDays) * 24 * time.Hour}
+	return nil
+}
+
+// Close stops the background jobs and closes the database. In test mode it
+// also removes the temp data directory.
+func (a *App) Close() error {
+	var err error
+	a.closeOnce.Do(func() {
+		close(a.stop)
+		if a.db != nil {
+			err = a.db.Close()
+		}
+		if a.testDir != "" {
+			os.RemoveAll(a.testDir)
+		}
+	})
+	return err
+}
+
+// Start runs the background jobs, trash purging and scheduled backups,
+// until Close.
+func (a *App) Start() {
+	a.startTrashPurger()
+	a.startBackupScheduler()
+}
+
+// sleep waits for d, and reports false if the App was closed first.
+func (a *App) sleep(d time.Duration) bool {
+	select {
+	case <-a.stop:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// Run starts the background jobs and serves the API on cfg.Port until ctx
+// is done, then shuts the server down gracefully.
+func (a *App) Run(ctx context.Context) error {
+	a.logStatus()
+	a.Start()
+
+	srv := &http.Server{Addr: ":" + a.cfg.Port, Handler: a.Handler()}
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	a.log.Printf("Backend running on http://localhost:%s", a.cfg.Port)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-shutdownDone
+	return nil
+}
+
+func (a *App) logStatus() {
+	if a.cfg.ConfigFile != "" {
+		a.log.Printf("Config file: %s", a.cfg.ConfigFile)
+	}
+	if a.jira != nil {
+		a.log.Printf("JIRA integration: enabled (%s)", a.jira.baseURL)
+	} else {
+		a.log.Printf("JIRA integration: disabled — JIRA_BASE_URL=%q JIRA_EMAIL=%q JIRA_API_TOKEN=(set=%v)",
+			a.cfg.JIRABaseURL, a.cfg.JIRAEmail, a.cfg.JIRAAPIToken != "")
+	}
+	if a.provider == nil {
+		a.log.Printf("AI provider: none (%v)", a.providerErr)
+	} else {
+		a.log.Printf("AI provider: %s", a.provider.Name())
+	}
+}
+
+// Handler returns the API: every route, wrapped in the CORS middleware.
+func (a *App) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/team", a.handleGetTeam)
+	mux.HandleFunc("POST /api/team", a.handleCreateTeamMember)
+	mux.HandleFunc("PUT /api/team/{id}", a.handleUpdateTeamMember)
+	mux.HandleFunc("PUT /api/team/{id}/prep-notes", a.handleUpdatePrepNotes)
+	mux.HandleFunc("DELETE /api/team/{id}", a.handleDeleteTeamMember)
+	mux.HandleFunc("GET /api/team/{id}/review", a.handleReviewExport)
+	mux.HandleFunc("GET /api/team/{id}/speakers", a.handleGetSpeakerLabels)
+	mux.HandleFunc("PUT /api/team/{id}/speakers", a.handlePutSpeakerLabels)
+
+	mux.HandleFunc("GET /api/entries", a.handleGetEntries)
+	mux.HandleFunc("GET /api/entries/{id}", a.handleGetEntry)
+	mux.HandleFunc("POST /api/entries", a.handleCreateEntry)
+	mux.HandleFunc("PUT /api/entries/{id}", a.handleUpdateEntry)
+	mux.HandleFunc("DELETE /api/entries/{id}", a.handleDeleteEntry)
+	mux.HandleFunc("GET /api/entries/{id}/revisions", a.handleGetRevisions)
+	mux.HandleFunc("GET /api/entries/{id}/revisions/diff", a.handleDiffRevisions)
+	mux.HandleFunc("GET /api/entries/{id}/revisions/{rev}", a.handleGetRevision)
+	mux.HandleFunc("POST /api/entries/{id}/revisions/{rev}/restore", a.handleRestoreRevision)
+
+	mux.HandleFunc("GET /api/action-items", a.handleGetActionItems)
+	mux.HandleFunc("POST /api/action-items", a.handleCreateActionItem)
+	mux.HandleFunc("PUT /api/action-items/{id}", a.handleUpdateActionItem)
+
+	mux.HandleFunc("GET /api/trash", a.handleGetTrash)
+	mux.HandleFunc("DELETE /api/trash", a.handleEmptyTrash)
+	mux.HandleFunc("POST /api/trash/team/{id}/restore", a.handleRestoreTrashedMember)
+	mux.HandleFunc("DELETE /api/trash/team/{id}", a.handlePurgeTrashedMember)
+	mux.HandleFunc("POST /api/trash/entries/{id}/restore", a.handleRestoreTrashedEntry)
+	mux.HandleFunc("DELETE /api/trash/entries/{id}", a.handlePurgeTrashedEntry)
+
+	mux.HandleFunc("GET /api/tags", a.handleGetTags)
+	mux.HandleFunc("POST /api/tags", a.handleCreateTag)
+	mux.HandleFunc("PUT /api/tags/{id}", a.handleUpdateTag)
+	mux.HandleFunc("DELETE /api/tags/{id}", a.handleDeleteTag)
+	mux.HandleFunc("POST /api/tags/{id}/merge", a.handleMergeTag)
+
+	mux.HandleFunc("GET /api/custom-fields", a.handleGetCustomFields)
+	mux.HandleFunc("POST /api/custom-fields", a.handleCreateCustomField)
+	mux.HandleFunc("PUT /api/custom-fields/{id}", a.handleUpdateCustomField)
+	mux.HandleFunc("DELETE /api/custom-fields/{id}", a.handleDeleteCustomField)
+
+	mux.HandleFunc("GET /api/redaction-terms", a.handleGetRedactionTerms)
+	mux.HandleFunc("POST /api/redaction-terms", a.handleCreateRedactionTerm)
+	mux.HandleFunc("DELETE /api/redaction-terms/{id}", a.handleDeleteRedactionTerm)
+
+	mux.HandleFunc("GET /api/prompts", a.handleGetPrompts)
+	mux.HandleFunc("GET /api/prompts/{name}", a.handleGetPrompt)
+	mux.HandleFunc("PUT /api/prompts/{name}", a.handleUpdatePrompt)
+	mux.HandleFunc("POST /api/prompts/{name}/reset", a.handleResetPrompt)
+	mux.HandleFunc("POST /api/prompts/{name}/preview", a.handlePreviewPrompt)
+	mux.HandleFunc("GET /api/prompts/{name}/versions", a.handleGetPromptVersions)
+	mux.HandleFunc("GET /api/prompts/{name}/versions/{version}", a.handleGetPromptVersion)
+
+	mux.HandleFunc("GET /api/search", a.handleSearch)
+
+	mux.HandleFunc("GET /api/export", a.handleExport)
+	mux.HandleFunc("POST /api/import", a.handleImport)
+	mux.HandleFunc("POST /api/backup", a.handleBackup)
+
+	mux.HandleFunc("GET /api/config", a.handleGetConfig)
+	mux.HandleFunc("GET /api/usage", a.handleGetUsage)
+	mux.HandleFunc("POST /api/extract", a.handleExtract)
+	mux.HandleFunc("POST /api/extract/stream", a.handleExtractStream)
+	mux.HandleFunc("POST /api/transcripts/parse", a.handleParseTranscript)
+	mux.HandleFunc("POST /api/prep", a.handlePrep)
+	mux.HandleFunc("POST /api/prep/stream", a.handlePrepStream)
+
+	return corsMiddleware(mux)
+}
+
+// Commands are the maintenance commands RunCommand accepts.
+var Commands = []string{"encrypt", "rotate-key"}
+
+// RunCommand runs a maintenance command against the database instead of
+// starting the server.
+func (a *App) RunCommand(name string) error {
+	switch name {
+	case "encrypt":
+		return a.runEncryptCommand()
+	case "rotate-key":
+		return a.runRotateKeyCommand()
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(204)
+			return
+		}
+
+		// Limit request body size to 10 MB, except for imports of a whole journal
+		if r.Body != nil {
+			limit := int64(10 << 20)
+			if r.URL.Path == "/api/import" {
+				limit = 200 << 20
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
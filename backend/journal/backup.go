@@ -0,0 +1,196 @@
+package journal
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Backups are consistent snapshots of the live database taken with VACUUM
+// INTO, which reads through SQLite so WAL contents are included and writers
+// aren't blocked. One is taken at startup and then every
+// BACKUP_INTERVAL_HOURS; after each one, old copies are pruned to the newest
+// of each of the last BACKUP_KEEP_DAILY days and BACKUP_KEEP_WEEKLY weeks.
+const (
+	defaultBackupIntervalHours = 24
+	defaultBackupKeepDaily     = 7
+	defaultBackupKeepWeekly    = 4
+	backupPrefix               = "people-journal-"
+	backupTimeFormat           = "20060102-150405"
+)
+
+func (a *App) backupDir() string {
+	if a.cfg.BackupDir != "" {
+		return a.cfg.BackupDir
+	}
+	return filepath.Join(a.cfg.DataDir, "backups")
+}
+
+type BackupResult struct {
+	File      string   `json:"file"`
+	Size      int64    `json:"size"`
+	CreatedAt string   `json:"created_at"`
+	Removed   []string `json:"removed"`
+}
+
+type backupFile struct {
+	name  string
+	taken time.Time
+}
+
+// listBackups returns the backups in dir, newest first. Files that don't
+// match the backup naming scheme are ignored and never pruned.
+func listBackups(dir string) ([]backupFile, error) {
+	names, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []backupFile
+	for _, n := range names {
+		name := n.Name()
+		if n.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, ".db") {
+			continue
+		}
+		taken, err := time.ParseInLocation(backupTimeFormat,
+			strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), ".db"), time.UTC)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{name: name, taken: taken})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].taken.After(backups[j].taken) })
+	return backups, nil
+}
+
+// backupsToPrune picks the backups retention doesn't keep: the newest backup
+// of each of the keepDaily most recent days and of each of the keepWeekly
+// most recent ISO weeks survive, as does the newest backup overall. backups
+// must be newest first.
+func backupsToPrune(backups []backupFile, keepDaily, keepWeekly int) []backupFile {
+	keep := map[string]bool{}
+	if len(backups) > 0 {
+		keep[backups[0].name] = true
+	}
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+	for _, b := range backups {
+		day := b.taken.Format("2006-01-02")
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep[b.name] = true
+		}
+		y, w := b.taken.ISOWeek()
+		week := fmt.Sprintf("%d-W%02d", y, w)
+		if !weeks[week] && len(weeks) < keepWeekly {
+			weeks[week] = true
+			keep[b.name] = true
+		}
+	}
+
+	var prune []backupFile
+	for _, b := range backups {
+		if !keep[b.name] {
+			prune = append(prune, b)
+		}
+	}
+	return prune
+}
+
+// runBackup snapshots the database into backupDir and prunes old copies.
+func (a *App) runBackup() (BackupResult, error) {
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	dir := a.backupDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return BackupResult{}, fmt.Errorf("create backup directory: %w", err)
+	}
+
+	now := time.Now().UTC()
+	name := backupPrefix + now.Format(backupTimeFormat) + ".db"
+	path := filepath.Join(dir, name)
+	// VACUUM INTO refuses to overwrite, and a half-written file must never
+	// look like a finished backup, so write to a temporary name first.
+	tmp := path + ".partial"
+	os.Remove(tmp)
+	if _, err := a.db.Exec("VACUUM INTO ?", tmp); err != nil {
+		os.Remove(tmp)
+		return BackupResult{}, fmt.Errorf("snapshot database: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return BackupResult{}, fmt.Errorf("finish backup: %w", err)
+	}
+	a.lastBackup = now
+
+	res := BackupResult{File: path, CreatedAt: now.Format(time.RFC3339), Removed: []string{}}
+	if info, err := os.Stat(path); err == nil {
+		res.Size = info.Size()
+	}
+
+	backups, err := listBackups(dir)
+	if err != nil {
+		return res, fmt.Errorf("list backups: %w", err)
+	}
+	for _, b := range backupsToPrune(backups, a.cfg.BackupKeepDaily, a.cfg.BackupKeepWeekly) {
+		if err := os.Remove(filepath.Join(dir, b.name)); err != nil {
+			a.log.Printf("Failed to remove old backup %s: %v", b.name, err)
+			continue
+		}
+		res.Removed = append(res.Removed, b.name)
+	}
+	return res, nil
+}
+
+// lastBackupTime is the newest backup taken by this process, or failing that
+// the newest one in the backup directory.
+func (a *App) lastBackupTime() *time.Time {
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	if !a.lastBackup.IsZero() {
+		t := a.lastBackup
+		return &t
+	}
+	backups, err := listBackups(a.backupDir())
+	if err != nil || len(backups) == 0 {
+		return nil
+	}
+	return &backups[0].taken
+}
+
+// startBackupScheduler takes a backup now and then every
+// BACKUP_INTERVAL_HOURS. An interval of 0 turns scheduled backups off;
+// POST /api/backup still works.
+func (a *App) startBackupScheduler() {
+	hours := a.cfg.BackupIntervalHours
+	if hours == 0 {
+		return
+	}
+	go func() {
+		for {
+			if res, err := a.runBackup(); err != nil {
+				a.log.Printf("Backup failed: %v", err)
+			} else {
+				a.log.Printf("Backup written to %s (%d bytes, %d old copies removed)", res.File, res.Size, len(res.Removed))
+			}
+			if !a.sleep(time.Duration(hours) * time.Hour) {
+				return
+			}
+		}
+	}()
+}
+
+func (a *App) handleBackup(w http.ResponseWriter, r *http.Request) {
+	res, err := a.runBackup()
+	if err != nil {
+		a.log.Printf("Backup failed: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "backup failed: " + err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
@@ -0,0 +1,151 @@
+package journal
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// backupsAt names a backup for each time, newest first as listBackups
+// returns them.
+func backupsAt(t *testing.T, times ...string) []backupFile {
+	t.Helper()
+	var backups []backupFile
+	for _, s := range times {
+		taken, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backups = append(backups, backupFile{name: backupPrefix + taken.Format(backupTimeFormat) + ".db", taken: taken})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].taken.After(backups[j].taken) })
+	return backups
+}
+
+func TestBackupsToPrune(t *testing.T) {
+	tests := []struct {
+		name                  string
+		backups               []string
+		keepDaily, keepWeekly int
+		prune                 []string
+	}{
+		{
+			name:      "newest of each recent day",
+			backups:   []string{"2024-05-08 18:00", "2024-05-08 09:00", "2024-05-07 20:00", "2024-05-07 08:00", "2024-05-06 12:00", "2024-05-05 12:00"},
+			keepDaily: 3, keepWeekly: 0,
+			prune: []string{"2024-05-08 09:00", "2024-05-07 08:00", "2024-05-05 12:00"},
+		},
+		{
+			// 2024-05-06 is the Monday of ISO week 19.
+			name:      "newest of each recent ISO week",
+			backups:   []string{"2024-05-08 10:00", "2024-05-06 10:00", "2024-05-05 23:00", "2024-05-03 10:00", "2024-04-28 10:00", "2024-04-22 10:00", "2024-04-21 10:00"},
+			keepDaily: 1, keepWeekly: 3,
+			prune: []string{"2024-05-06 10:00", "2024-05-03 10:00", "2024-04-22 10:00", "2024-04-21 10:00"},
+		},
+		{
+			name:      "days and weeks overlap",
+			backups:   []string{"2024-05-08 10:00", "2024-05-07 10:00", "2024-05-06 10:00", "2024-05-05 10:00", "2024-04-30 10:00", "2024-04-29 10:00", "2024-04-22 10:00"},
+			keepDaily: 2, keepWeekly: 3,
+			prune: []string{"2024-05-06 10:00", "2024-04-30 10:00", "2024-04-29 10:00"},
+		},
+		{
+			// 2024-12-30 is already in ISO week 1 of 2025.
+			name:      "ISO weeks across a new year",
+			backups:   []string{"2025-01-02 10:00", "2024-12-30 10:00", "2024-12-29 10:00", "2024-12-23 10:00"},
+			keepDaily: 0, keepWeekly: 2,
+			prune: []string{"2024-12-30 10:00", "2024-12-23 10:00"},
+		},
+		{
+			name:      "the newest backup always survives",
+			backups:   []string{"2024-05-08 10:00", "2024-05-01 10:00", "2024-04-01 10:00"},
+			keepDaily: 0, keepWeekly: 0,
+			prune: []string{"2024-05-01 10:00", "2024-04-01 10:00"},
+		},
+		{
+			name:      "nothing to prune",
+			backups:   []string{"2024-05-08 10:00", "2024-05-07 10:00"},
+			keepDaily: 7, keepWeekly: 4,
+		},
+		{
+			name:      "no backups",
+			keepDaily: 7, keepWeekly: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, want []string
+			for _, b := range backupsToPrune(backupsAt(t, tt.backups...), tt.keepDaily, tt.keepWeekly) {
+				got = append(got, b.name)
+			}
+			for _, b := range backupsAt(t, tt.prune...) {
+				want = append(want, b.name)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("pruned %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestBackupEndpoint(t *testing.T) {
+	s := newTestServer(t, func(c *Config) {
+		c.BackupKeepDaily = 1
+		c.BackupKeepWeekly = 1
+	})
+	seedEntry(t, s)
+	dir := s.app.backupDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{backupPrefix + "20200106-120000.db", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("old"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var file string
+	s.run(t, []apiStep{
+		{name: "config reports the newest backup on disk", method: "GET", path: "/api/config", status: 200,
+			check: checks(wantField("2020-01-06T12:00:00Z", "last_backup"), wantField(dir, "backup_dir"))},
+		{name: "back up", method: "POST", path: "/api/backup", status: 200,
+			check: func(t *testing.T, s *testServer, got any) {
+				file, _ = field(got, "file").(string)
+				if filepath.Dir(file) != dir {
+					t.Errorf("file = %q, want it in %s", file, dir)
+				}
+				if size, _ := field(got, "size").(float64); size == 0 {
+					t.Error("size = 0")
+				}
+				if removed := fmt.Sprint(field(got, "removed")); removed != "["+backupPrefix+"20200106-120000.db]" {
+					t.Errorf("removed = %s, want the 2020 backup", removed)
+				}
+				s.vars["created"], _ = field(got, "created_at").(string)
+			}},
+		{name: "config reports the backup", method: "GET", path: "/api/config", status: 200,
+			check: func(t *testing.T, s *testServer, got any) {
+				if last := field(got, "last_backup"); last == nil || last != s.vars["created"] {
+					t.Errorf("last_backup = %v, want %s", last, s.vars["created"])
+				}
+			}},
+	})
+
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("a file that isn't a backup was touched: %v", err)
+	}
+	if _, err := os.Stat(file + ".partial"); !os.IsNotExist(err) {
+		t.Errorf("partial file left behind: %v", err)
+	}
+	db, err := sql.Open("sqlite", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var summary string
+	if err := db.QueryRow("SELECT summary FROM entries WHERE id = 'entry-1'").Scan(&summary); err != nil || summary != "Talked about the launch." {
+		t.Errorf("backup entry = %q, %v", summary, err)
+	}
+}
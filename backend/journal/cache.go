@@ -0,0 +1,60 @@
+package journal
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+func cacheKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0}) // separator
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// cache holds model results in the cache table, by key within a category,
+// for ttl.
+type cache struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+func (c *cache) get(key, category string) (string, bool) {
+	var value, createdAt string
+	err := c.db.QueryRow(
+		"SELECT value, created_at FROM cache WHERE key = ? AND category = ?",
+		key, category,
+	).Scan(&value, &createdAt)
+	if err != nil {
+		return "", false
+	}
+
+	t, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil || time.Since(t) > c.ttl {
+		c.db.Exec("DELETE FROM cache WHERE key = ? AND category = ?", key, category)
+		return "", false
+	}
+
+	return value, true
+}
+
+func (c *cache) set(key, category, value string) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	c.db.Exec(
+		"INSERT OR REPLACE INTO cache (key, category, value, created_at) VALUES (?, ?, ?, ?)",
+		key, category, value, now,
+	)
+	// Lazy cleanup: delete expired entries
+	cutoff := time.Now().UTC().Add(-c.ttl).Format(time.RFC3339)
+	c.db.Exec("DELETE FROM cache WHERE created_at < ?", cutoff)
+}
+
+// clear drops everything in category.
+func (c *cache) clear(category string) error {
+	_, err := c.db.Exec("DELETE FROM cache WHERE category = ?", category)
+	return err
+}
@@ -0,0 +1,318 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Transcripts longer than chunkThreshold characters are extracted in
+// overlapping chunks and merged, so hour-long meetings don't overflow the
+// model's context or the output token budget.
+const (
+	defaultChunkChars   = 24000
+	defaultOverlapTurns = 2
+	mergeMaxTokens      = 2000
+)
+
+func (a *App) chunkThreshold() int {
+	if a.cfg.ExtractChunkChars > 0 {
+		return a.cfg.ExtractChunkChars
+	}
+	return defaultChunkChars
+}
+
+// ─── Splitting ──────────────────────────────────────────
+
+// speakerLine matches the start of a speaker turn: an optional timestamp
+// followed by a short label and a colon, e.g. "Sam:", "[00:12:04] Jo Li:" or
+// "Jo Li (report):".
+var speakerLine = regexp.MustCompile(`^\s*(\[?\(?\d{1,2}:\d{2}(:\d{2})?\)?\]?\s*)?[\p{L}][\p{L}\d .'\-]{0,40}( \([a-z]+\))?:\s`)
+
+// splitTurns breaks a transcript into speaker turns. Lines without a speaker
+// label are kept with the turn before them. Transcripts with no labels at all
+// are split on blank lines instead.
+func splitTurns(transcript string) []string {
+	lines := strings.Split(strings.ReplaceAll(transcript, "\r\n", "\n"), "\n")
+
+	labelled := false
+	for _, l := range lines {
+		if speakerLine.MatchString(l) {
+			labelled = true
+			break
+		}
+	}
+
+	var turns []string
+	var cur []string
+	flush := func() {
+		if t := strings.TrimSpace(strings.Join(cur, "\n")); t != "" {
+			turns = append(turns, t)
+		}
+		cur = nil
+	}
+	for _, l := range lines {
+		if labelled && speakerLine.MatchString(l) {
+			flush()
+		} else if !labelled && strings.TrimSpace(l) == "" {
+			flush()
+			continue
+		}
+		cur = append(cur, l)
+	}
+	flush()
+	return turns
+}
+
+// chunkTranscript packs speaker turns into chunks of at most maxChars. Each
+// chunk after the first repeats the last overlap turns of the one before so
+// context that straddles a boundary isn't lost. A single turn longer than
+// maxChars is cut at word boundaries.
+func chunkTranscript(transcript string, maxChars, overlap int) []string {
+	var turns []string
+	for _, t := range splitTurns(transcript) {
+		turns = append(turns, splitLongTurn(t, maxChars)...)
+	}
+
+	var chunks []string
+	var cur []string
+	size := 0
+	fresh := 0 // turns in cur that haven't appeared in a previous chunk
+	for _, t := range turns {
+		if fresh > 0 && size+len(t)+1 > maxChars {
+			chunks = append(chunks, strings.Join(cur, "\n"))
+			start := max(len(cur)-overlap, 0)
+			cur = append([]string(nil), cur[start:]...)
+			size = 0
+			for _, c := range cur {
+				size += len(c) + 1
+			}
+			// Drop overlap rather than exceed the limit
+			for len(cur) > 0 && size+len(t)+1 > maxChars {
+				size -= len(cur[0]) + 1
+				cur = cur[1:]
+			}
+			fresh = 0
+		}
+		cur = append(cur, t)
+		size += len(t) + 1
+		fresh++
+	}
+	if fresh > 0 {
+		chunks = append(chunks, strings.Join(cur, "\n"))
+	}
+	return chunks
+}
+
+func splitLongTurn(turn string, maxChars int) []string {
+	var parts []string
+	for len(turn) > maxChars {
+		cut := strings.LastIndexFunc(turn[:maxChars], unicode.IsSpace)
+		if cut <= 0 {
+			cut = maxChars
+		}
+		parts = append(parts, strings.TrimSpace(turn[:cut]))
+		turn = strings.TrimSpace(turn[cut:])
+	}
+	if turn != "" {
+		parts = append(parts, turn)
+	}
+	return parts
+}
+
+// ─── Map / Reduce ───────────────────────────────────────
+
+func (a *App) buildChunkExtractionPrompt(data ExtractionPromptData, part, total int) string {
+	return a.renderPrompt(promptChunk, ChunkPromptData{Part: part, Total: total, Extraction: a.renderPrompt(promptExtraction, data)})
+}
+
+func (a *App) buildMergePrompt(data ExtractionPromptData, partials []ExtractionResult) string {
+	return a.renderPrompt(promptMerge, a.mergePromptData(data, partials))
+}
+
+// mergePromptData fills the merge template from the extraction data shared
+// by every part.
+func (a *App) mergePromptData(data ExtractionPromptData, partials []ExtractionResult) MergePromptData {
+	m := MergePromptData{Member: data.Member, Tags: data.Tags, CustomFields: data.CustomFields}
+	for _, p := range partials {
+		b, _ := json.MarshalIndent(p, "", "  ")
+		m.Partials = append(m.Partials, string(b))
+	}
+	return m
+}
+
+// mergeLocally combines partial extractions without the model, used when
+// the merge pass fails: summaries are concatenated, scores averaged and
+// lists deduped. Custom fields are merged the same way.
+func mergeLocally(partials []ExtractionResult) ExtractionResult {
+	var merged ExtractionResult
+	var summaries, moraleRats, growthRats []string
+	var morale, growth []int
+	custom := map[string][]any{}
+	for _, p := range partials {
+		if p.Summary != "" {
+			summaries = append(summaries, p.Summary)
+		}
+		if p.MoraleRationale != "" {
+			moraleRats = append(moraleRats, p.MoraleRationale)
+		}
+		if p.GrowthRationale != "" {
+			growthRats = append(growthRats, p.GrowthRationale)
+		}
+		if p.MoraleScore != nil {
+			morale = append(morale, *p.MoraleScore)
+		}
+		if p.GrowthScore != nil {
+			growth = append(growth, *p.GrowthScore)
+		}
+		merged.Tags = append(merged.Tags, p.Tags...)
+		merged.ActionItemsMine = append(merged.ActionItemsMine, p.ActionItemsMine...)
+		merged.ActionItemsTheirs = append(merged.ActionItemsTheirs, p.ActionItemsTheirs...)
+		merged.NotableQuotes = append(merged.NotableQuotes, p.NotableQuotes...)
+		merged.Blockers = append(merged.Blockers, p.Blockers...)
+		merged.Wins = append(merged.Wins, p.Wins...)
+		for name, val := range p.CustomFields {
+			custom[name] = append(custom[name], val)
+		}
+	}
+	merged.Summary = strings.Join(summaries, " ")
+	merged.MoraleRationale = strings.Join(moraleRats, " ")
+	merged.GrowthRationale = strings.Join(growthRats, " ")
+	merged.MoraleScore = averageScore(morale)
+	merged.GrowthScore = averageScore(growth)
+	merged.Tags = dedupeStrings(merged.Tags)
+	merged.ActionItemsMine = dedupeStrings(merged.ActionItemsMine)
+	merged.ActionItemsTheirs = dedupeStrings(merged.ActionItemsTheirs)
+	merged.NotableQuotes = dedupeStrings(merged.NotableQuotes)
+	merged.Blockers = dedupeStrings(merged.Blockers)
+	merged.Wins = dedupeStrings(merged.Wins)
+	merged.CustomFields = mergeCustomLocally(custom)
+	return merged
+}
+
+// mergeCustomLocally combines each custom field's values across parts the
+// same way as the built-in fields of that type.
+func mergeCustomLocally(values map[string][]any) map[string]any {
+	if len(values) == 0 {
+		return nil
+	}
+	merged := map[string]any{}
+	for name, vals := range values {
+		var texts, items []string
+		var scores []int
+		for _, v := range vals {
+			switch v := v.(type) {
+			case string:
+				texts = append(texts, v)
+			case []string:
+				items = append(items, v...)
+			case int:
+				scores = append(scores, v)
+			}
+		}
+		switch {
+		case len(scores) > 0:
+			merged[name] = *averageScore(scores)
+		case len(items) > 0:
+			merged[name] = dedupeStrings(items)
+		default:
+			merged[name] = strings.Join(texts, " ")
+		}
+	}
+	return merged
+}
+
+func averageScore(scores []int) *int {
+	if len(scores) == 0 {
+		return nil
+	}
+	sum := 0
+	for _, s := range scores {
+		sum += s
+	}
+	avg := (sum + len(scores)/2) / len(scores)
+	return &avg
+}
+
+// dedupeStrings drops items that only differ in case, whitespace or
+// punctuation, keeping the first spelling. The result is never nil.
+func dedupeStrings(items []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		key := strings.Join(strings.FieldsFunc(strings.ToLower(item), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}), " ")
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, item)
+	}
+	return out
+}
+
+// extractChunked runs the map-reduce extraction: every chunk is extracted and
+// validated on its own, then a merge pass produces the final result. The
+// merge pass is relayed through onDelta; onChunk is told before each chunk
+// starts. Either callback may be nil.
+func (a *App) extractChunked(provider Provider, body extractRequest, chunks []string, onChunk func(part, total int) error, onDelta func(string) error) (ExtractionResult, error) {
+	// The prompt data is the same for every chunk but the transcript, and
+	// the whole transcript is never rendered.
+	data := a.extractionPromptData(body)
+	partials := make([]ExtractionResult, 0, len(chunks))
+	for i, chunk := range chunks {
+		if onChunk != nil {
+			if err := onChunk(i+1, len(chunks)); err != nil {
+				return ExtractionResult{}, err
+			}
+		}
+		data.Transcript = chunk
+		req := CompletionRequest{
+			Prompt:    a.buildChunkExtractionPrompt(data, i+1, len(chunks)),
+			Model:     body.Model,
+			MaxTokens: body.MaxTokens,
+		}
+		text, err := provider.Complete(req)
+		if err != nil {
+			return ExtractionResult{}, fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+		partial, err := a.validateWithRepair(provider, req, text)
+		if err != nil {
+			a.log.Printf("[AI] Skipping chunk %d/%d: %v", i+1, len(chunks), err)
+			continue
+		}
+		partial.Corrections = nil
+		partials = append(partials, partial)
+	}
+	if len(partials) == 0 {
+		return ExtractionResult{}, fmt.Errorf("no chunk could be extracted")
+	}
+
+	mergeReq := CompletionRequest{
+		Prompt:    a.buildMergePrompt(data, partials),
+		Model:     body.Model,
+		MaxTokens: body.MaxTokens,
+	}
+	if mergeReq.MaxTokens == 0 {
+		mergeReq.MaxTokens = mergeMaxTokens
+	}
+
+	var text string
+	var err error
+	if onDelta != nil {
+		text, err = streamCompletion(provider, mergeReq, onDelta)
+	} else {
+		text, err = provider.Complete(mergeReq)
+	}
+	if err == nil {
+		var merged ExtractionResult
+		if merged, err = a.validateWithRepair(provider, mergeReq, text); err == nil {
+			return merged, nil
+		}
+	}
+	a.log.Printf("[AI] Merge pass failed, merging %d chunks locally: %v", len(partials), err)
+	return mergeLocally(partials), nil
+}
@@ -0,0 +1,224 @@
+package journal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplitTurns(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript string
+		want       []string
+	}{
+		{
+			name:       "speaker labels",
+			transcript: "Dana: How was the launch?\r\nSam: Good.\nThe rollback plan helped.\n\nDana: Great.",
+			want:       []string{"Dana: How was the launch?", "Sam: Good.\nThe rollback plan helped.", "Dana: Great."},
+		},
+		{
+			name:       "timestamps and roles",
+			transcript: "[00:01:02] Jo Li: Hi.\n(1:03) Sam (report): Hey.\n10:04 Dana: Let's start.",
+			want:       []string{"[00:01:02] Jo Li: Hi.", "(1:03) Sam (report): Hey.", "10:04 Dana: Let's start."},
+		},
+		{
+			name:       "text before the first label",
+			transcript: "Recording started\nSam: Hi.",
+			want:       []string{"Recording started", "Sam: Hi."},
+		},
+		{
+			name:       "no labels splits on blank lines",
+			transcript: "First paragraph\nstill first.\n\n\nSecond: not a label because nothing follows",
+			want:       []string{"First paragraph\nstill first.", "Second: not a label because nothing follows"},
+		},
+		{
+			name:       "empty",
+			transcript: "  \n\n",
+			want:       nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitTurns(tt.transcript); fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Errorf("splitTurns = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitLongTurn(t *testing.T) {
+	tests := []struct {
+		turn string
+		max  int
+		want []string
+	}{
+		{"Sam: short", 20, []string{"Sam: short"}},
+		{"Sam: one two three four", 12, []string{"Sam: one", "two three", "four"}},
+		{"Sam: abcdefghijklmnop", 8, []string{"Sam:", "abcdefgh", "ijklmnop"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+	}
+	for _, tt := range tests {
+		got := splitLongTurn(tt.turn, tt.max)
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("splitLongTurn(%q, %d) = %q, want %q", tt.turn, tt.max, got, tt.want)
+		}
+		for _, p := range got {
+			if len(p) > tt.max {
+				t.Errorf("part %q is longer than %d", p, tt.max)
+			}
+		}
+	}
+}
+
+func TestChunkTranscript(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, fmt.Sprintf("S%d: turn number %02d.", i%2, i))
+	}
+	transcript := strings.Join(lines, "\n")
+	turnLen := len(lines[0]) + 1
+
+	chunks := chunkTranscript(transcript, 4*turnLen, 2)
+	want := [][]int{{1, 2, 3, 4}, {3, 4, 5, 6}, {5, 6, 7, 8}, {7, 8, 9, 10}}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d:\n%s", len(chunks), len(want), strings.Join(chunks, "\n---\n"))
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > 4*turnLen {
+			t.Errorf("chunk %d is %d chars, over the limit", i+1, len(chunk))
+		}
+		var nums []string
+		for _, n := range want[i] {
+			nums = append(nums, fmt.Sprintf("%02d", n))
+		}
+		got := strings.Join(turnNumbers(chunk), " ")
+		if got != strings.Join(nums, " ") {
+			t.Errorf("chunk %d has turns %s, want %s", i+1, got, strings.Join(nums, " "))
+		}
+	}
+
+	if got := chunkTranscript(transcript, len(transcript)+1, 2); len(got) != 1 || got[0] != transcript {
+		t.Errorf("a short transcript was split: %q", got)
+	}
+
+	// Overlap is dropped rather than push a chunk over the limit.
+	long := "Sam: " + strings.Repeat("x", 3*turnLen)
+	chunks = chunkTranscript(lines[0]+"\n"+lines[1]+"\n"+long, 4*turnLen, 2)
+	if len(chunks) != 2 || chunks[1] != long {
+		t.Errorf("chunks = %q, want the long turn alone in the second", chunks)
+	}
+}
+
+// turnNumbers returns the two-digit turn numbers in a chunk built by
+// TestChunkTranscript, in order.
+func turnNumbers(chunk string) []string {
+	var out []string
+	for _, line := range strings.Split(chunk, "\n") {
+		_, rest, _ := strings.Cut(line, "turn number ")
+		out = append(out, strings.TrimSuffix(rest, "."))
+	}
+	return out
+}
+
+func TestMergeLocally(t *testing.T) {
+	score := func(n int) *int { return &n }
+	merged := mergeLocally([]ExtractionResult{
+		{
+			Summary: "Talked about the launch.", MoraleScore: score(4), GrowthScore: score(2), MoraleRationale: "Upbeat.",
+			Tags: []string{"wins"}, ActionItemsMine: []string{"Share the rollout doc"}, Wins: []string{"Launch went out"},
+			CustomFields: map[string]any{"goals": "Lead a team.", "skills": []string{"Go"}, "confidence": 4},
+		},
+		{
+			Summary: "Then hiring.", MoraleScore: score(3), GrowthRationale: "Wants to interview.",
+			Tags: []string{"hiring", "Wins"}, ActionItemsMine: []string{"share the rollout doc!"}, Blockers: []string{"No headcount"},
+			CustomFields: map[string]any{"goals": "Mentor.", "skills": []string{"go", "Interviewing"}, "confidence": 2},
+		},
+		{},
+	})
+
+	got := fmt.Sprintf("%q|%d|%d|%q|%q|%q|%q|%q|%q|%q",
+		merged.Summary, *merged.MoraleScore, *merged.GrowthScore, merged.MoraleRationale, merged.GrowthRationale,
+		merged.Tags, merged.ActionItemsMine, merged.Blockers, merged.Wins, merged.NotableQuotes)
+	want := `"Talked about the launch. Then hiring."|4|2|"Upbeat."|"Wants to interview."|["wins" "hiring"]|["Share the rollout doc"]|["No headcount"]|["Launch went out"]|[]`
+	if got != want {
+		t.Errorf("merged\n got  %s\n want %s", got, want)
+	}
+	if fmt.Sprint(merged.CustomFields) != "map[confidence:3 goals:Lead a team. Mentor. skills:[Go Interviewing]]" {
+		t.Errorf("custom fields = %v", merged.CustomFields)
+	}
+
+	if empty := mergeLocally([]ExtractionResult{{Summary: "Only text."}}); empty.MoraleScore != nil || empty.CustomFields != nil || empty.Tags == nil {
+		t.Errorf("merge without scores = %+v", empty)
+	}
+}
+
+func TestExtractChunkedPrompts(t *testing.T) {
+	s := newTestServer(t, func(c *Config) { c.ExtractChunkChars = 200 })
+	var turns []string
+	for i := 1; i <= 12; i++ {
+		turns = append(turns, fmt.Sprintf("Sam: This is turn %02d of a long meeting.", i))
+	}
+	s.run(t, []apiStep{
+		{name: "extract", method: "POST", path: "/api/extract", status: 200,
+			body:  map[string]any{"transcript": strings.Join(turns, "\n"), "member_name": "Sam"},
+			check: wantContains("Mock summary", "summary")},
+	})
+
+	prompts := s.mock.prompts()
+	if len(prompts) < 3 {
+		t.Fatalf("mock was called %d times, want chunks and a merge", len(prompts))
+	}
+	chunks := prompts[:len(prompts)-1]
+	for i, p := range chunks {
+		if !strings.Contains(p, fmt.Sprintf("This is part %d of %d", i+1, len(chunks))) {
+			t.Errorf("prompt %d isn't a chunk prompt:\n%s", i+1, p)
+		}
+		if n := strings.Count(p, "This is turn"); n == 0 || n == len(turns) {
+			t.Errorf("chunk %d has %d turns", i+1, n)
+		}
+	}
+	if !strings.Contains(prompts[0], "turn 01") || strings.Contains(prompts[0], "turn 12") {
+		t.Error("the first chunk should start the transcript and not end it")
+	}
+	if !strings.Contains(prompts[len(prompts)-1], "Merge the partial extractions") {
+		t.Error("the last call isn't the merge pass")
+	}
+}
+
+func TestChunkAndMergeTemplates(t *testing.T) {
+	s := newTestServer(t, func(c *Config) { c.ExtractChunkChars = 200 })
+	var turns []string
+	for i := 1; i <= 12; i++ {
+		turns = append(turns, fmt.Sprintf("Sam: This is turn %02d of a long meeting.", i))
+	}
+	s.run(t, []apiStep{
+		{name: "list prompts", method: "GET", path: "/api/prompts", status: 200, check: wantLen(4)},
+		{name: "chunk template missing a field", method: "PUT", path: "/api/prompts/chunk", body: map[string]string{"body": "Part {{.Number}}"}, status: 400,
+			check: wantContains("invalid template", "error")},
+		{name: "save chunk template", method: "PUT", path: "/api/prompts/chunk", status: 200, check: wantField(1.0, "version"),
+			body: map[string]string{"body": "Section {{.Part}}/{{.Total}} of the meeting.\n\n{{.Extraction}}"}},
+		{name: "save merge template", method: "PUT", path: "/api/prompts/merge", status: 200, check: wantField(1.0, "version"),
+			body: map[string]string{"body": "Combine these {{len .Partials}} results about {{.Member.Name}}:\n{{range .Partials}}{{.}}\n{{end}}"}},
+		{name: "preview merge", method: "POST", path: "/api/prompts/merge/preview", body: map[string]string{"member_name": "Alex"}, status: 200,
+			check: wantContains("Combine these 2 results about Alex", "prompt")},
+		{name: "extract", method: "POST", path: "/api/extract", status: 200,
+			body:  map[string]any{"transcript": strings.Join(turns, "\n"), "member_name": "Sam"},
+			check: wantContains("Mock summary", "summary")},
+	})
+
+	prompts := s.mock.prompts()
+	if len(prompts) < 3 {
+		t.Fatalf("mock was called %d times, want chunks and a merge", len(prompts))
+	}
+	chunks := prompts[:len(prompts)-1]
+	for i, p := range chunks {
+		if !strings.HasPrefix(p, fmt.Sprintf("Section %d/%d of the meeting.", i+1, len(chunks))) || !strings.Contains(p, "This is turn") {
+			t.Errorf("prompt %d doesn't use the saved chunk template:\n%s", i+1, p)
+		}
+	}
+	merge := prompts[len(prompts)-1]
+	if want := fmt.Sprintf("Combine these %d results about Sam:", len(chunks)); !strings.HasPrefix(merge, want) || !strings.Contains(merge, `"summary"`) {
+		t.Errorf("merge prompt doesn't use the saved merge template:\n%s", merge)
+	}
+}
@@ -0,0 +1,517 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is everything an App needs to know about its surroundings. The
+// server builds one with LoadConfig from a config file and the environment;
+// code embedding the journal can fill one in directly. Zero values mean
+// "off" or "none", so start from LoadConfig or DefaultConfig to get the
+// usual defaults. configFields lists each setting's file key and variable.
+type Config struct {
+	Port string
+	// DataDir holds the database and, unless BackupDir is set, its backups.
+	DataDir string
+	// TestMode replaces DataDir with a temp directory removed by Close, turns
+	// scheduled backups off and uses the mock provider unless AIProvider is set.
+	TestMode bool
+	// ConfigFile is the file LoadConfig read, if any.
+	ConfigFile string
+
+	// AIProvider is a provider name or a comma-separated fallback chain.
+	// Empty means the local model when LocalLLMBaseURL is set, and otherwise
+	// every cloud provider that has a key.
+	AIProvider        string
+	AnthropicAPIKey   string
+	AnthropicModel    string
+	OpenAIAPIKey      string
+	OpenAIModel       string
+	LocalLLMBaseURL   string
+	LocalLLMAPI       string
+	LocalLLMModel     string
+	LocalLLMAPIKey    string
+	MockScript        string
+	ExtractChunkChars int
+	RedactTranscripts string
+
+	// MonthlyBudgetUSD is nil when there's no budget. The prices, when both
+	// are set, override the built-in price list for every model.
+	MonthlyBudgetUSD   *float64
+	PriceInputPerMTok  *float64
+	PriceOutputPerMTok *float64
+
+	// Per-attempt timeouts for outbound requests; 0 means none.
+	AnthropicTimeout time.Duration
+	OpenAITimeout    time.Duration
+	LocalLLMTimeout  time.Duration
+	JIRATimeout      time.Duration
+	HTTPMaxRetries   int
+
+	JIRABaseURL  string
+	JIRAEmail    string
+	JIRAAPIToken string
+
+	// At most one of each passphrase and keyfile pair may be set. The New
+	// pair is the key rotate-key moves to.
+	EncryptionPassphrase    string
+	EncryptionKeyfile       string
+	NewEncryptionPassphrase string
+	NewEncryptionKeyfile    string
+
+	// CacheTTLDays is how long extraction and prep results are reused.
+	CacheTTLDays int
+	// PrepLookback is how many of a member's latest entries prep briefs on.
+	PrepLookback int
+
+	// TrashRetentionDays 0 keeps trash until it's emptied, and
+	// BackupIntervalHours 0 turns scheduled backups off.
+	TrashRetentionDays  int
+	BackupDir           string
+	BackupIntervalHours int
+	BackupKeepDaily     int
+	BackupKeepWeekly    int
+
+	// Logger receives the App's log output. Nil uses the standard logger.
+	Logger *log.Logger
+}
+
+const (
+	defaultCacheTTLDays = 30
+	defaultPrepLookback = 5
+)
+
+// DefaultConfig is the configuration with no config file and nothing set in
+// the environment.
+func DefaultConfig() Config {
+	return Config{
+		Port:                "3001",
+		DataDir:             defaultDataDir(),
+		AnthropicModel:      defaultAnthropicModel,
+		OpenAIModel:         defaultOpenAIModel,
+		LocalLLMAPI:         "openai",
+		LocalLLMModel:       defaultLocalModel,
+		ExtractChunkChars:   defaultChunkChars,
+		RedactTranscripts:   "cloud",
+		AnthropicTimeout:    180 * time.Second,
+		OpenAITimeout:       180 * time.Second,
+		LocalLLMTimeout:     600 * time.Second,
+		JIRATimeout:         20 * time.Second,
+		HTTPMaxRetries:      defaultMaxRetries,
+		CacheTTLDays:        defaultCacheTTLDays,
+		PrepLookback:        defaultPrepLookback,
+		TrashRetentionDays:  defaultTrashRetentionDays,
+		BackupIntervalHours: defaultBackupIntervalHours,
+		BackupKeepDaily:     defaultBackupKeepDaily,
+		BackupKeepWeekly:    defaultBackupKeepWeekly,
+	}
+}
+
+// LoadConfig reads the TOML file at path, if path isn't empty, over
+// DefaultConfig, and then the environment variables documented in
+// .env.example over that. Values that don't parse are errors; unset and
+// placeholder variables are skipped. Call Validate, or New, which does, to
+// check the result as a whole.
+func LoadConfig(path string) (Config, error) {
+	c := DefaultConfig()
+	if path != "" {
+		if err := c.loadFile(path); err != nil {
+			return c, err
+		}
+		c.ConfigFile = path
+	}
+	var errs []error
+	for _, f := range configFields {
+		v := os.Getenv(f.env)
+		if v == "" || v == "your-key-here" {
+			continue
+		}
+		if err := f.set(&c, v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+		}
+	}
+	return c, errors.Join(errs...)
+}
+
+// Set sets the setting with the given file key, e.g. "jira.base_url", from
+// its text form, as a flag would.
+func (c *Config) Set(key, value string) error {
+	f, ok := configFieldByKey(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return f.set(c, value)
+}
+
+// Validate checks the settings together and reports every problem found,
+// one per line.
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		f, _ := configFieldByKey(key)
+		errs = append(errs, fmt.Errorf("%s (%s): %s", key, f.env, fmt.Sprintf(format, args...)))
+	}
+
+	for _, f := range configFields {
+		switch v := f.ptr(&c).(type) {
+		case *int:
+			if *v < 0 {
+				fail(f.key, "must not be negative, got %d", *v)
+			}
+		case *time.Duration:
+			if *v < 0 {
+				fail(f.key, "must not be negative, got %s", *v)
+			}
+		case **float64:
+			if *v != nil && **v < 0 {
+				fail(f.key, "must not be negative, got %v", **v)
+			}
+		}
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		fail("port", "must be a port number from 1 to 65535, got %q", c.Port)
+	}
+	if c.DataDir == "" && !c.TestMode {
+		fail("data_dir", "must be set")
+	}
+
+	for _, name := range strings.Split(c.AIProvider, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := providerConstructors[name]; name != "" && !ok {
+			fail("ai.provider", "unknown provider %q (want anthropic, openai, local or mock)", name)
+		}
+	}
+	switch strings.ToLower(c.RedactTranscripts) {
+	case "", "cloud", "always", "off":
+	default:
+		fail("ai.redact_transcripts", "must be cloud, always or off, got %q", c.RedactTranscripts)
+	}
+	if (c.PriceInputPerMTok == nil) != (c.PriceOutputPerMTok == nil) {
+		fail("ai.price_input_per_mtok", "set both or neither of the input and output prices")
+	}
+
+	if c.LocalLLMBaseURL != "" {
+		if err := checkHTTPURL(c.LocalLLMBaseURL); err != nil {
+			fail("local.base_url", "%v", err)
+		}
+		switch strings.ToLower(c.LocalLLMAPI) {
+		case "", "openai", "ollama":
+		default:
+			fail("local.api", "must be openai or ollama, got %q", c.LocalLLMAPI)
+		}
+	}
+
+	if c.JIRABaseURL != "" || c.JIRAEmail != "" || c.JIRAAPIToken != "" {
+		var missing []string
+		for key, v := range map[string]string{"jira.base_url": c.JIRABaseURL, "jira.email": c.JIRAEmail, "jira.api_token": c.JIRAAPIToken} {
+			if v == "" {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			slices.Sort(missing)
+			fail("jira.base_url", "JIRA needs a base URL, email and API token; missing %s", strings.Join(missing, ", "))
+		}
+		if c.JIRABaseURL != "" {
+			if err := checkHTTPURL(c.JIRABaseURL); err != nil {
+				fail("jira.base_url", "%v", err)
+			}
+		}
+	}
+
+	if c.EncryptionPassphrase != "" && c.EncryptionKeyfile != "" {
+		fail("encryption.passphrase", "set only one of the passphrase and the keyfile")
+	}
+	if c.NewEncryptionPassphrase != "" && c.NewEncryptionKeyfile != "" {
+		fail("encryption.new_passphrase", "set only one of the new passphrase and the new keyfile")
+	}
+	if c.CacheTTLDays < 1 {
+		fail("cache.ttl_days", "must be at least 1, got %d", c.CacheTTLDays)
+	}
+	if c.PrepLookback < 1 {
+		fail("prep.lookback_entries", "must be at least 1, got %d", c.PrepLookback)
+	}
+	return errors.Join(errs...)
+}
+
+func checkHTTPURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL, got %q", s)
+	}
+	return nil
+}
+
+// defaultDataDir is the platform's per-user application data directory.
+func defaultDataDir() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "People Journal")
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), "People Journal")
+	default:
+		// Linux / other Unix
+		if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+			return filepath.Join(xdg, "people-journal")
+		}
+		return filepath.Join(os.Getenv("HOME"), ".local", "share", "people-journal")
+	}
+}
+
+// jiraConfigured reports whether all three JIRA settings are present.
+func (c Config) jiraConfigured() bool {
+	return c.JIRABaseURL != "" && c.JIRAEmail != "" && c.JIRAAPIToken != ""
+}
+
+// ─── Settings ───────────────────────────────────────────
+
+// configField ties a Config field to its key in the config file, written
+// section.name, and its environment variable. Secret fields are redacted by
+// /api/config.
+type configField struct {
+	key    string
+	env    string
+	secret bool
+	// ptr returns a *string, *int, *bool, *time.Duration (given in seconds)
+	// or **float64 (nil when empty) pointing into c.
+	ptr func(c *Config) any
+}
+
+var configFields = []configField{
+	{key: "port", env: "PORT", ptr: func(c *Config) any { return &c.Port }},
+	{key: "data_dir", env: "DATA_DIR", ptr: func(c *Config) any { return &c.DataDir }},
+	{key: "test_mode", env: "TEST_MODE", ptr: func(c *Config) any { return &c.TestMode }},
+
+	{key: "ai.provider", env: "AI_PROVIDER", ptr: func(c *Config) any { return &c.AIProvider }},
+	{key: "ai.redact_transcripts", env: "REDACT_TRANSCRIPTS", ptr: func(c *Config) any { return &c.RedactTranscripts }},
+	{key: "ai.extract_chunk_chars", env: "EXTRACT_CHUNK_CHARS", ptr: func(c *Config) any { return &c.ExtractChunkChars }},
+	{key: "ai.monthly_budget_usd", env: "AI_MONTHLY_BUDGET_USD", ptr: func(c *Config) any { return &c.MonthlyBudgetUSD }},
+	{key: "ai.price_input_per_mtok", env: "AI_PRICE_INPUT_PER_MTOK", ptr: func(c *Config) any { return &c.PriceInputPerMTok }},
+	{key: "ai.price_output_per_mtok", env: "AI_PRICE_OUTPUT_PER_MTOK", ptr: func(c *Config) any { return &c.PriceOutputPerMTok }},
+
+	{key: "anthropic.api_key", env: "ANTHROPIC_API_KEY", secret: true, ptr: func(c *Config) any { return &c.AnthropicAPIKey }},
+	{key: "anthropic.model", env: "ANTHROPIC_MODEL", ptr: func(c *Config) any { return &c.AnthropicModel }},
+	{key: "anthropic.timeout_seconds", env: "ANTHROPIC_TIMEOUT_SECONDS", ptr: func(c *Config) any { return &c.AnthropicTimeout }},
+	{key: "openai.api_key", env: "OPENAI_API_KEY", secret: true, ptr: func(c *Config) any { return &c.OpenAIAPIKey }},
+	{key: "openai.model", env: "OPENAI_MODEL", ptr: func(c *Config) any { return &c.OpenAIModel }},
+	{key: "openai.timeout_seconds", env: "OPENAI_TIMEOUT_SECONDS", ptr: func(c *Config) any { return &c.OpenAITimeout }},
+	{key: "local.base_url", env: "LOCAL_LLM_BASE_URL", ptr: func(c *Config) any { return &c.LocalLLMBaseURL }},
+	{key: "local.api", env: "LOCAL_LLM_API", ptr: func(c *Config) any { return &c.LocalLLMAPI }},
+	{key: "local.model", env: "LOCAL_LLM_MODEL", ptr: func(c *Config) any { return &c.LocalLLMModel }},
+	{key: "local.api_key", env: "LOCAL_LLM_API_KEY", secret: true, ptr: func(c *Config) any { return &c.LocalLLMAPIKey }},
+	{key: "local.timeout_seconds", env: "LOCAL_LLM_TIMEOUT_SECONDS", ptr: func(c *Config) any { return &c.LocalLLMTimeout }},
+	{key: "mock.script", env: "MOCK_AI_SCRIPT", ptr: func(c *Config) any { return &c.MockScript }},
+	{key: "http.max_retries", env: "HTTP_MAX_RETRIES", ptr: func(c *Config) any { return &c.HTTPMaxRetries }},
+
+	{key: "jira.base_url", env: "JIRA_BASE_URL", ptr: func(c *Config) any { return &c.JIRABaseURL }},
+	{key: "jira.email", env: "JIRA_EMAIL", ptr: func(c *Config) any { return &c.JIRAEmail }},
+	{key: "jira.api_token", env: "JIRA_API_TOKEN", secret: true, ptr: func(c *Config) any { return &c.JIRAAPIToken }},
+	{key: "jira.timeout_seconds", env: "JIRA_TIMEOUT_SECONDS", ptr: func(c *Config) any { return &c.JIRATimeout }},
+
+	{key: "encryption.passphrase", env: "DB_ENCRYPTION_PASSPHRASE", secret: true, ptr: func(c *Config) any { return &c.EncryptionPassphrase }},
+	{key: "encryption.keyfile", env: "DB_ENCRYPTION_KEYFILE", ptr: func(c *Config) any { return &c.EncryptionKeyfile }},
+	{key: "encryption.new_passphrase", env: "DB_ENCRYPTION_NEW_PASSPHRASE", secret: true, ptr: func(c *Config) any { return &c.NewEncryptionPassphrase }},
+	{key: "encryption.new_keyfile", env: "DB_ENCRYPTION_NEW_KEYFILE", ptr: func(c *Config) any { return &c.NewEncryptionKeyfile }},
+
+	{key: "cache.ttl_days", env: "CACHE_TTL_DAYS", ptr: func(c *Config) any { return &c.CacheTTLDays }},
+	{key: "prep.lookback_entries", env: "PREP_LOOKBACK_ENTRIES", ptr: func(c *Config) any { return &c.PrepLookback }},
+	{key: "trash.retention_days", env: "TRASH_RETENTION_DAYS", ptr: func(c *Config) any { return &c.TrashRetentionDays }},
+	{key: "backup.dir", env: "BACKUP_DIR", ptr: func(c *Config) any { return &c.BackupDir }},
+	{key: "backup.interval_hours", env: "BACKUP_INTERVAL_HOURS", ptr: func(c *Config) any { return &c.BackupIntervalHours }},
+	{key: "backup.keep_daily", env: "BACKUP_KEEP_DAILY", ptr: func(c *Config) any { return &c.BackupKeepDaily }},
+	{key: "backup.keep_weekly", env: "BACKUP_KEEP_WEEKLY", ptr: func(c *Config) any { return &c.BackupKeepWeekly }},
+}
+
+func configFieldByKey(key string) (configField, bool) {
+	for _, f := range configFields {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return configField{}, false
+}
+
+// set parses v into the field.
+func (f configField) set(c *Config, v string) error {
+	switch p := f.ptr(c).(type) {
+	case *string:
+		*p = v
+	case *bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", v)
+		}
+		*p = b
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%q is not a whole number of 0 or more", v)
+		}
+		*p = n
+	case *time.Duration:
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%q is not a whole number of seconds", v)
+		}
+		*p = time.Duration(n) * time.Second
+	case **float64:
+		if v == "" {
+			*p = nil
+			return nil
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%q is not a number of 0 or more", v)
+		}
+		*p = &n
+	}
+	return nil
+}
+
+// redacted is the configuration as it would be written in the config file,
+// grouped by section, with secrets replaced by "[redacted]" when set.
+func (c Config) redacted() map[string]any {
+	out := map[string]any{"config_file": c.ConfigFile}
+	for _, f := range configFields {
+		var v any
+		switch p := f.ptr(&c).(type) {
+		case *string:
+			v = *p
+			if f.secret && *p != "" {
+				v = "[redacted]"
+			}
+		case *bool:
+			v = *p
+		case *int:
+			v = *p
+		case *time.Duration:
+			v = int(*p / time.Second)
+		case **float64:
+			if *p != nil {
+				v = **p
+			}
+		}
+		section, name, ok := strings.Cut(f.key, ".")
+		if !ok {
+			out[f.key] = v
+			continue
+		}
+		m, _ := out[section].(map[string]any)
+		if m == nil {
+			m = map[string]any{}
+			out[section] = m
+		}
+		m[name] = v
+	}
+	return out
+}
+
+// ─── Config File ────────────────────────────────────────
+
+// loadFile reads the TOML subset the config file needs: [section] headers,
+// key = value lines with strings, numbers and booleans, and # comments.
+// Keys are those of configFields; a key before any section is top-level.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	var errs []error
+	section := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("%s:%d: %s", path, i+1, fmt.Sprintf(format, args...)))
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			name, ok := strings.CutSuffix(stripComment(line), "]")
+			if !ok {
+				fail("malformed section header %s", line)
+				continue
+			}
+			section = strings.TrimSpace(name[1:])
+			continue
+		}
+		name, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			fail("expected key = value, got %s", line)
+			continue
+		}
+		key := strings.TrimSpace(name)
+		if section != "" {
+			key = section + "." + key
+		}
+		f, ok := configFieldByKey(key)
+		if !ok {
+			fail("unknown setting %q", key)
+			continue
+		}
+		value, err := parseTOMLValue(strings.TrimSpace(raw))
+		if err != nil {
+			fail("%s: %v", key, err)
+			continue
+		}
+		if err := f.set(c, value); err != nil {
+			fail("%s: %v", key, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseTOMLValue returns the text of a string, number or boolean value,
+// without its quotes or trailing comment.
+func parseTOMLValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		end := 0
+		for i := 1; i < len(raw) && end == 0; i++ {
+			switch raw[i] {
+			case '\\':
+				i++
+			case '"':
+				end = i
+			}
+		}
+		if end == 0 || stripComment(raw[end+1:]) != "" {
+			return "", fmt.Errorf("malformed string %s", raw)
+		}
+		s, err := strconv.Unquote(raw[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("malformed string %s", raw)
+		}
+		return s, nil
+	case strings.HasPrefix(raw, "'"):
+		end := strings.Index(raw[1:], "'") + 1
+		if end <= 0 || stripComment(raw[end+1:]) != "" {
+			return "", fmt.Errorf("malformed string %s", raw)
+		}
+		return raw[1:end], nil
+	}
+	v := stripComment(raw)
+	if v == "" {
+		return "", errors.New("missing value")
+	}
+	return strings.ReplaceAll(v, "_", ""), nil
+}
+
+// stripComment drops a trailing # comment from a line without strings.
+func stripComment(s string) string {
+	if i := strings.Index(s, "#"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
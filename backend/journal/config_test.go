@@ -0,0 +1,204 @@
+package journal
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearConfigEnv unsets every variable LoadConfig reads so tests don't pick
+// up settings from the developer's environment.
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, f := range configFields {
+		t.Setenv(f.env, "")
+	}
+}
+
+func writeConfigFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "people-journal.toml")
+	if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeConfigFile(t, `
+# People Journal
+port = 4000
+data_dir = "/srv/journal"  # trailing comment
+
+[ai]
+provider = "anthropic, openai"
+monthly_budget_usd = 12.5
+
+[anthropic]
+api_key = "sk-\"quoted\""
+timeout_seconds = 1_200
+
+[jira]
+base_url = 'https://example.atlassian.net'
+email = "me@example.com"
+api_token = "from-file"
+
+[cache]
+ttl_days = 7
+
+[prep]
+lookback_entries = 3
+`)
+	t.Setenv("JIRA_API_TOKEN", "from-env")
+	t.Setenv("OPENAI_API_KEY", "your-key-here")
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if c.Port != "4000" || c.DataDir != "/srv/journal" || c.ConfigFile != path {
+		t.Errorf("port=%q data_dir=%q config_file=%q", c.Port, c.DataDir, c.ConfigFile)
+	}
+	if c.AIProvider != "anthropic, openai" || c.MonthlyBudgetUSD == nil || *c.MonthlyBudgetUSD != 12.5 {
+		t.Errorf("provider=%q budget=%v", c.AIProvider, c.MonthlyBudgetUSD)
+	}
+	if c.AnthropicAPIKey != `sk-"quoted"` || c.AnthropicTimeout != 1200*time.Second {
+		t.Errorf("anthropic key=%q timeout=%s", c.AnthropicAPIKey, c.AnthropicTimeout)
+	}
+	if c.JIRAAPIToken != "from-env" || c.JIRAEmail != "me@example.com" {
+		t.Errorf("jira token=%q email=%q, want the environment over the file", c.JIRAAPIToken, c.JIRAEmail)
+	}
+	if c.OpenAIAPIKey != "" {
+		t.Errorf("placeholder key was used: %q", c.OpenAIAPIKey)
+	}
+	if c.CacheTTLDays != 7 || c.PrepLookback != 3 || c.OpenAIModel != defaultOpenAIModel {
+		t.Errorf("ttl=%d lookback=%d openai model=%q", c.CacheTTLDays, c.PrepLookback, c.OpenAIModel)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	if err := c.Set("prep.lookback_entries", "10"); err != nil || c.PrepLookback != 10 {
+		t.Errorf("Set = %v, lookback %d", err, c.PrepLookback)
+	}
+	if err := c.Set("prep.lookback", "10"); err == nil {
+		t.Error("Set accepted an unknown setting")
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeConfigFile(t, `port = 4000
+[jira]
+bse_url = "https://example.atlassian.net"
+timeout_seconds = "soon"
+[ai]
+provider = "unterminated
+`)
+	t.Setenv("HTTP_MAX_RETRIES", "-1")
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("LoadConfig accepted a bad file")
+	}
+	for _, want := range []string{
+		path + `:3: unknown setting "jira.bse_url"`,
+		path + `:4: jira.timeout_seconds: "soon" is not a whole number of seconds`,
+		path + `:6: ai.provider: malformed string`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error is missing %q:\n%v", want, err)
+		}
+	}
+
+	_, err = LoadConfig("")
+	if err == nil || !strings.Contains(err.Error(), `HTTP_MAX_RETRIES: "-1" is not a whole number`) {
+		t.Errorf("err = %v, want the bad variable named", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{name: "defaults"},
+		{name: "bad port", modify: func(c *Config) { c.Port = "http" }, want: "port (PORT): must be a port number"},
+		{name: "unknown provider", modify: func(c *Config) { c.AIProvider = "anthropic,gemini" }, want: `unknown provider "gemini"`},
+		{name: "bad redaction mode", modify: func(c *Config) { c.RedactTranscripts = "sometimes" }, want: "ai.redact_transcripts (REDACT_TRANSCRIPTS)"},
+		{name: "one price", modify: func(c *Config) { c.PriceInputPerMTok = new(float64) }, want: "set both or neither"},
+		{name: "bad local api", modify: func(c *Config) {
+			c.LocalLLMBaseURL = "http://localhost:11434"
+			c.LocalLLMAPI = "grpc"
+		}, want: "local.api (LOCAL_LLM_API): must be openai or ollama"},
+		{name: "partial jira", modify: func(c *Config) { c.JIRABaseURL = "https://example.atlassian.net" },
+			want: "missing jira.api_token, jira.email"},
+		{name: "jira url without scheme", modify: func(c *Config) {
+			c.JIRABaseURL, c.JIRAEmail, c.JIRAAPIToken = "example.atlassian.net", "me@example.com", "token"
+		}, want: "must be an http or https URL"},
+		{name: "two encryption keys", modify: func(c *Config) { c.EncryptionPassphrase, c.EncryptionKeyfile = "pass", "key" },
+			want: "set only one of the passphrase and the keyfile"},
+		{name: "two new encryption keys", modify: func(c *Config) { c.NewEncryptionPassphrase, c.NewEncryptionKeyfile = "pass", "key" },
+			want: "encryption.new_passphrase (DB_ENCRYPTION_NEW_PASSPHRASE): set only one of the new passphrase and the new keyfile"},
+		{name: "zero cache ttl", modify: func(c *Config) { c.CacheTTLDays = 0 }, want: "cache.ttl_days (CACHE_TTL_DAYS): must be at least 1"},
+		{name: "zero lookback", modify: func(c *Config) { c.PrepLookback = 0 }, want: "prep.lookback_entries (PREP_LOOKBACK_ENTRIES): must be at least 1"},
+		{name: "negative timeout", modify: func(c *Config) { c.JIRATimeout = -time.Second }, want: "jira.timeout_seconds (JIRA_TIMEOUT_SECONDS): must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			if tt.modify != nil {
+				tt.modify(&c)
+			}
+			err := c.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigEndpointRedactsSecrets(t *testing.T) {
+	a := testApp(t, func(c *Config) {
+		c.AnthropicAPIKey = "sk-ant-secret"
+		c.JIRABaseURL, c.JIRAEmail, c.JIRAAPIToken = "https://example.atlassian.net", "me@example.com", "jira-secret"
+		c.PrepLookback = 8
+	})
+
+	rec := httptest.NewRecorder()
+	a.handleGetConfig(rec, httptest.NewRequest("GET", "/api/config", nil))
+	body := rec.Body.String()
+	if strings.Contains(body, "sk-ant-secret") || strings.Contains(body, "jira-secret") {
+		t.Fatalf("secret in /api/config: %s", body)
+	}
+
+	var config struct {
+		Settings map[string]any `json:"settings"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &config)
+	for _, check := range []struct {
+		got, want any
+	}{
+		{field(config.Settings, "anthropic", "api_key"), "[redacted]"},
+		{field(config.Settings, "openai", "api_key"), ""},
+		{field(config.Settings, "jira", "email"), "me@example.com"},
+		{field(config.Settings, "prep", "lookback_entries"), 8.0},
+		{field(config.Settings, "jira", "timeout_seconds"), 20.0},
+	} {
+		if check.got != check.want {
+			t.Errorf("got %v, want %v in %v", check.got, check.want, config.Settings)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Custom fields are things the manager wants extraction to pick out beyond
+// the built-in fields, such as career goals or attrition risk. Definitions
+// live in the custom_fields table; values are stored per entry in the
+// entries.custom_fields JSON object, keyed by field name. Only active fields
+// are asked for in extraction; archived ones stay on existing entries.
+
+const (
+	fieldText  = "text"
+	fieldList  = "list"
+	fieldScore = "score"
+)
+
+// customFieldName is the shape of a field name. Names are JSON keys in the
+// extraction output and on entries, so they're kept to identifiers.
+var customFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+type CustomField struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Instructions string `json:"instructions"`
+	Archived     bool   `json:"archived"`
+	EntryCount   int    `json:"entry_count"`
+}
+
+// entryHasField matches entries (aliased e) with a value for the field name
+// bound to the placeholder.
+const entryHasField = "json_type(e.custom_fields, '$.' || ?) IS NOT NULL"
+
+const customFieldSelect = `
+	SELECT f.id, f.name, f.type, f.instructions, f.archived,
+		(SELECT COUNT(*) FROM entries e WHERE e.deleted_at IS NULL
+			AND json_type(e.custom_fields, '$.' || f.name) IS NOT NULL)
+	FROM custom_fields f`
+
+func scanCustomField(row interface{ Scan(...any) error }) (CustomField, error) {
+	var f CustomField
+	err := row.Scan(&f.ID, &f.Name, &f.Type, &f.Instructions, &f.Archived, &f.EntryCount)
+	return f, err
+}
+
+func loadCustomFields(q dbtx, includeArchived bool) ([]CustomField, error) {
+	where := " WHERE f.archived = 0"
+	if includeArchived {
+		where = ""
+	}
+	rows, err := q.Query(customFieldSelect + where + " ORDER BY f.position, f.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	fields := []CustomField{}
+	for rows.Next() {
+		f, err := scanCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, rows.Err()
+}
+
+// activeCustomFields are the fields extraction asks for. Without a database,
+// or if the table can't be read, there are none.
+func (a *App) activeCustomFields() []CustomField {
+	if a.db == nil {
+		return nil
+	}
+	fields, err := loadCustomFields(a.db, false)
+	if err != nil {
+		a.log.Printf("Failed to load custom fields: %v", err)
+		return nil
+	}
+	return fields
+}
+
+func validateCustomField(name, fieldType, instructions string) error {
+	if !customFieldName.MatchString(name) {
+		return fmt.Errorf("name must be lowercase letters, digits and underscores, starting with a letter (e.g. career_goals)")
+	}
+	switch fieldType {
+	case fieldText, fieldList, fieldScore:
+	default:
+		return fmt.Errorf("type must be text, list or score")
+	}
+	if instructions == "" {
+		return fmt.Errorf("instructions are required")
+	}
+	return nil
+}
+
+// ─── Entry values ───────────────────────────────────────
+
+// parseCustomValues reads an entries.custom_fields column. The result is
+// never nil.
+func parseCustomValues(s string) map[string]any {
+	values := map[string]any{}
+	if s != "" {
+		json.Unmarshal([]byte(s), &values)
+	}
+	return values
+}
+
+func customValuesJSON(values map[string]any) string {
+	if values == nil {
+		return "{}"
+	}
+	return jsonStringify(values)
+}
+
+// normalizeCustomValue checks a value from a request against its field's
+// type. Empty values (null, "", [] ) come back as nil, meaning "unset".
+func normalizeCustomValue(f CustomField, val any) (any, error) {
+	if val == nil {
+		return nil, nil
+	}
+	switch f.Type {
+	case fieldText:
+		s, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a string", f.Name)
+		}
+		if s = strings.TrimSpace(s); s == "" {
+			return nil, nil
+		}
+		return s, nil
+	case fieldList:
+		items, ok := val.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an array of strings", f.Name)
+		}
+		out := []string{}
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be an array of strings", f.Name)
+			}
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+		if len(out) == 0 {
+			return nil, nil
+		}
+		return out, nil
+	default:
+		n, ok := val.(float64)
+		if !ok || n != math.Trunc(n) || n < 1 || n > 5 {
+			return nil, fmt.Errorf("%s must be an integer from 1 to 5", f.Name)
+		}
+		return int(n), nil
+	}
+}
+
+// mergeCustomValues applies the custom_fields object from a request to an
+// entry's current values: fields in patch are set, and fields set to null or
+// left empty are removed. Every key must be one of fields, which should
+// include archived fields so existing values stay editable.
+func mergeCustomValues(fields []CustomField, current map[string]any, patch any) (map[string]any, error) {
+	obj, ok := patch.(map[string]any)
+	if patch != nil && !ok {
+		return nil, fmt.Errorf("custom_fields must be an object")
+	}
+	byName := map[string]CustomField{}
+	for _, f := range fields {
+		byName[f.Name] = f
+	}
+
+	out := map[string]any{}
+	for k, v := range current {
+		out[k] = v
+	}
+	for name, val := range obj {
+		f, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown custom field %q", name)
+		}
+		v, err := normalizeCustomValue(f, val)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			delete(out, name)
+		} else {
+			out[name] = v
+		}
+	}
+	return out, nil
+}
+
+// ─── Handlers ───────────────────────────────────────────
+
+// handleGetCustomFields lists the field definitions in display order, with
+// how many entries have a value for each. Archived fields are left out
+// unless include_archived=true.
+func (a *App) handleGetCustomFields(w http.ResponseWriter, r *http.Request) {
+	fields, err := loadCustomFields(a.db, r.URL.Query().Get("include_archived") == "true")
+	if err != nil {
+		a.log.Printf("Failed to load custom fields: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, fields)
+}
+
+func (a *App) handleCreateCustomField(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name         string `json:"name"`
+		Type         string `json:"type"`
+		Instructions string `json:"instructions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return
+	}
+	body.Instructions = strings.TrimSpace(body.Instructions)
+	if err := validateCustomField(body.Name, body.Type, body.Instructions); err != nil {
+		writeJSON(w, 400, map[string]string{"error": err.Error()})
+		return
+	}
+	var taken int
+	a.db.QueryRow("SELECT COUNT(*) FROM custom_fields WHERE name = ?", body.Name).Scan(&taken)
+	if taken > 0 {
+		writeJSON(w, 409, map[string]string{"error": fmt.Sprintf("a custom field named %q already exists", body.Name)})
+		return
+	}
+
+	id := fmt.Sprintf("field-%d", time.Now().UnixMilli())
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := a.db.Exec(`
+		INSERT INTO custom_fields (id, name, type, instructions, archived, position, created_at, updated_at)
+		VALUES (?, ?, ?, ?, 0, (SELECT COALESCE(MAX(position), 0) + 1 FROM custom_fields), ?, ?)`,
+		id, body.Name, body.Type, body.Instructions, now, now)
+	if err != nil {
+		a.log.Printf("Failed to create custom field %q: %v", body.Name, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to create custom field"})
+		return
+	}
+	a.clearExtractionCache()
+
+	f, err := scanCustomField(a.db.QueryRow(customFieldSelect+" WHERE f.id = ?", id))
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "failed to read created custom field"})
+		return
+	}
+	writeJSON(w, 201, f)
+}
+
+// handleUpdateCustomField changes any of name, type, instructions and
+// archived. Name and type can only change while no entry has a value for
+// the field, since stored values are keyed by name and shaped by type.
+func (a *App) handleUpdateCustomField(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var body struct {
+		Name         *string `json:"name"`
+		Type         *string `json:"type"`
+		Instructions *string `json:"instructions"`
+		Archived     *bool   `json:"archived"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return
+	}
+
+	f, err := scanCustomField(a.db.QueryRow(customFieldSelect+" WHERE f.id = ?", id))
+	if err != nil {
+		writeJSON(w, 404, map[string]string{"error": "custom field not found"})
+		return
+	}
+	var used int
+	a.db.QueryRow("SELECT COUNT(*) FROM entries e WHERE "+entryHasField, f.Name).Scan(&used)
+
+	renamed := body.Name != nil && *body.Name != f.Name
+	retyped := body.Type != nil && *body.Type != f.Type
+	if (renamed || retyped) && used > 0 {
+		writeJSON(w, 409, map[string]string{"error": fmt.Sprintf("%q has values on %d entries, so its name and type can't change; archive it and create a new field", f.Name, used)})
+		return
+	}
+	if body.Name != nil {
+		f.Name = *body.Name
+	}
+	if body.Type != nil {
+		f.Type = *body.Type
+	}
+	if body.Instructions != nil {
+		f.Instructions = strings.TrimSpace(*body.Instructions)
+	}
+	if body.Archived != nil {
+		f.Archived = *body.Archived
+	}
+	if err := validateCustomField(f.Name, f.Type, f.Instructions); err != nil {
+		writeJSON(w, 400, map[string]string{"error": err.Error()})
+		return
+	}
+	if renamed {
+		var taken int
+		a.db.QueryRow("SELECT COUNT(*) FROM custom_fields WHERE name = ? AND id != ?", f.Name, id).Scan(&taken)
+		if taken > 0 {
+			writeJSON(w, 409, map[string]string{"error": fmt.Sprintf("a custom field named %q already exists", f.Name)})
+			return
+		}
+	}
+
+	_, err = a.db.Exec("UPDATE custom_fields SET name = ?, type = ?, instructions = ?, archived = ?, updated_at = ? WHERE id = ?",
+		f.Name, f.Type, f.Instructions, f.Archived, time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		a.log.Printf("Failed to update custom field %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to update custom field"})
+		return
+	}
+	a.clearExtractionCache()
+
+	if f, err = scanCustomField(a.db.QueryRow(customFieldSelect+" WHERE f.id = ?", id)); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, f)
+}
+
+// handleDeleteCustomField deletes a field no entry has a value for, trash
+// included. Fields in use have to be archived instead.
+func (a *App) handleDeleteCustomField(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var name string
+	if err := a.db.QueryRow("SELECT name FROM custom_fields WHERE id = ?", id).Scan(&name); err != nil {
+		writeJSON(w, 404, map[string]string{"error": "custom field not found"})
+		return
+	}
+	var used int
+	a.db.QueryRow("SELECT COUNT(*) FROM entries e WHERE "+entryHasField, name).Scan(&used)
+	if used > 0 {
+		writeJSON(w, 409, map[string]string{"error": fmt.Sprintf("%q has values on %d entries; archive it instead", name, used)})
+		return
+	}
+	if _, err := a.db.Exec("DELETE FROM custom_fields WHERE id = ?", id); err != nil {
+		a.log.Printf("Failed to delete custom field %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to delete custom field"})
+		return
+	}
+	a.clearExtractionCache()
+	writeJSON(w, 200, map[string]bool{"deleted": true})
+}
+
+func (a *App) customFieldExists(name string) bool {
+	var n int
+	a.db.QueryRow("SELECT COUNT(*) FROM custom_fields WHERE name = ?", name).Scan(&n)
+	return n > 0
+}
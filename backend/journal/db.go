@@ -0,0 +1,214 @@
+package journal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+type TeamMember struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Role          string  `json:"role"`
+	Color         string  `json:"color"`
+	JiraAccountID *string `json:"jira_account_id"`
+	PrepNotes     *string `json:"prep_notes"`
+}
+
+type ActionItem struct {
+	ID        string  `json:"id,omitempty"`
+	Text      string  `json:"text"`
+	Completed bool    `json:"completed"`
+	DueDate   *string `json:"due_date,omitempty"`
+}
+
+type Entry struct {
+	ID                string         `json:"id"`
+	MemberID          string         `json:"member_id"`
+	Date              string         `json:"date"`
+	Summary           *string        `json:"summary"`
+	MoraleScore       *int           `json:"morale_score"`
+	GrowthScore       *int           `json:"growth_score"`
+	MoraleRationale   *string        `json:"morale_rationale"`
+	GrowthRationale   *string        `json:"growth_rationale"`
+	Tags              []string       `json:"tags"`
+	ActionItemsMine   []ActionItem   `json:"action_items_mine"`
+	ActionItemsTheirs []ActionItem   `json:"action_items_theirs"`
+	NotableQuotes     []string       `json:"notable_quotes"`
+	Blockers          []string       `json:"blockers"`
+	Wins              []string       `json:"wins"`
+	PrivateNote       *string        `json:"private_note"`
+	Transcript        *string        `json:"transcript"`
+	CustomFields      map[string]any `json:"custom_fields"`
+	CreatedAt         *string        `json:"created_at"`
+	UpdatedAt         *string        `json:"updated_at"`
+}
+
+// openDB opens the SQLite database at path and migrates it to the current
+// schema version, logging each migration applied.
+func openDB(path string, logger *log.Logger) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if _, err = db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("set WAL mode: %w", err)
+	}
+	if _, err = db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("enable foreign keys: %w", err)
+	}
+
+	if err := migrate(db, logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// seedTeam adds placeholder team members to an empty database.
+func (a *App) seedTeam() error {
+	var count int
+	if err := a.db.QueryRow("SELECT COUNT(*) FROM team_members").Scan(&count); err != nil {
+		return fmt.Errorf("count team members: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	defaults := [][]string{
+		{"member-1", "Engineer 1", "Engineer", "#E07A5F"},
+		{"member-2", "Engineer 2", "Engineer", "#3D405B"},
+		{"member-3", "Engineer 3", "Engineer", "#81B29A"},
+		{"member-4", "Engineer 4", "Engineer", "#F2CC8F"},
+	}
+	for _, m := range defaults {
+		if _, err := a.db.Exec("INSERT INTO team_members (id, name, role, color) VALUES (?, ?, ?, ?)",
+			m[0], m[1], m[2], m[3]); err != nil {
+			a.log.Printf("Failed to seed team member %s: %v", m[1], err)
+		}
+	}
+	return nil
+}
+
+func parseJSONArray(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	var arr []string
+	if err := json.Unmarshal([]byte(s), &arr); err != nil {
+		return []string{}
+	}
+	return arr
+}
+
+func parseActionItems(s string) []ActionItem {
+	if s == "" {
+		return []ActionItem{}
+	}
+	var items []ActionItem
+	if err := json.Unmarshal([]byte(s), &items); err != nil {
+		return []ActionItem{}
+	}
+	return items
+}
+
+func (a *App) scanEntry(row interface{ Scan(...any) error }) (Entry, error) {
+	var e Entry
+	var tags, actionMine, actionTheirs, quotes, blockers, wins sql.NullString
+	var summary, moraleRat, growthRat, privateNote sql.NullString
+	var transcript, createdAt, updatedAt, customFields sql.NullString
+	var moraleScore, growthScore sql.NullInt64
+
+	err := row.Scan(
+		&e.ID, &e.MemberID, &e.Date,
+		&summary, &moraleScore, &growthScore,
+		&moraleRat, &growthRat,
+		&tags, &actionMine, &actionTheirs,
+		&quotes, &blockers, &wins,
+		&privateNote,
+		&transcript, &createdAt, &updatedAt, &customFields,
+	)
+	if err != nil {
+		return e, err
+	}
+
+	if summary.Valid {
+		e.Summary = &summary.String
+	}
+	if moraleScore.Valid {
+		v := int(moraleScore.Int64)
+		e.MoraleScore = &v
+	}
+	if growthScore.Valid {
+		v := int(growthScore.Int64)
+		e.GrowthScore = &v
+	}
+	if moraleRat.Valid {
+		e.MoraleRationale = &moraleRat.String
+	}
+	if growthRat.Valid {
+		e.GrowthRationale = &growthRat.String
+	}
+	if privateNote.Valid {
+		v, err := a.decryptField(privateNote.String)
+		if err != nil {
+			return e, fmt.Errorf("private_note of %s: %w", e.ID, err)
+		}
+		e.PrivateNote = &v
+	}
+	if transcript.Valid {
+		v, err := a.decryptField(transcript.String)
+		if err != nil {
+			return e, fmt.Errorf("transcript of %s: %w", e.ID, err)
+		}
+		e.Transcript = &v
+	}
+	if createdAt.Valid {
+		e.CreatedAt = &createdAt.String
+	}
+	if updatedAt.Valid {
+		e.UpdatedAt = &updatedAt.String
+	}
+
+	e.Tags = parseJSONArray(tags.String)
+	e.ActionItemsMine = parseActionItems(actionMine.String)
+	e.ActionItemsTheirs = parseActionItems(actionTheirs.String)
+	e.NotableQuotes = parseJSONArray(quotes.String)
+	e.Blockers = parseJSONArray(blockers.String)
+	e.Wins = parseJSONArray(wins.String)
+	e.CustomFields = parseCustomValues(customFields.String)
+
+	return e, nil
+}
+
+// entryColumns lists the entries columns in scanEntry order.
+var entryColumns = []string{
+	"id", "member_id", "date",
+	"summary", "morale_score", "growth_score",
+	"morale_rationale", "growth_rationale",
+	"tags", "action_items_mine", "action_items_theirs",
+	"notable_quotes", "blockers", "wins",
+	"private_note", "transcript", "created_at", "updated_at", "custom_fields",
+}
+
+// entryCols is the SELECT column list for entries, matching scanEntry order.
+var entryCols = strings.Join(entryColumns, ", ")
+
+// entryColsAs is entryCols qualified with a table alias, for joins.
+func entryColsAs(alias string) string {
+	cols := make([]string, len(entryColumns))
+	for i, c := range entryColumns {
+		cols[i] = alias + "." + c
+	}
+	return strings.Join(cols, ", ")
+}
+
+func entryQuery(where string) string {
+	return fmt.Sprintf("SELECT %s FROM entries %s ORDER BY date DESC", entryCols, where)
+}
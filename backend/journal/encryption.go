@@ -0,0 +1,441 @@
+package journal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// entries.transcript, entries.private_note and revision snapshots are
+// encrypted with AES-256-GCM when DB_ENCRYPTION_PASSPHRASE or
+// DB_ENCRYPTION_KEYFILE is set. Encrypted values are stored as
+// "enc:v1:<base64 nonce+ciphertext>"; anything without that prefix is
+// plaintext, so a database can be switched over gradually.
+//
+// The encryption table records how the key was made (passphrase salt and
+// iterations) and a check value, so a wrong key fails at startup instead of
+// surfacing as unreadable entries.
+
+const (
+	encryptedPrefix  = "enc:v1:"
+	keyCheckText     = "people-journal"
+	pbkdf2Iterations = 600000
+)
+
+var errNoEncryptionKey = errors.New("the database is encrypted; set DB_ENCRYPTION_PASSPHRASE or DB_ENCRYPTION_KEYFILE")
+
+type fieldCipher struct {
+	aead cipher.AEAD
+}
+
+func newFieldCipher(key []byte) (*fieldCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &fieldCipher{aead: aead}, nil
+}
+
+func (c *fieldCipher) seal(plaintext string) string {
+	nonce := make([]byte, c.aead.NonceSize())
+	rand.Read(nonce)
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed)
+}
+
+func (c *fieldCipher) open(value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	n := c.aead.NonceSize()
+	plain, err := c.aead.Open(nil, sealed[:n], sealed[n:], nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt value; wrong key?")
+	}
+	return string(plain), nil
+}
+
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// encryptField encrypts s with the active key, or returns it unchanged when
+// encryption is off.
+func (a *App) encryptField(s string) string {
+	if a.fieldKey == nil {
+		return s
+	}
+	return a.fieldKey.seal(s)
+}
+
+// encryptValue encrypts a string request value, passing nil and other types
+// through.
+func (a *App) encryptValue(v any) any {
+	if s, ok := v.(string); ok {
+		return a.encryptField(s)
+	}
+	return v
+}
+
+func (a *App) encryptPtr(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return a.encryptField(*s)
+}
+
+// decryptField returns plaintext values unchanged.
+func (a *App) decryptField(s string) (string, error) {
+	if !isEncrypted(s) {
+		return s, nil
+	}
+	if a.fieldKey == nil {
+		return "", errNoEncryptionKey
+	}
+	return a.fieldKey.open(s)
+}
+
+// ─── Keys ───────────────────────────────────────────────
+
+// keySource is where a key comes from: exactly one of passphrase or keyfile.
+type keySource struct {
+	passphrase string
+	keyfile    string
+}
+
+func (s keySource) kdf() string {
+	if s.keyfile != "" {
+		return "keyfile"
+	}
+	return "pbkdf2-sha256"
+}
+
+// newKeySource checks that at most one of passphrase and keyfile is set,
+// naming them by their variables. ok is false when neither is set.
+func newKeySource(passphrase, keyfile, passphraseVar, keyfileVar string) (src keySource, ok bool, err error) {
+	src = keySource{passphrase: passphrase, keyfile: keyfile}
+	if src.passphrase != "" && src.keyfile != "" {
+		return src, false, fmt.Errorf("set only one of %s and %s", passphraseVar, keyfileVar)
+	}
+	return src, src.passphrase != "" || src.keyfile != "", nil
+}
+
+// readKeyfile accepts 32 raw bytes, or 32 bytes encoded as hex or base64
+// (e.g. from `openssl rand -base64 32`).
+func readKeyfile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, fmt.Errorf("keyfile %s must hold a 32-byte key (raw, hex or base64)", path)
+}
+
+// encryptionMeta is the single row of the encryption table.
+type encryptionMeta struct {
+	kdf        string
+	salt       []byte
+	iterations int
+	keyCheck   string
+}
+
+func readEncryptionMeta(db dbtx) (*encryptionMeta, error) {
+	var m encryptionMeta
+	var salt sql.NullString
+	var iterations sql.NullInt64
+	err := db.QueryRow("SELECT kdf, salt, iterations, key_check FROM encryption WHERE id = 1").
+		Scan(&m.kdf, &salt, &iterations, &m.keyCheck)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if salt.Valid {
+		if m.salt, err = base64.StdEncoding.DecodeString(salt.String); err != nil {
+			return nil, fmt.Errorf("corrupt encryption salt: %w", err)
+		}
+	}
+	m.iterations = int(iterations.Int64)
+	return &m, nil
+}
+
+func writeEncryptionMeta(db dbtx, m *encryptionMeta) error {
+	var salt any
+	if m.salt != nil {
+		salt = base64.StdEncoding.EncodeToString(m.salt)
+	}
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO encryption (id, kdf, salt, iterations, key_check, created_at)
+		VALUES (1, ?, ?, ?, ?, ?)`,
+		m.kdf, salt, m.iterations, m.keyCheck, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// deriveCipher builds the cipher for src using the salt and iterations in
+// meta, and checks it against meta's key check.
+func deriveCipher(src keySource, meta *encryptionMeta) (*fieldCipher, error) {
+	if src.kdf() != meta.kdf {
+		return nil, fmt.Errorf("the database key was set up with a %s, not a %s", meta.kdf, src.kdf())
+	}
+	var key []byte
+	var err error
+	if src.keyfile != "" {
+		key, err = readKeyfile(src.keyfile)
+	} else {
+		key, err = pbkdf2.Key(sha256.New, src.passphrase, meta.salt, meta.iterations, 32)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := newFieldCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if check, err := c.open(meta.keyCheck); err != nil || check != keyCheckText {
+		return nil, errors.New("wrong encryption key")
+	}
+	return c, nil
+}
+
+// newEncryptionMeta sets up a new key from src, with a fresh salt for
+// passphrases.
+func newEncryptionMeta(src keySource) (*encryptionMeta, *fieldCipher, error) {
+	meta := &encryptionMeta{kdf: src.kdf()}
+	var key []byte
+	var err error
+	if src.keyfile != "" {
+		key, err = readKeyfile(src.keyfile)
+	} else {
+		meta.salt = make([]byte, 16)
+		rand.Read(meta.salt)
+		meta.iterations = pbkdf2Iterations
+		key, err = pbkdf2.Key(sha256.New, src.passphrase, meta.salt, meta.iterations, 32)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := newFieldCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta.keyCheck = c.seal(keyCheckText)
+	return meta, c, nil
+}
+
+// loadEncryption sets fieldKey from the configured key. The first time a key
+// is configured it is recorded in the database; after that the same key must
+// be supplied on every start.
+func (a *App) loadEncryption() error {
+	src, ok, err := newKeySource(a.cfg.EncryptionPassphrase, a.cfg.EncryptionKeyfile, "DB_ENCRYPTION_PASSPHRASE", "DB_ENCRYPTION_KEYFILE")
+	if err != nil {
+		return err
+	}
+	meta, err := readEncryptionMeta(a.db)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		if meta != nil {
+			return errNoEncryptionKey
+		}
+		a.fieldKey = nil
+		return nil
+	}
+
+	if meta == nil {
+		meta, a.fieldKey, err = newEncryptionMeta(src)
+		if err != nil {
+			return err
+		}
+		if err := writeEncryptionMeta(a.db, meta); err != nil {
+			return err
+		}
+		a.log.Printf("Encryption at rest enabled. Run `people-journal encrypt` to encrypt existing entries.")
+		return nil
+	}
+
+	a.fieldKey, err = deriveCipher(src, meta)
+	return err
+}
+
+// ─── Commands ───────────────────────────────────────────
+
+// recryptAll rewrites every encrypted column through transform, in one
+// transaction. transform returns ok=false to leave a value as it is.
+func recryptAll(tx *sql.Tx, transform func(string) (string, bool, error)) (int, error) {
+	targets := []struct{ table, key, col string }{
+		{"entries", "rowid", "private_note"},
+		{"entries", "rowid", "transcript"},
+		{"entry_revisions", "rowid", "snapshot"},
+	}
+	changed := 0
+	for _, t := range targets {
+		rows, err := tx.Query(fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s IS NOT NULL", t.key, t.col, t.table, t.col))
+		if err != nil {
+			return 0, err
+		}
+		type update struct {
+			key   int64
+			value string
+		}
+		var updates []update
+		for rows.Next() {
+			var u update
+			if err := rows.Scan(&u.key, &u.value); err != nil {
+				rows.Close()
+				return 0, err
+			}
+			next, ok, err := transform(u.value)
+			if err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("%s.%s row %d: %w", t.table, t.col, u.key, err)
+			}
+			if ok {
+				updates = append(updates, update{u.key, next})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+
+		for _, u := range updates {
+			if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", t.table, t.col, t.key), u.value, u.key); err != nil {
+				return 0, err
+			}
+		}
+		changed += len(updates)
+	}
+	return changed, nil
+}
+
+// rebuildSearchIndex rebuilds entries_fts from scratch. FTS5 deletes only
+// mark old tokens as removed until their segments merge, so without this the
+// plaintext recryptAll just replaced would stay readable in the index pages.
+func rebuildSearchIndex(tx *sql.Tx) error {
+	if _, err := tx.Exec("INSERT INTO entries_fts(entries_fts) VALUES('rebuild')"); err != nil {
+		return fmt.Errorf("rebuild search index: %w", err)
+	}
+	return nil
+}
+
+// compactDB rewrites the database file and truncates the WAL so old
+// plaintext or old-key pages don't linger on disk.
+func compactDB(db *sql.DB) error {
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return err
+	}
+	_, err := db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
+
+// runEncryptCommand encrypts every plaintext transcript, private note and
+// revision snapshot with the configured key.
+func (a *App) runEncryptCommand() error {
+	if a.fieldKey == nil {
+		return errors.New("set DB_ENCRYPTION_PASSPHRASE or DB_ENCRYPTION_KEYFILE first")
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	n, err := recryptAll(tx, func(v string) (string, bool, error) {
+		if isEncrypted(v) {
+			return v, false, nil
+		}
+		return a.fieldKey.seal(v), true, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := rebuildSearchIndex(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := compactDB(a.db); err != nil {
+		return fmt.Errorf("compact database: %w", err)
+	}
+	fmt.Printf("Encrypted %d values.\n", n)
+	return nil
+}
+
+// runRotateKeyCommand re-encrypts everything from the current key to the one
+// in DB_ENCRYPTION_NEW_PASSPHRASE or DB_ENCRYPTION_NEW_KEYFILE.
+func (a *App) runRotateKeyCommand() error {
+	if a.fieldKey == nil {
+		return errors.New("set DB_ENCRYPTION_PASSPHRASE or DB_ENCRYPTION_KEYFILE to the current key first")
+	}
+	src, ok, err := newKeySource(a.cfg.NewEncryptionPassphrase, a.cfg.NewEncryptionKeyfile, "DB_ENCRYPTION_NEW_PASSPHRASE", "DB_ENCRYPTION_NEW_KEYFILE")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("set DB_ENCRYPTION_NEW_PASSPHRASE or DB_ENCRYPTION_NEW_KEYFILE to the new key")
+	}
+	meta, next, err := newEncryptionMeta(src)
+	if err != nil {
+		return err
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	n, err := recryptAll(tx, func(v string) (string, bool, error) {
+		plain, err := a.decryptField(v)
+		if err != nil {
+			return "", false, err
+		}
+		return next.seal(plain), true, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := rebuildSearchIndex(tx); err != nil {
+		return err
+	}
+	if err := writeEncryptionMeta(tx, meta); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	a.fieldKey = next
+	if err := compactDB(a.db); err != nil {
+		return fmt.Errorf("compact database: %w", err)
+	}
+	fmt.Printf("Re-encrypted %d values. Replace the old key with the new one in .env before starting the server.\n", n)
+	return nil
+}
@@ -0,0 +1,186 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testCipher(t *testing.T, fill byte) *fieldCipher {
+	t.Helper()
+	c, err := newFieldCipher(bytes.Repeat([]byte{fill}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestFieldCipherRoundTrip(t *testing.T) {
+	c := testCipher(t, 1)
+	for _, plain := range []string{"", "Sam is thinking about leaving.", "ünïcödé ✓"} {
+		sealed := c.seal(plain)
+		if !isEncrypted(sealed) || strings.Contains(sealed, plain) && plain != "" {
+			t.Fatalf("seal(%q) = %q", plain, sealed)
+		}
+		got, err := c.open(sealed)
+		if err != nil || got != plain {
+			t.Errorf("open(seal(%q)) = %q, %v", plain, got, err)
+		}
+	}
+	if c.seal("same") == c.seal("same") {
+		t.Error("two seals of the same text are identical; nonce reused")
+	}
+}
+
+func TestFieldCipherWrongKey(t *testing.T) {
+	sealed := testCipher(t, 1).seal("private")
+	if got, err := testCipher(t, 2).open(sealed); err == nil {
+		t.Errorf("open with the wrong key = %q, want an error", got)
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, encryptedPrefix))
+	raw[len(raw)-1] ^= 1
+	tampered := encryptedPrefix + base64.StdEncoding.EncodeToString(raw)
+	if got, err := testCipher(t, 1).open(tampered); err == nil {
+		t.Errorf("open of a tampered value = %q, want an error", got)
+	}
+	if _, err := testCipher(t, 1).open(encryptedPrefix + "not base64!"); err == nil {
+		t.Error("open of a malformed value succeeded")
+	}
+}
+
+// writeKeyfile writes a hex keyfile of 32 fill bytes.
+func writeKeyfile(t *testing.T, fill byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.key")
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(bytes.Repeat([]byte{fill}, 32))), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// runJournalCommand runs a maintenance command against the database in dir.
+func runJournalCommand(t *testing.T, dir, name string, configure func(c *Config)) {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.DataDir = dir
+	configure(&cfg)
+	app, err := New(cfg)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	defer app.Close()
+	if err := app.RunCommand(name); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
+
+// seedPrivateEntry creates a member and an entry with a transcript and
+// private note, then edits it so there's a revision snapshot too.
+func seedPrivateEntry(t *testing.T, s *testServer, transcript, note string) {
+	t.Helper()
+	s.run(t, []apiStep{
+		{name: "create member", method: "POST", path: "/api/team", body: map[string]string{"name": "Sam"}, status: 201, save: "sam"},
+		{name: "create entry", method: "POST", path: "/api/entries", status: 201, save: "entry",
+			body: map[string]any{"member_id": "$sam", "date": "2024-05-01T15:00:00Z", "summary": "Talked about the launch.", "transcript": transcript, "private_note": note}},
+		{name: "edit entry", method: "PUT", path: "/api/entries/$entry", body: map[string]any{"summary": "Launch went well."}, status: 200},
+	})
+}
+
+func TestEncryptLeavesNoPlaintextOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	transcript := "Sam: the qwzxkplm migration is stressing me out."
+	note := "Ask about vrbnmtsk."
+
+	s := newTestServer(t, func(c *Config) { c.DataDir = dir })
+	seedPrivateEntry(t, s, transcript, note)
+	vars := s.vars
+	s.Close()
+	s.app.Close()
+
+	keyfile := writeKeyfile(t, 7)
+	runJournalCommand(t, dir, "encrypt", func(c *Config) { c.EncryptionKeyfile = keyfile })
+
+	var files []string
+	for _, name := range []string{"people-journal.db", "people-journal.db-wal"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, name)
+		for _, secret := range []string{"qwzxkplm", "vrbnmtsk"} {
+			if bytes.Contains(data, []byte(secret)) {
+				t.Errorf("%s still contains %q after encrypt", name, secret)
+			}
+		}
+	}
+	if len(files) == 0 {
+		t.Fatal("no database files found")
+	}
+
+	s = newTestServer(t, func(c *Config) {
+		c.DataDir = dir
+		c.EncryptionKeyfile = keyfile
+	})
+	s.vars = vars
+	s.run(t, []apiStep{
+		{name: "read back", method: "GET", path: "/api/entries/$entry", status: 200,
+			check: checks(wantField(transcript, "transcript"), wantField(note, "private_note"))},
+	})
+}
+
+func TestRotateKey(t *testing.T) {
+	dir := t.TempDir()
+	transcript := "Sam: I'd like to lead the next project."
+	note := "Promo case is strong."
+	oldKey, newKey := writeKeyfile(t, 1), writeKeyfile(t, 2)
+
+	s := newTestServer(t, func(c *Config) { c.DataDir = dir })
+	seedPrivateEntry(t, s, transcript, note)
+	vars := s.vars
+	s.Close()
+	s.app.Close()
+
+	runJournalCommand(t, dir, "encrypt", func(c *Config) { c.EncryptionKeyfile = oldKey })
+	runJournalCommand(t, dir, "rotate-key", func(c *Config) {
+		c.EncryptionKeyfile = oldKey
+		c.NewEncryptionKeyfile = newKey
+	})
+
+	cfg := DefaultConfig()
+	cfg.DataDir = dir
+	cfg.EncryptionKeyfile = oldKey
+	if app, err := New(cfg); err == nil {
+		app.Close()
+		t.Fatal("the old key still opens the database after rotate-key")
+	}
+
+	s = newTestServer(t, func(c *Config) {
+		c.DataDir = dir
+		c.EncryptionKeyfile = newKey
+	})
+	s.vars = vars
+	s.run(t, []apiStep{
+		{name: "entry", method: "GET", path: "/api/entries/$entry", status: 200,
+			check: checks(wantField(transcript, "transcript"), wantField(note, "private_note"), wantField("Launch went well.", "summary"))},
+		{name: "revision snapshot", method: "GET", path: "/api/entries/$entry/revisions/1", status: 200,
+			check: checks(wantField(transcript, "snapshot", "transcript"), wantField(note, "snapshot", "private_note"), wantField("Talked about the launch.", "snapshot", "summary"))},
+	})
+
+	for _, col := range []struct{ table, col string }{{"entries", "transcript"}, {"entries", "private_note"}, {"entry_revisions", "snapshot"}} {
+		var v string
+		s.app.db.QueryRow("SELECT " + col.col + " FROM " + col.table + " LIMIT 1").Scan(&v)
+		if !isEncrypted(v) {
+			t.Errorf("%s.%s is stored unencrypted: %.40q", col.table, col.col, v)
+		}
+		if _, err := testCipher(t, 2).open(v); err != nil {
+			t.Errorf("%s.%s isn't sealed with the new key: %v", col.table, col.col, err)
+		}
+	}
+}
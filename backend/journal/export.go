@@ -0,0 +1,787 @@
+package journal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The export document holds the whole journal: team members (with their
+// prep notes and speaker labels), entries, action items, the tag taxonomy,
+// the custom field definitions, every saved prompt template version and the
+// redaction deny-list. Trashed rows and revision history are left out. Bump
+// exportVersion when the document shape changes in a way an older importer
+// can't read. Version 2 added everything after the action items.
+const (
+	exportFormat  = "people-journal-export"
+	exportVersion = 2
+)
+
+type ExportDocument struct {
+	Format         string             `json:"format"`
+	Version        int                `json:"version"`
+	SchemaVersion  int                `json:"schema_version"`
+	ExportedAt     string             `json:"exported_at"`
+	TeamMembers    []TeamMember       `json:"team_members"`
+	Entries        []Entry            `json:"entries"`
+	ActionItems    []ActionItemRecord `json:"action_items"`
+	SpeakerLabels  []SpeakerLabel     `json:"speaker_labels"`
+	Tags           []Tag              `json:"tags"`
+	CustomFields   []CustomField      `json:"custom_fields"`
+	Prompts        []PromptVersion    `json:"prompt_templates"`
+	RedactionTerms []RedactionTerm    `json:"redaction_terms"`
+}
+
+// PromptVersion is one saved version of a prompt template.
+type PromptVersion struct {
+	Name      string `json:"name"`
+	Version   int    `json:"version"`
+	Body      string `json:"body"`
+	Note      string `json:"note"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (a *App) buildExport() (ExportDocument, error) {
+	doc := ExportDocument{
+		Format:        exportFormat,
+		Version:       exportVersion,
+		SchemaVersion: schemaVersion(),
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+		TeamMembers:   []TeamMember{},
+		Entries:       []Entry{},
+		ActionItems:   []ActionItemRecord{},
+		SpeakerLabels: []SpeakerLabel{},
+	}
+
+	tags, err := loadTags(a.db, true)
+	if err != nil {
+		return doc, err
+	}
+	doc.Tags = tags
+	if doc.CustomFields, err = loadCustomFields(a.db, true); err != nil {
+		return doc, err
+	}
+	if doc.Prompts, err = loadPromptVersions(a.db); err != nil {
+		return doc, err
+	}
+	if doc.RedactionTerms, err = a.loadRedactionTerms(); err != nil {
+		return doc, err
+	}
+
+	rows, err := a.db.Query("SELECT id, name, role, color, jira_account_id, prep_notes FROM team_members WHERE deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		return doc, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		m, err := scanTeamMember(rows)
+		if err != nil {
+			return doc, err
+		}
+		doc.TeamMembers = append(doc.TeamMembers, m)
+	}
+	if err := rows.Err(); err != nil {
+		return doc, err
+	}
+
+	entryRows, err := a.db.Query(entryQuery("WHERE deleted_at IS NULL"))
+	if err != nil {
+		return doc, err
+	}
+	defer entryRows.Close()
+	for entryRows.Next() {
+		e, err := a.scanEntry(entryRows)
+		if err != nil {
+			return doc, err
+		}
+		doc.Entries = append(doc.Entries, e)
+	}
+	if err := entryRows.Err(); err != nil {
+		return doc, err
+	}
+
+	itemRows, err := a.db.Query(actionItemSelect + " ORDER BY a.entry_id, a.owner, a.position")
+	if err != nil {
+		return doc, err
+	}
+	defer itemRows.Close()
+	for itemRows.Next() {
+		item, err := scanActionItemRecord(itemRows)
+		if err != nil {
+			return doc, err
+		}
+		doc.ActionItems = append(doc.ActionItems, item)
+	}
+	if err := itemRows.Err(); err != nil {
+		return doc, err
+	}
+
+	labelRows, err := a.db.Query(`
+		SELECT s.member_id, s.label, s.speaker
+		FROM speaker_labels s
+		JOIN team_members m ON m.id = s.member_id AND m.deleted_at IS NULL
+		ORDER BY s.member_id, s.label`)
+	if err != nil {
+		return doc, err
+	}
+	defer labelRows.Close()
+	for labelRows.Next() {
+		var l SpeakerLabel
+		if err := labelRows.Scan(&l.MemberID, &l.Label, &l.Speaker); err != nil {
+			return doc, err
+		}
+		doc.SpeakerLabels = append(doc.SpeakerLabels, l)
+	}
+	return doc, labelRows.Err()
+}
+
+func loadPromptVersions(q dbtx) ([]PromptVersion, error) {
+	rows, err := q.Query("SELECT name, version, body, note, created_at FROM prompt_templates ORDER BY name, version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	prompts := []PromptVersion{}
+	for rows.Next() {
+		var p PromptVersion
+		if err := rows.Scan(&p.Name, &p.Version, &p.Body, &p.Note, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts, rows.Err()
+}
+
+func (a *App) handleExport(w http.ResponseWriter, r *http.Request) {
+	doc, err := a.buildExport()
+	if err != nil {
+		a.log.Printf("Failed to export journal: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "failed to export"})
+		return
+	}
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="people-journal-%s.json"`, time.Now().Format("2006-01-02")))
+	writeJSON(w, 200, doc)
+}
+
+// ─── Import ─────────────────────────────────────────────
+
+// In merge mode, records whose ID isn't in the journal are added and records
+// that already exist are left alone; existing records that differ from the
+// import are reported as conflicts. Replace mode empties the journal,
+// including the trash, before loading the document. The tag taxonomy, custom
+// fields, prompt templates and deny-list are only replaced when the document
+// has them, so a version 1 document keeps them.
+const (
+	importMerge   = "merge"
+	importReplace = "replace"
+)
+
+type ImportCounts struct {
+	Created   int `json:"created"`
+	Unchanged int `json:"unchanged"`
+	Conflicts int `json:"conflicts"`
+	Removed   int `json:"removed"`
+}
+
+type ImportConflict struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type ImportReport struct {
+	Mode           string           `json:"mode"`
+	DryRun         bool             `json:"dry_run"`
+	TeamMembers    ImportCounts     `json:"team_members"`
+	Entries        ImportCounts     `json:"entries"`
+	ActionItems    ImportCounts     `json:"action_items"`
+	Tags           ImportCounts     `json:"tags"`
+	CustomFields   ImportCounts     `json:"custom_fields"`
+	Prompts        ImportCounts     `json:"prompt_templates"`
+	RedactionTerms ImportCounts     `json:"redaction_terms"`
+	Conflicts      []ImportConflict `json:"conflicts"`
+}
+
+func (rep *ImportReport) conflict(counts *ImportCounts, kind, id, format string, args ...any) {
+	counts.Conflicts++
+	rep.Conflicts = append(rep.Conflicts, ImportConflict{Type: kind, ID: id, Reason: fmt.Sprintf(format, args...)})
+}
+
+// validateExportDocument checks the parts of a document an import relies on,
+// before anything is written.
+func validateExportDocument(doc ExportDocument) error {
+	if doc.Format != exportFormat {
+		return fmt.Errorf("not a People Journal export (format %q)", doc.Format)
+	}
+	if doc.Version < 1 || doc.Version > exportVersion {
+		return fmt.Errorf("export version %d is not supported by this version (up to %d)", doc.Version, exportVersion)
+	}
+
+	seen := map[string]bool{}
+	unique := func(kind, id string) error {
+		if id == "" {
+			return fmt.Errorf("a %s has no id", kind)
+		}
+		if seen[kind+"/"+id] {
+			return fmt.Errorf("%s %s appears twice", kind, id)
+		}
+		seen[kind+"/"+id] = true
+		return nil
+	}
+	for _, m := range doc.TeamMembers {
+		if err := unique("team_member", m.ID); err != nil {
+			return err
+		}
+	}
+	for _, e := range doc.Entries {
+		if err := unique("entry", e.ID); err != nil {
+			return err
+		}
+	}
+	for _, a := range doc.ActionItems {
+		if err := unique("action_item", a.ID); err != nil {
+			return err
+		}
+		if _, ok := ownerColumns[a.Owner]; !ok {
+			return fmt.Errorf("action item %s has owner %q, want manager or member", a.ID, a.Owner)
+		}
+		if a.Status != "open" && a.Status != "done" {
+			return fmt.Errorf("action item %s has status %q, want open or done", a.ID, a.Status)
+		}
+	}
+	for _, l := range doc.SpeakerLabels {
+		if err := validateSpeakerLabels(map[string]string{l.Label: l.Speaker}); err != nil {
+			return fmt.Errorf("team member %s: %w", l.MemberID, err)
+		}
+	}
+	tagNames := map[string]bool{}
+	for _, t := range doc.Tags {
+		if err := unique("tag", t.ID); err != nil {
+			return err
+		}
+		name := strings.ToLower(strings.Join(strings.Fields(t.Name), " "))
+		if name == "" {
+			return fmt.Errorf("tag %s has no name", t.ID)
+		}
+		if tagNames[name] {
+			return fmt.Errorf("tag %q appears twice", t.Name)
+		}
+		tagNames[name] = true
+	}
+	fieldNames := map[string]bool{}
+	for _, f := range doc.CustomFields {
+		if err := unique("custom_field", f.ID); err != nil {
+			return err
+		}
+		if err := validateCustomField(f.Name, f.Type, strings.TrimSpace(f.Instructions)); err != nil {
+			return fmt.Errorf("custom field %s: %w", f.ID, err)
+		}
+		if fieldNames[f.Name] {
+			return fmt.Errorf("custom field %q appears twice", f.Name)
+		}
+		fieldNames[f.Name] = true
+	}
+	for _, p := range doc.Prompts {
+		if _, ok := promptSpecs[p.Name]; !ok {
+			return fmt.Errorf("unknown prompt %q; prompts are %s", p.Name, strings.Join(promptNames, ", "))
+		}
+		if p.Version < 1 {
+			return fmt.Errorf("%s prompt has version %d, want 1 or more", p.Name, p.Version)
+		}
+		if err := unique("prompt_template", fmt.Sprintf("%s/%d", p.Name, p.Version)); err != nil {
+			return err
+		}
+		if strings.TrimSpace(p.Body) == "" {
+			return fmt.Errorf("%s prompt version %d has no body", p.Name, p.Version)
+		}
+	}
+	terms := map[string]bool{}
+	for _, t := range doc.RedactionTerms {
+		if err := unique("redaction_term", t.ID); err != nil {
+			return err
+		}
+		term := strings.ToLower(strings.Join(strings.Fields(t.Term), " "))
+		if term == "" {
+			return fmt.Errorf("redaction term %s is empty", t.ID)
+		}
+		if terms[term] {
+			return fmt.Errorf("redaction term %q appears twice", t.Term)
+		}
+		terms[term] = true
+	}
+	return nil
+}
+
+// sameJSON reports whether a and b encode identically.
+func sameJSON(a, b any) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+// orEmpty stores a list missing from a hand-written document as [] rather
+// than null.
+func orEmpty[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// clearJournal deletes everything, trash included, for replace mode. The
+// taxonomy, custom fields, prompt templates and deny-list are kept when the
+// document doesn't have them.
+func clearJournal(tx dbtx, doc ExportDocument, rep *ImportReport) error {
+	for _, t := range []struct {
+		table  string
+		counts *ImportCounts
+		keep   bool
+	}{
+		{"action_items", &rep.ActionItems, false},
+		{"entry_revisions", nil, false},
+		{"entries", &rep.Entries, false},
+		{"speaker_labels", nil, false},
+		{"team_members", &rep.TeamMembers, false},
+		{"tags", &rep.Tags, doc.Tags == nil},
+		{"custom_fields", &rep.CustomFields, doc.CustomFields == nil},
+		{"prompt_templates", &rep.Prompts, doc.Prompts == nil},
+		{"redaction_terms", &rep.RedactionTerms, doc.RedactionTerms == nil},
+	} {
+		if t.keep {
+			continue
+		}
+		res, err := tx.Exec("DELETE FROM " + t.table)
+		if err != nil {
+			return err
+		}
+		if t.counts != nil {
+			n, _ := res.RowsAffected()
+			t.counts.Removed = int(n)
+		}
+	}
+	return nil
+}
+
+func importTeamMembers(tx dbtx, doc ExportDocument, rep *ImportReport) (available map[string]bool, err error) {
+	available = map[string]bool{}
+	for _, m := range doc.TeamMembers {
+		var trashed bool
+		local, err := scanTeamMember(scanAppend(tx.QueryRow(
+			"SELECT id, name, role, color, jira_account_id, prep_notes, deleted_at IS NOT NULL FROM team_members WHERE id = ?", m.ID,
+		), &trashed))
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := tx.Exec(
+				"INSERT INTO team_members (id, name, role, color, jira_account_id, prep_notes) VALUES (?, ?, ?, ?, ?, ?)",
+				m.ID, m.Name, m.Role, m.Color, m.JiraAccountID, m.PrepNotes,
+			); err != nil {
+				return nil, fmt.Errorf("team member %s: %w", m.ID, err)
+			}
+			rep.TeamMembers.Created++
+			available[m.ID] = true
+		case err != nil:
+			return nil, err
+		case trashed:
+			rep.conflict(&rep.TeamMembers, "team_member", m.ID, "exists in the trash; restore or purge it first")
+		case sameJSON(local, m):
+			rep.TeamMembers.Unchanged++
+			available[m.ID] = true
+		default:
+			rep.conflict(&rep.TeamMembers, "team_member", m.ID, "differs from the journal's copy; kept the journal's")
+			available[m.ID] = true
+		}
+	}
+	return available, nil
+}
+
+// importTags adds tags whose ID and name are both new. Entries keep their
+// tags as names, so they don't depend on this.
+func importTags(tx dbtx, doc ExportDocument, rep *ImportReport) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, t := range doc.Tags {
+		name := strings.Join(strings.Fields(t.Name), " ")
+		var local Tag
+		err := tx.QueryRow("SELECT name, description, color, archived FROM tags WHERE id = ?", t.ID).
+			Scan(&local.Name, &local.Description, &local.Color, &local.Archived)
+		switch {
+		case err == nil:
+			if local.Name == name && local.Description == t.Description && local.Color == t.Color && local.Archived == t.Archived {
+				rep.Tags.Unchanged++
+			} else {
+				rep.conflict(&rep.Tags, "tag", t.ID, "differs from the journal's copy; kept the journal's")
+			}
+			continue
+		case err != sql.ErrNoRows:
+			return err
+		}
+
+		if tagNameTaken(tx, name, t.ID) {
+			rep.conflict(&rep.Tags, "tag", t.ID, "a tag named %q already exists", name)
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO tags (id, name, description, color, archived, position, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM tags), ?, ?)`,
+			t.ID, name, t.Description, t.Color, t.Archived, now, now,
+		); err != nil {
+			return fmt.Errorf("tag %s: %w", t.ID, err)
+		}
+		rep.Tags.Created++
+	}
+	return nil
+}
+
+// importCustomFields adds field definitions whose ID and name are both new.
+// Entries' values are imported with the entries whether or not their field
+// is.
+func importCustomFields(tx dbtx, doc ExportDocument, rep *ImportReport) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, f := range doc.CustomFields {
+		instructions := strings.TrimSpace(f.Instructions)
+		var local CustomField
+		err := tx.QueryRow("SELECT name, type, instructions, archived FROM custom_fields WHERE id = ?", f.ID).
+			Scan(&local.Name, &local.Type, &local.Instructions, &local.Archived)
+		switch {
+		case err == nil:
+			if local.Name == f.Name && local.Type == f.Type && local.Instructions == instructions && local.Archived == f.Archived {
+				rep.CustomFields.Unchanged++
+			} else {
+				rep.conflict(&rep.CustomFields, "custom_field", f.ID, "differs from the journal's copy; kept the journal's")
+			}
+			continue
+		case err != sql.ErrNoRows:
+			return err
+		}
+
+		var taken int
+		tx.QueryRow("SELECT COUNT(*) FROM custom_fields WHERE name = ?", f.Name).Scan(&taken)
+		if taken > 0 {
+			rep.conflict(&rep.CustomFields, "custom_field", f.ID, "a field named %q already exists", f.Name)
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO custom_fields (id, name, type, instructions, archived, position, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM custom_fields), ?, ?)`,
+			f.ID, f.Name, f.Type, instructions, f.Archived, now, now,
+		); err != nil {
+			return fmt.Errorf("custom field %s: %w", f.ID, err)
+		}
+		rep.CustomFields.Created++
+	}
+	return nil
+}
+
+// importPromptVersions adds template versions the journal doesn't have,
+// keeping their version numbers. The newest version of each prompt is the
+// one in use.
+func importPromptVersions(tx dbtx, doc ExportDocument, rep *ImportReport) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, p := range doc.Prompts {
+		id := fmt.Sprintf("%s/%d", p.Name, p.Version)
+		var body, note string
+		err := tx.QueryRow("SELECT body, note FROM prompt_templates WHERE name = ? AND version = ?", p.Name, p.Version).Scan(&body, &note)
+		switch {
+		case err == nil:
+			if body == p.Body && note == p.Note {
+				rep.Prompts.Unchanged++
+			} else {
+				rep.conflict(&rep.Prompts, "prompt_template", id, "differs from the journal's copy; kept the journal's")
+			}
+			continue
+		case err != sql.ErrNoRows:
+			return err
+		}
+
+		createdAt := p.CreatedAt
+		if createdAt == "" {
+			createdAt = now
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO prompt_templates (name, version, body, note, created_at) VALUES (?, ?, ?, ?, ?)",
+			p.Name, p.Version, p.Body, p.Note, createdAt,
+		); err != nil {
+			return fmt.Errorf("prompt template %s: %w", id, err)
+		}
+		rep.Prompts.Created++
+	}
+	return nil
+}
+
+// importRedactionTerms adds deny-list terms whose ID and term are both new.
+func importRedactionTerms(tx dbtx, doc ExportDocument, rep *ImportReport) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, t := range doc.RedactionTerms {
+		term := strings.Join(strings.Fields(t.Term), " ")
+		var local string
+		err := tx.QueryRow("SELECT term FROM redaction_terms WHERE id = ?", t.ID).Scan(&local)
+		switch {
+		case err == nil:
+			if local == term {
+				rep.RedactionTerms.Unchanged++
+			} else {
+				rep.conflict(&rep.RedactionTerms, "redaction_term", t.ID, "differs from the journal's copy; kept the journal's")
+			}
+			continue
+		case err != sql.ErrNoRows:
+			return err
+		}
+
+		var taken int
+		tx.QueryRow("SELECT COUNT(*) FROM redaction_terms WHERE term = ?", term).Scan(&taken)
+		if taken > 0 {
+			rep.conflict(&rep.RedactionTerms, "redaction_term", t.ID, "%q is already on the deny-list", term)
+			continue
+		}
+		createdAt := t.CreatedAt
+		if createdAt == "" {
+			createdAt = now
+		}
+		if _, err := tx.Exec("INSERT INTO redaction_terms (id, term, created_at) VALUES (?, ?, ?)", t.ID, term, createdAt); err != nil {
+			return fmt.Errorf("redaction term %s: %w", t.ID, err)
+		}
+		rep.RedactionTerms.Created++
+	}
+	return nil
+}
+
+// importSpeakerLabels adds the labels of available members. A label the
+// member already has keeps its current mapping.
+func importSpeakerLabels(tx dbtx, doc ExportDocument, members map[string]bool) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, l := range doc.SpeakerLabels {
+		if !members[l.MemberID] {
+			continue
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO speaker_labels (member_id, label, speaker, updated_at) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING",
+			l.MemberID, strings.TrimSpace(l.Label), l.Speaker, now,
+		); err != nil {
+			return fmt.Errorf("speaker label %q for %s: %w", l.Label, l.MemberID, err)
+		}
+	}
+	return nil
+}
+
+// importEntries adds entries whose member is available and returns the ones
+// it created, by ID.
+func (a *App) importEntries(tx dbtx, doc ExportDocument, members map[string]bool, rep *ImportReport) (map[string]Entry, error) {
+	created := map[string]Entry{}
+	for _, e := range doc.Entries {
+		var trashed bool
+		local, err := a.scanEntry(scanAppend(tx.QueryRow(
+			fmt.Sprintf("SELECT %s, deleted_at IS NOT NULL FROM entries WHERE id = ?", entryCols), e.ID,
+		), &trashed))
+		switch {
+		case err == sql.ErrNoRows:
+		case err != nil:
+			return nil, err
+		case trashed:
+			rep.conflict(&rep.Entries, "entry", e.ID, "exists in the trash; restore or purge it first")
+			continue
+		case sameJSON(local, e):
+			rep.Entries.Unchanged++
+			continue
+		default:
+			rep.conflict(&rep.Entries, "entry", e.ID, "differs from the journal's copy; kept the journal's")
+			continue
+		}
+
+		if !members[e.MemberID] {
+			var active bool
+			tx.QueryRow("SELECT deleted_at IS NULL FROM team_members WHERE id = ?", e.MemberID).Scan(&active)
+			if !active {
+				rep.conflict(&rep.Entries, "entry", e.ID, "team member %s is not in the journal or the import", e.MemberID)
+				continue
+			}
+			members[e.MemberID] = true
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO entries (id, member_id, date, summary, morale_score, growth_score,
+				morale_rationale, growth_rationale,
+				tags, action_items_mine, action_items_theirs, notable_quotes, blockers, wins,
+				private_note, transcript, created_at, updated_at, custom_fields)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			e.ID, e.MemberID, e.Date, e.Summary, e.MoraleScore, e.GrowthScore,
+			e.MoraleRationale, e.GrowthRationale,
+			jsonStringify(orEmpty(e.Tags)), jsonStringify(orEmpty(e.ActionItemsMine)), jsonStringify(orEmpty(e.ActionItemsTheirs)),
+			jsonStringify(orEmpty(e.NotableQuotes)), jsonStringify(orEmpty(e.Blockers)), jsonStringify(orEmpty(e.Wins)),
+			a.encryptPtr(e.PrivateNote), a.encryptPtr(e.Transcript), e.CreatedAt, e.UpdatedAt, customValuesJSON(e.CustomFields),
+		); err != nil {
+			return nil, fmt.Errorf("entry %s: %w", e.ID, err)
+		}
+		rep.Entries.Created++
+		created[e.ID] = e
+	}
+	return created, nil
+}
+
+// importActionItems adds the action items of newly created entries. Entries
+// the document has no action item records for get theirs from the entry's
+// own action item lists instead.
+func importActionItems(tx dbtx, doc ExportDocument, created map[string]Entry, rep *ImportReport) error {
+	positions := map[string]int{}
+	withRecords := map[string]bool{}
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	for _, a := range doc.ActionItems {
+		var local ActionItemRecord
+		err := tx.QueryRow("SELECT entry_id, owner, text, status, due_date FROM action_items WHERE id = ?", a.ID).
+			Scan(&local.EntryID, &local.Owner, &local.Text, &local.Status, &local.DueDate)
+		switch {
+		case err == nil:
+			if local.EntryID == a.EntryID && local.Owner == a.Owner && local.Text == a.Text &&
+				local.Status == a.Status && sameJSON(local.DueDate, a.DueDate) {
+				rep.ActionItems.Unchanged++
+			} else {
+				rep.conflict(&rep.ActionItems, "action_item", a.ID, "differs from the journal's copy; kept the journal's")
+			}
+			continue
+		case err != sql.ErrNoRows:
+			return err
+		}
+
+		entry, ok := created[a.EntryID]
+		if !ok {
+			rep.conflict(&rep.ActionItems, "action_item", a.ID, "entry %s was not imported", a.EntryID)
+			continue
+		}
+
+		key := a.EntryID + "/" + a.Owner
+		createdAt := a.CreatedAt
+		if createdAt == "" {
+			createdAt = now
+		}
+		var completedAt any
+		if a.CompletedAt != nil {
+			completedAt = *a.CompletedAt
+		} else if a.Status == "done" {
+			completedAt = now
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO action_items (id, entry_id, member_id, owner, text, status, position, due_date, created_at, completed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			a.ID, a.EntryID, entry.MemberID, a.Owner, a.Text, a.Status, positions[key], a.DueDate, createdAt, completedAt,
+		); err != nil {
+			return fmt.Errorf("action item %s: %w", a.ID, err)
+		}
+		positions[key]++
+		withRecords[a.EntryID] = true
+		rep.ActionItems.Created++
+	}
+
+	for id, e := range created {
+		lists := map[string][]ActionItem{ownerManager: e.ActionItemsMine, ownerMember: e.ActionItemsTheirs}
+		for owner, items := range lists {
+			var err error
+			if withRecords[id] {
+				err = writeActionItemsJSON(tx, id, owner)
+			} else {
+				err = syncActionItems(tx, id, owner, items)
+				rep.ActionItems.Created += len(items)
+			}
+			if err != nil {
+				return fmt.Errorf("entry %s action items: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// handleImport loads an export document. ?mode=merge (the default) or
+// replace; ?dry_run=true runs the whole import and rolls it back, so the
+// report shows exactly what would change.
+func (a *App) handleImport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	mode := q.Get("mode")
+	if mode == "" {
+		mode = importMerge
+	}
+	if mode != importMerge && mode != importReplace {
+		writeJSON(w, 400, map[string]string{"error": "mode must be merge or replace"})
+		return
+	}
+	dryRun := q.Get("dry_run") == "true" || q.Get("dry_run") == "1"
+
+	var doc ExportDocument
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid export document: " + err.Error()})
+		return
+	}
+	if err := validateExportDocument(doc); err != nil {
+		writeJSON(w, 400, map[string]string{"error": err.Error()})
+		return
+	}
+	for _, p := range doc.Prompts {
+		if err := a.checkPrompt(p.Name, p.Body); err != nil {
+			writeJSON(w, 400, map[string]string{"error": fmt.Sprintf("%s prompt version %d: invalid template: %v", p.Name, p.Version, err)})
+			return
+		}
+	}
+
+	rep := ImportReport{Mode: mode, DryRun: dryRun, Conflicts: []ImportConflict{}}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+
+	err = func() error {
+		if mode == importReplace {
+			if err := clearJournal(tx, doc, &rep); err != nil {
+				return err
+			}
+		}
+		if err := importTags(tx, doc, &rep); err != nil {
+			return err
+		}
+		if err := importCustomFields(tx, doc, &rep); err != nil {
+			return err
+		}
+		if err := importPromptVersions(tx, doc, &rep); err != nil {
+			return err
+		}
+		if err := importRedactionTerms(tx, doc, &rep); err != nil {
+			return err
+		}
+		members, err := importTeamMembers(tx, doc, &rep)
+		if err != nil {
+			return err
+		}
+		created, err := a.importEntries(tx, doc, members, &rep)
+		if err != nil {
+			return err
+		}
+		if err := importActionItems(tx, doc, created, &rep); err != nil {
+			return err
+		}
+		return importSpeakerLabels(tx, doc, members)
+	}()
+	if err != nil {
+		a.log.Printf("Import failed: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "import failed: " + err.Error()})
+		return
+	}
+
+	if !dryRun {
+		if err := tx.Commit(); err != nil {
+			writeJSON(w, 500, map[string]string{"error": "db error"})
+			return
+		}
+		if rep.Tags.Created+rep.Tags.Removed+rep.CustomFields.Created+rep.CustomFields.Removed+
+			rep.RedactionTerms.Created+rep.RedactionTerms.Removed > 0 {
+			a.clearExtractionCache()
+		}
+		if rep.Prompts.Created+rep.Prompts.Removed > 0 {
+			a.clearPromptCache(promptExtraction)
+			a.clearPromptCache(promptPrep)
+		}
+	}
+	writeJSON(w, 200, rep)
+}
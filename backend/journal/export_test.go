@@ -0,0 +1,236 @@
+package journal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// seedJournal fills s with one of everything an export holds.
+func seedJournal(t *testing.T, s *testServer) {
+	t.Helper()
+	s.run(t, []apiStep{
+		{name: "create member", method: "POST", path: "/api/team", body: map[string]string{"name": "Sam", "role": "Engineer"}, status: 201, save: "sam"},
+		{name: "prep notes", method: "PUT", path: "/api/team/$sam/prep-notes", body: map[string]string{"prep_notes": "Ask about the offsite."}, status: 200},
+		{name: "speaker labels", method: "PUT", path: "/api/team/$sam/speakers", body: map[string]any{"labels": map[string]string{"Dana": "manager", "Sam L": "member"}}, status: 200},
+		{name: "create tag", method: "POST", path: "/api/tags", body: map[string]string{"name": "Launch", "color": "#ff0000"}, status: 201},
+		{name: "create field", method: "POST", path: "/api/custom-fields", body: map[string]string{"name": "career_goals", "type": "text", "instructions": "career goals they mentioned"}, status: 201},
+		{name: "save prompt", method: "PUT", path: "/api/prompts/prep", body: map[string]string{"body": defaultPrepTemplate + "\nKeep it short.", "note": "shorter"}, status: 200},
+		{name: "add term", method: "POST", path: "/api/redaction-terms", body: map[string]string{"term": "Project Falcon"}, status: 201},
+		{name: "create entry", method: "POST", path: "/api/entries", status: 201, save: "entry",
+			body: map[string]any{"member_id": "$sam", "date": "2024-05-01T15:00:00Z", "summary": "Talked about the launch.",
+				"tags": []string{"Launch"}, "transcript": "Sam: It went well.", "private_note": "Promo soon.",
+				"action_items_mine": []string{"Share the rubric"}, "action_items_theirs": []string{"Write the postmortem"},
+				"custom_fields": map[string]any{"career_goals": "Lead a team."}}},
+	})
+}
+
+// exportDoc returns s's export as decoded JSON, without the timestamp.
+func exportDoc(t *testing.T, s *testServer) map[string]any {
+	t.Helper()
+	status, data := s.do(t, "GET", "/api/export", nil)
+	if status != 200 {
+		t.Fatalf("export = %d: %s", status, data)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	delete(doc, "exported_at")
+	return doc
+}
+
+// sameDoc fails unless two exports hold the same journal.
+func sameDoc(t *testing.T, got, want map[string]any) {
+	t.Helper()
+	for key := range want {
+		g, _ := json.Marshal(got[key])
+		w, _ := json.Marshal(want[key])
+		if string(g) != string(w) {
+			t.Errorf("%s differs:\n got  %s\n want %s", key, g, w)
+		}
+	}
+}
+
+// wantImported checks the created, unchanged, conflicts and removed counts
+// of one section of an import report.
+func wantImported(section string, created, unchanged, conflicts, removed float64) checkFunc {
+	return checks(
+		wantField(created, section, "created"),
+		wantField(unchanged, section, "unchanged"),
+		wantField(conflicts, section, "conflicts"),
+		wantField(removed, section, "removed"),
+	)
+}
+
+func TestExportImportMerge(t *testing.T) {
+	src := newTestServer(t)
+	seedJournal(t, src)
+	doc := exportDoc(t, src)
+	if doc["version"] != float64(exportVersion) || doc["format"] != exportFormat {
+		t.Fatalf("export header = %v %v", doc["format"], doc["version"])
+	}
+
+	dst := newTestServer(t)
+	created := checks(
+		wantImported("team_members", 1, 0, 0, 0),
+		wantImported("entries", 1, 0, 0, 0),
+		wantImported("action_items", 2, 0, 0, 0),
+		wantImported("tags", 1, 15, 0, 0),
+		wantImported("custom_fields", 1, 0, 0, 0),
+		wantImported("prompt_templates", 1, 0, 0, 0),
+		wantImported("redaction_terms", 1, 0, 0, 0),
+		wantLen(0, "conflicts"),
+	)
+	dst.run(t, []apiStep{
+		{name: "dry run", method: "POST", path: "/api/import?dry_run=true", body: doc, status: 200,
+			check: checks(created, wantField(true, "dry_run"))},
+		{name: "dry run changed nothing", method: "GET", path: "/api/team", status: 200, check: wantLen(0)},
+		{name: "dry run left the taxonomy", method: "GET", path: "/api/custom-fields", status: 200, check: wantLen(0)},
+		{name: "merge", method: "POST", path: "/api/import", body: doc, status: 200,
+			check: checks(created, wantField("merge", "mode"), wantField(false, "dry_run"))},
+		{name: "merge again", method: "POST", path: "/api/import", body: doc, status: 200,
+			check: checks(
+				wantImported("team_members", 0, 1, 0, 0),
+				wantImported("entries", 0, 1, 0, 0),
+				wantImported("action_items", 0, 2, 0, 0),
+				wantImported("tags", 0, 16, 0, 0),
+				wantImported("custom_fields", 0, 1, 0, 0),
+				wantImported("prompt_templates", 0, 1, 0, 0),
+				wantImported("redaction_terms", 0, 1, 0, 0),
+				wantLen(0, "conflicts"),
+			)},
+	})
+	sameDoc(t, exportDoc(t, dst), doc)
+}
+
+func TestExportImportReplace(t *testing.T) {
+	src := newTestServer(t)
+	seedJournal(t, src)
+	doc := exportDoc(t, src)
+
+	dst := newTestServer(t)
+	dst.run(t, []apiStep{
+		{name: "create other member", method: "POST", path: "/api/team", body: map[string]string{"name": "Alex"}, status: 201, save: "alex"},
+		{name: "create trashed member", method: "POST", path: "/api/team", body: map[string]string{"name": "Jo"}, status: 201, save: "jo"},
+		{name: "trash it", method: "DELETE", path: "/api/team/$jo", status: 200},
+		{name: "create other tag", method: "POST", path: "/api/tags", body: map[string]string{"name": "Offsite"}, status: 201},
+		{name: "replace dry run", method: "POST", path: "/api/import?mode=replace&dry_run=true", body: doc, status: 200,
+			check: checks(wantImported("team_members", 1, 0, 0, 2), wantImported("tags", 16, 0, 0, 16), wantField(true, "dry_run"))},
+		{name: "dry run kept the journal", method: "GET", path: "/api/team", status: 200,
+			check: checks(wantLen(1), func(t *testing.T, s *testServer, got any) {
+				if list, _ := got.([]any); len(list) == 1 && field(list[0], "name") != "Alex" {
+					t.Errorf("team = %v", got)
+				}
+			})},
+		{name: "replace", method: "POST", path: "/api/import?mode=replace", body: doc, status: 200,
+			check: checks(
+				wantField("replace", "mode"),
+				wantImported("team_members", 1, 0, 0, 2),
+				wantImported("entries", 1, 0, 0, 0),
+				wantImported("action_items", 2, 0, 0, 0),
+				wantImported("tags", 16, 0, 0, 16),
+				wantImported("custom_fields", 1, 0, 0, 0),
+				wantImported("prompt_templates", 1, 0, 0, 0),
+				wantImported("redaction_terms", 1, 0, 0, 0),
+				wantLen(0, "conflicts"),
+			)},
+		{name: "trash is emptied", method: "GET", path: "/api/trash", status: 200, check: checks(wantLen(0, "members"), wantLen(0, "entries"))},
+	})
+	sameDoc(t, exportDoc(t, dst), doc)
+
+	// A version 1 document has no taxonomy, so replacing with it keeps the
+	// journal's.
+	v1 := map[string]any{"format": exportFormat, "version": 1, "team_members": []any{}, "entries": []any{}}
+	dst.run(t, []apiStep{
+		{name: "replace with version 1", method: "POST", path: "/api/import?mode=replace", body: v1, status: 200,
+			check: checks(wantImported("team_members", 0, 0, 0, 1), wantImported("tags", 0, 0, 0, 0), wantImported("redaction_terms", 0, 0, 0, 0))},
+		{name: "tags kept", method: "GET", path: "/api/tags", status: 200, check: wantLen(16)},
+		{name: "terms kept", method: "GET", path: "/api/redaction-terms", status: 200, check: wantLen(1)},
+	})
+}
+
+func TestImportCollisions(t *testing.T) {
+	src := newTestServer(t)
+	seedJournal(t, src)
+	doc := exportDoc(t, src)
+
+	dst := newTestServer(t)
+	dst.vars = src.vars
+	dst.run(t, []apiStep{{name: "merge", method: "POST", path: "/api/import", body: doc, status: 200}})
+
+	// edited returns a copy of doc with fn applied to the last record of
+	// section, which is the seeded one.
+	edited := func(section string, fn func(rec map[string]any)) map[string]any {
+		var c map[string]any
+		b, _ := json.Marshal(doc)
+		json.Unmarshal(b, &c)
+		list := c[section].([]any)
+		fn(list[len(list)-1].(map[string]any))
+		return c
+	}
+	conflict := func(kind, reason string) checkFunc {
+		return func(t *testing.T, s *testServer, got any) {
+			t.Helper()
+			list, _ := field(got, "conflicts").([]any)
+			for _, c := range list {
+				if field(c, "type") == kind && strings.Contains(field(c, "reason").(string), reason) {
+					return
+				}
+			}
+			t.Errorf("no %s conflict with %q in %v", kind, reason, list)
+		}
+	}
+	differs := "differs from the journal's copy"
+
+	dst.run(t, []apiStep{
+		{name: "changed member", method: "POST", path: "/api/import", status: 200,
+			body:  edited("team_members", func(m map[string]any) { m["name"] = "Samantha" }),
+			check: checks(wantImported("team_members", 0, 0, 1, 0), conflict("team_member", differs))},
+		{name: "changed entry", method: "POST", path: "/api/import", status: 200,
+			body:  edited("entries", func(e map[string]any) { e["summary"] = "Something else." }),
+			check: checks(wantImported("entries", 0, 0, 1, 0), conflict("entry", differs))},
+		{name: "changed action item", method: "POST", path: "/api/import", status: 200,
+			body:  edited("action_items", func(a map[string]any) { a["status"] = "done" }),
+			check: checks(wantImported("action_items", 0, 1, 1, 0), conflict("action_item", differs))},
+		{name: "changed tag", method: "POST", path: "/api/import", status: 200,
+			body:  edited("tags", func(tag map[string]any) { tag["color"] = "#00ff00" }),
+			check: checks(wantImported("tags", 0, 15, 1, 0), conflict("tag", differs))},
+		{name: "changed prompt", method: "POST", path: "/api/import", status: 200,
+			body:  edited("prompt_templates", func(p map[string]any) { p["note"] = "other" }),
+			check: conflict("prompt_template", differs)},
+		{name: "tag name taken by another id", method: "POST", path: "/api/import", status: 200,
+			body:  edited("tags", func(tag map[string]any) { tag["id"], tag["name"] = "tag-other", "LAUNCH" }),
+			check: checks(wantImported("tags", 0, 15, 1, 0), conflict("tag", `a tag named "LAUNCH" already exists`))},
+		{name: "field name taken by another id", method: "POST", path: "/api/import", status: 200,
+			body:  edited("custom_fields", func(f map[string]any) { f["id"] = "field-other" }),
+			check: conflict("custom_field", `a field named "career_goals" already exists`)},
+		{name: "term taken by another id", method: "POST", path: "/api/import", status: 200,
+			body:  edited("redaction_terms", func(r map[string]any) { r["id"], r["term"] = "term-other", "project falcon" }),
+			check: conflict("redaction_term", "already on the deny-list")},
+		{name: "entry for an unknown member", method: "POST", path: "/api/import", status: 200,
+			body: edited("entries", func(e map[string]any) { e["id"], e["member_id"] = "entry-other", "member-ghost" }),
+			check: checks(wantImported("entries", 0, 0, 1, 0), wantImported("action_items", 0, 2, 0, 0),
+				conflict("entry", "team member member-ghost is not in the journal or the import"))},
+		{name: "journal kept its copies", method: "GET", path: "/api/entries/$entry", status: 200,
+			check: wantField("Talked about the launch.", "summary")},
+	})
+	dst.run(t, []apiStep{
+		{name: "trash the entry", method: "DELETE", path: "/api/entries/$entry", status: 200},
+		{name: "trashed entry", method: "POST", path: "/api/import", body: doc, status: 200,
+			check: checks(wantImported("entries", 0, 0, 1, 0), conflict("entry", "exists in the trash"))},
+	})
+
+	dup := edited("entries", func(map[string]any) {})
+	dup["entries"] = append(dup["entries"].([]any), dup["entries"].([]any)[0])
+	newer := edited("entries", func(map[string]any) {})
+	newer["version"] = exportVersion + 1
+	dst.run(t, []apiStep{
+		{name: "duplicate id", method: "POST", path: "/api/import", body: dup, status: 400,
+			check: wantContains("appears twice", "error")},
+		{name: "not an export", method: "POST", path: "/api/import", body: map[string]any{"format": "something-else", "version": 1}, status: 400,
+			check: wantContains("not a People Journal export", "error")},
+		{name: "newer version", method: "POST", path: "/api/import", body: newer, status: 400,
+			check: wantContains("is not supported", "error")},
+	})
+}
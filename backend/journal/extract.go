@@ -0,0 +1,258 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type extractRequest struct {
+	Transcript string `json:"transcript"`
+	MemberName string `json:"member_name"`
+	Model      string `json:"model"`
+	MaxTokens  int    `json:"max_tokens"`
+	// MemberID picks up the member's saved speaker labels. Speakers maps
+	// labels in this transcript to manager, member or other; with a MemberID
+	// they're saved for next time.
+	MemberID string            `json:"member_id"`
+	Speakers map[string]string `json:"speakers"`
+
+	attribution attribution
+	// redacted says placeholders stand in for redacted details in Transcript.
+	redacted bool
+}
+
+// attributeSpeakers tags the transcript's turns with who is speaking, using
+// the member's saved labels overlaid with body.Speakers. Nothing changes when
+// no turn can be attributed to the member.
+func (a *App) attributeSpeakers(b *extractRequest) {
+	known := map[string]string{}
+	if b.MemberID != "" {
+		saved, err := loadSpeakerLabels(a.db, b.MemberID)
+		if err != nil {
+			a.log.Printf("Failed to load speaker labels for %s: %v", b.MemberID, err)
+		}
+		for label, speaker := range saved {
+			known[labelKey(label)] = speaker
+		}
+		if len(b.Speakers) > 0 && a.memberExists(b.MemberID) {
+			if err := saveSpeakerLabels(a.db, b.MemberID, b.Speakers); err != nil {
+				a.log.Printf("Failed to save speaker labels for %s: %v", b.MemberID, err)
+			}
+		}
+	}
+	for label, speaker := range b.Speakers {
+		known[labelKey(label)] = speaker
+	}
+	b.attribution = attributeTranscript(b.Transcript, b.MemberName, known)
+	b.Transcript = b.attribution.transcript
+}
+
+func (b extractRequest) attributed() bool {
+	return len(b.attribution.reportTurns) > 0
+}
+
+func (a *App) extractionPromptData(b extractRequest) ExtractionPromptData {
+	member := PromptMember{ID: b.MemberID, Name: b.MemberName}
+	if b.MemberID != "" && a.db != nil {
+		a.db.QueryRow("SELECT role FROM team_members WHERE id = ?", b.MemberID).Scan(&member.Role)
+	}
+	return ExtractionPromptData{
+		Member:       member,
+		Transcript:   b.Transcript,
+		Attributed:   b.attributed(),
+		Redacted:     b.redacted,
+		Tags:         a.activeTags(),
+		CustomFields: a.activeCustomFields(),
+	}
+}
+
+func (b extractRequest) cacheKey() string {
+	keyParts := []string{b.MemberName, b.Transcript}
+	if b.Model != "" {
+		keyParts = append(keyParts, b.Model)
+	}
+	return cacheKey(keyParts...)
+}
+
+func (a *App) extractionCompletion(b extractRequest) CompletionRequest {
+	return CompletionRequest{
+		Prompt:    a.renderPrompt(promptExtraction, a.extractionPromptData(b)),
+		Model:     b.Model,
+		MaxTokens: b.MaxTokens,
+	}
+}
+
+// decodeExtractRequest reads and validates the request body, writing the
+// error response itself when it fails.
+func (a *App) decodeExtractRequest(w http.ResponseWriter, r *http.Request) (extractRequest, bool) {
+	var body extractRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, 400)
+		return body, false
+	}
+	if body.Transcript == "" || body.MemberName == "" {
+		writeJSON(w, 400, map[string]string{"error": "transcript and member_name are required"})
+		return body, false
+	}
+	if err := validateSpeakerLabels(body.Speakers); err != nil {
+		writeJSON(w, 400, map[string]string{"error": err.Error()})
+		return body, false
+	}
+	a.attributeSpeakers(&body)
+	return body, true
+}
+
+// extractCacheCategory holds validated ExtractionResult JSON. It replaced the
+// "extract" category, whose entries were unvalidated model output.
+const extractCacheCategory = "extract-validated"
+
+func (a *App) cachedExtraction(key string) (ExtractionResult, bool) {
+	var result ExtractionResult
+	cached, ok := a.cache.get(key, extractCacheCategory)
+	if !ok {
+		return result, false
+	}
+	if err := json.Unmarshal([]byte(cached), &result); err != nil {
+		return result, false
+	}
+	return result, true
+}
+
+// runExtraction extracts body.Transcript with provider and caches the
+// validated result under key. Transcripts over the chunk threshold go through
+// extractChunked. Model output from the final pass is relayed through onDelta
+// and chunk progress through onChunk; both may be nil. Relayed output still
+// has the redaction placeholders in it; the result doesn't.
+func (a *App) runExtraction(provider Provider, body extractRequest, key string, onChunk func(part, total int) error, onDelta func(string) error) (ExtractionResult, error) {
+	var result ExtractionResult
+	red := a.newRedactor(provider)
+	body.Transcript = red.redact(body.Transcript)
+	body.MemberName = red.redact(body.MemberName)
+	if red != nil && len(red.items) > 0 {
+		body.redacted = true
+		a.log.Printf("[AI] Redacted %d value(s) from the transcript", len(red.items))
+	}
+	if len(body.Transcript) > a.chunkThreshold() {
+		chunks := chunkTranscript(body.Transcript, a.chunkThreshold(), defaultOverlapTurns)
+		a.log.Printf("[AI] Transcript is %d chars, extracting in %d chunks", len(body.Transcript), len(chunks))
+		var err error
+		if result, err = a.extractChunked(provider, body, chunks, onChunk, onDelta); err != nil {
+			return result, err
+		}
+	} else {
+		var text string
+		var err error
+		if onDelta != nil {
+			text, err = streamCompletion(provider, a.extractionCompletion(body), onDelta)
+		} else {
+			text, err = provider.Complete(a.extractionCompletion(body))
+		}
+		if err != nil {
+			return result, err
+		}
+		if result, err = a.validateWithRepair(provider, a.extractionCompletion(body), text); err != nil {
+			return result, err
+		}
+	}
+	red.restoreResult(&result)
+	keepReportQuotes(&result, body.attribution)
+	if len(result.Corrections) > 0 {
+		a.log.Printf("[AI] Extraction needed %d correction(s)", len(result.Corrections))
+	}
+
+	b, _ := json.Marshal(result)
+	a.cache.set(key, extractCacheCategory, string(b))
+	return result, nil
+}
+
+// writeExtractionError answers a failed extraction. Running out of budget
+// part way through a chunked transcript is reported as such; anything else
+// is logged by the caller and kept generic.
+func writeExtractionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBudgetExceeded) {
+		writeJSON(w, 429, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 500, map[string]string{"error": "Failed to extract from transcript"})
+}
+
+func (a *App) handleExtract(w http.ResponseWriter, r *http.Request) {
+	body, ok := a.decodeExtractRequest(w, r)
+	if !ok {
+		return
+	}
+
+	extractKey := body.cacheKey()
+	if result, ok := a.cachedExtraction(extractKey); ok {
+		a.recordCacheHit(purposeExtract)
+		writeJSON(w, 200, result)
+		return
+	}
+
+	provider, err := a.aiProvider(r.Context(), purposeExtract)
+	if err != nil {
+		writeJSON(w, providerErrorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	extracted, err := a.runExtraction(provider, body, extractKey, nil, nil)
+	if err != nil {
+		a.log.Println("Extraction failed:", err)
+		writeExtractionError(w, err)
+		return
+	}
+
+	writeJSON(w, 200, extracted)
+}
+
+// handleExtractStream is the SSE variant of handleExtract. It relays model
+// output as "token" events and finishes with a "result" event holding the
+// parsed JSON, or an "error" event. Chunked transcripts also send a "chunk"
+// event ({"part": n, "total": m}) as each chunk starts; only the merge pass
+// is relayed as tokens.
+func (a *App) handleExtractStream(w http.ResponseWriter, r *http.Request) {
+	body, ok := a.decodeExtractRequest(w, r)
+	if !ok {
+		return
+	}
+
+	extractKey := body.cacheKey()
+	cached, hit := a.cachedExtraction(extractKey)
+	var provider Provider
+	if !hit {
+		var err error
+		if provider, err = a.aiProvider(r.Context(), purposeExtract); err != nil {
+			writeJSON(w, providerErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	sse, err := newSSEWriter(w)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if hit {
+		a.recordCacheHit(purposeExtract)
+		sse.send("result", cached)
+		return
+	}
+
+	onChunk := func(part, total int) error {
+		return sse.send("chunk", map[string]int{"part": part, "total": total})
+	}
+	extracted, err := a.runExtraction(provider, body, extractKey, onChunk, sse.token)
+	if err != nil {
+		a.log.Println("Extraction stream failed:", err)
+		if errors.Is(err, errBudgetExceeded) {
+			sse.fail(err.Error())
+		} else {
+			sse.fail("Failed to extract from transcript")
+		}
+		return
+	}
+
+	sse.send("result", extracted)
+}
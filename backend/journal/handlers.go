@@ -1,20 +1,18 @@
-package main
+package journal
 
 import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 )
 
 // ─── Team Handlers ──────────────────────────────────────
 
-func handleGetTeam(w http.ResponseWriter, r *http.Request) {
-	rows, err := DB.Query("SELECT id, name, role, color, jira_account_id, prep_notes FROM team_members")
+func (a *App) handleGetTeam(w http.ResponseWriter, r *http.Request) {
+	rows, err := a.db.Query("SELECT id, name, role, color, jira_account_id, prep_notes FROM team_members WHERE deleted_at IS NULL")
 	if err != nil {
 		http.Error(w, `{"error":"db error"}`, 500)
 		return
@@ -26,7 +24,7 @@ func handleGetTeam(w http.ResponseWriter, r *http.Request) {
 		var m TeamMember
 		var jiraID, prepNotes sql.NullString
 		if err := rows.Scan(&m.ID, &m.Name, &m.Role, &m.Color, &jiraID, &prepNotes); err != nil {
-			log.Printf("Failed to scan team member: %v", err)
+			a.log.Printf("Failed to scan team member: %v", err)
 			continue
 		}
 		if jiraID.Valid {
@@ -44,7 +42,7 @@ func handleGetTeam(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 200, members)
 }
 
-func handleCreateTeamMember(w http.ResponseWriter, r *http.Request) {
+func (a *App) handleCreateTeamMember(w http.ResponseWriter, r *http.Request) {
 	var body struct {
 		Name  string `json:"name"`
 		Role  string `json:"role"`
@@ -69,18 +67,18 @@ func handleCreateTeamMember(w http.ResponseWriter, r *http.Request) {
 		color = "#888888"
 	}
 
-	if _, err := DB.Exec("INSERT INTO team_members (id, name, role, color) VALUES (?, ?, ?, ?)",
+	if _, err := a.db.Exec("INSERT INTO team_members (id, name, role, color) VALUES (?, ?, ?, ?)",
 		id, name, role, color); err != nil {
-		log.Printf("Failed to create team member: %v", err)
+		a.log.Printf("Failed to create team member: %v", err)
 		writeJSON(w, 500, map[string]string{"error": "failed to create team member"})
 		return
 	}
 
 	var m TeamMember
 	var jiraID, prepNotes sql.NullString
-	if err := DB.QueryRow("SELECT id, name, role, color, jira_account_id, prep_notes FROM team_members WHERE id = ?", id).
+	if err := a.db.QueryRow("SELECT id, name, role, color, jira_account_id, prep_notes FROM team_members WHERE id = ?", id).
 		Scan(&m.ID, &m.Name, &m.Role, &m.Color, &jiraID, &prepNotes); err != nil {
-		log.Printf("Failed to read created team member: %v", err)
+		a.log.Printf("Failed to read created team member: %v", err)
 		writeJSON(w, 500, map[string]string{"error": "failed to read created team member"})
 		return
 	}
@@ -94,7 +92,7 @@ func handleCreateTeamMember(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 201, m)
 }
 
-func handleUpdateTeamMember(w http.ResponseWriter, r *http.Request) {
+func (a *App) handleUpdateTeamMember(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	var body struct {
 		Name          string  `json:"name"`
@@ -107,12 +105,12 @@ func handleUpdateTeamMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := DB.Exec(
-		"UPDATE team_members SET name = ?, role = ?, color = ?, jira_account_id = ? WHERE id = ?",
+	res, err := a.db.Exec(
+		"UPDATE team_members SET name = ?, role = ?, color = ?, jira_account_id = ? WHERE id = ? AND deleted_at IS NULL",
 		body.Name, body.Role, body.Color, body.JiraAccountID, id,
 	)
 	if err != nil {
-		log.Printf("Failed to update team member %s: %v", id, err)
+		a.log.Printf("Failed to update team member %s: %v", id, err)
 		writeJSON(w, 500, map[string]string{"error": "failed to update team member"})
 		return
 	}
@@ -122,9 +120,9 @@ func handleUpdateTeamMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	m, err := scanTeamMember(DB.QueryRow("SELECT id, name, role, color, jira_account_id, prep_notes FROM team_members WHERE id = ?", id))
+	m, err := scanTeamMember(a.db.QueryRow("SELECT id, name, role, color, jira_account_id, prep_notes FROM team_members WHERE id = ?", id))
 	if err != nil {
-		log.Printf("Failed to read updated team member: %v", err)
+		a.log.Printf("Failed to read updated team member: %v", err)
 		writeJSON(w, 500, map[string]string{"error": "failed to read updated team member"})
 		return
 	}
@@ -132,26 +130,22 @@ func handleUpdateTeamMember(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 200, m)
 }
 
-func handleDeleteTeamMember(w http.ResponseWriter, r *http.Request) {
+// handleDeleteTeamMember moves a member and their entries to the trash.
+func (a *App) handleDeleteTeamMember(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
+	now := deletionTime()
 
-	tx, err := DB.Begin()
+	tx, err := a.db.Begin()
 	if err != nil {
-		log.Printf("Failed to begin transaction: %v", err)
+		a.log.Printf("Failed to begin transaction: %v", err)
 		writeJSON(w, 500, map[string]string{"error": "db error"})
 		return
 	}
 	defer tx.Rollback()
 
-	if _, err := tx.Exec("DELETE FROM entries WHERE member_id = ?", id); err != nil {
-		log.Printf("Failed to delete entries for member %s: %v", id, err)
-		writeJSON(w, 500, map[string]string{"error": "failed to delete member entries"})
-		return
-	}
-
-	res, err := tx.Exec("DELETE FROM team_members WHERE id = ?", id)
+	res, err := tx.Exec("UPDATE team_members SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", now, id)
 	if err != nil {
-		log.Printf("Failed to delete team member %s: %v", id, err)
+		a.log.Printf("Failed to delete team member %s: %v", id, err)
 		writeJSON(w, 500, map[string]string{"error": "failed to delete team member"})
 		return
 	}
@@ -161,8 +155,16 @@ func handleDeleteTeamMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Entries share the member's deleted_at so restoring the member brings
+	// back exactly these, and not entries that were trashed on their own.
+	if _, err := tx.Exec("UPDATE entries SET deleted_at = ? WHERE member_id = ? AND deleted_at IS NULL", now, id); err != nil {
+		a.log.Printf("Failed to delete entries for member %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to delete member entries"})
+		return
+	}
+
 	if err := tx.Commit(); err != nil {
-		log.Printf("Failed to commit delete for member %s: %v", id, err)
+		a.log.Printf("Failed to commit delete for member %s: %v", id, err)
 		writeJSON(w, 500, map[string]string{"error": "db error"})
 		return
 	}
@@ -172,20 +174,33 @@ func handleDeleteTeamMember(w http.ResponseWriter, r *http.Request) {
 
 // ─── Config Handler ─────────────────────────────────────
 
-func handleGetConfig(w http.ResponseWriter, r *http.Request) {
-	configured := jiraConfigured()
+func (a *App) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 	config := map[string]any{
-		"jira_configured": configured,
+		"jira_configured": a.jira != nil,
 	}
-	if configured {
-		config["jira_base_url"] = strings.TrimRight(os.Getenv("JIRA_BASE_URL"), "/")
+	if a.jira != nil {
+		config["jira_base_url"] = a.jira.baseURL
+	}
+	if a.provider != nil {
+		config["ai_provider"] = a.provider.Name()
+		config["ai_offline"] = providerIsOffline(a.provider)
+	} else {
+		config["ai_provider"] = nil
+		config["ai_offline"] = false
+	}
+	config["backup_dir"] = a.backupDir()
+	config["settings"] = a.cfg.redacted()
+	if t := a.lastBackupTime(); t != nil {
+		config["last_backup"] = t.Format(time.RFC3339)
+	} else {
+		config["last_backup"] = nil
 	}
 	writeJSON(w, 200, config)
 }
 
 // ─── Entry Handlers ─────────────────────────────────────
 
-func handleGetEntries(w http.ResponseWriter, r *http.Request) {
+func (a *App) handleGetEntries(w http.ResponseWriter, r *http.Request) {
 	memberID := r.URL.Query().Get("member_id")
 
 	var rows interface {
@@ -197,9 +212,9 @@ func handleGetEntries(w http.ResponseWriter, r *http.Request) {
 	var err error
 
 	if memberID != "" {
-		rows, err = DB.Query(entryQuery("WHERE member_id = ?"), memberID)
+		rows, err = a.db.Query(entryQuery("WHERE deleted_at IS NULL AND member_id = ?"), memberID)
 	} else {
-		rows, err = DB.Query(entryQuery(""))
+		rows, err = a.db.Query(entryQuery("WHERE deleted_at IS NULL"))
 	}
 	if err != nil {
 		http.Error(w, `{"error":"db error"}`, 500)
@@ -209,9 +224,9 @@ func handleGetEntries(w http.ResponseWriter, r *http.Request) {
 
 	entries := []Entry{}
 	for rows.Next() {
-		e, err := scanEntry(rows)
+		e, err := a.scanEntry(rows)
 		if err != nil {
-			log.Printf("Failed to scan entry: %v", err)
+			a.log.Printf("Failed to scan entry: %v", err)
 			continue
 		}
 		entries = append(entries, e)
@@ -223,10 +238,10 @@ func handleGetEntries(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 200, entries)
 }
 
-func handleGetEntry(w http.ResponseWriter, r *http.Request) {
+func (a *App) handleGetEntry(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	row := DB.QueryRow(fmt.Sprintf("SELECT %s FROM entries WHERE id = ?", entryCols), id)
-	e, err := scanEntry(row)
+	row := a.db.QueryRow(fmt.Sprintf("SELECT %s FROM entries WHERE id = ? AND deleted_at IS NULL", entryCols), id)
+	e, err := a.scanEntry(row)
 	if err != nil {
 		http.Error(w, `{"error":"Entry not found"}`, 404)
 		return
@@ -234,7 +249,7 @@ func handleGetEntry(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 200, e)
 }
 
-func handleCreateEntry(w http.ResponseWriter, r *http.Request) {
+func (a *App) handleCreateEntry(w http.ResponseWriter, r *http.Request) {
 	var body map[string]any
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, `{"error":"invalid json"}`, 400)
@@ -255,7 +270,7 @@ func handleCreateEntry(w http.ResponseWriter, r *http.Request) {
 	growthScore := nullInt(body["growth_score"])
 	moraleRationale := nullString(body["morale_rationale"])
 	growthRationale := nullString(body["growth_rationale"])
-	privateNote := nullString(body["private_note"])
+	privateNote := a.encryptValue(nullString(body["private_note"]))
 
 	tags := jsonStringify(body["tags"])
 	actionMine := jsonStringify(body["action_items_mine"])
@@ -264,46 +279,84 @@ func handleCreateEntry(w http.ResponseWriter, r *http.Request) {
 	blockers := jsonStringify(body["blockers"])
 	wins := jsonStringify(body["wins"])
 
-	transcript := nullString(body["transcript"])
+	transcript := a.encryptValue(nullString(body["transcript"]))
 	now := time.Now().UTC().Format(time.RFC3339)
 
-	if _, err := DB.Exec(`
+	fields, err := loadCustomFields(a.db, true)
+	if err != nil {
+		a.log.Printf("Failed to load custom fields: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	customFields, err := mergeCustomValues(fields, nil, body["custom_fields"])
+	if err != nil {
+		writeJSON(w, 400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	var trashed bool
+	if err := a.db.QueryRow("SELECT deleted_at IS NOT NULL FROM team_members WHERE id = ?", memberID).Scan(&trashed); err == nil && trashed {
+		writeJSON(w, 404, map[string]string{"error": "member not found"})
+		return
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		a.log.Printf("Failed to begin transaction: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
 		INSERT INTO entries (id, member_id, date, summary, morale_score, growth_score,
 			morale_rationale, growth_rationale,
 			tags, action_items_mine, action_items_theirs, notable_quotes, blockers, wins,
-			private_note, transcript, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			private_note, transcript, created_at, updated_at, custom_fields)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		id, memberID, date, summary, moraleScore, growthScore,
 		moraleRationale, growthRationale,
 		tags, actionMine, actionTheirs, quotes, blockers, wins,
-		privateNote, transcript, now, now,
+		privateNote, transcript, now, now, customValuesJSON(customFields),
 	); err != nil {
-		log.Printf("Failed to create entry: %v", err)
+		a.log.Printf("Failed to create entry: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "failed to create entry"})
+		return
+	}
+
+	if err := syncEntryActionItems(tx, id, body); err != nil {
+		a.log.Printf("Failed to save action items for entry %s: %v", id, err)
 		writeJSON(w, 500, map[string]string{"error": "failed to create entry"})
 		return
 	}
 
 	// Clear prep notes — they were for this meeting, which just happened
 	if memberID != "" {
-		DB.Exec("UPDATE team_members SET prep_notes = NULL WHERE id = ?", memberID)
+		tx.Exec("UPDATE team_members SET prep_notes = NULL WHERE id = ?", memberID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.log.Printf("Failed to commit entry %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
 	}
 
-	row := DB.QueryRow(fmt.Sprintf("SELECT %s FROM entries WHERE id = ?", entryCols), id)
-	e, err := scanEntry(row)
+	row := a.db.QueryRow(fmt.Sprintf("SELECT %s FROM entries WHERE id = ?", entryCols), id)
+	e, err := a.scanEntry(row)
 	if err != nil {
-		log.Printf("Failed to read created entry: %v", err)
+		a.log.Printf("Failed to read created entry: %v", err)
 		writeJSON(w, 500, map[string]string{"error": "failed to read created entry"})
 		return
 	}
 	writeJSON(w, 201, e)
 }
 
-func handleUpdateEntry(w http.ResponseWriter, r *http.Request) {
+func (a *App) handleUpdateEntry(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
 	// Check entry exists
-	row := DB.QueryRow(fmt.Sprintf("SELECT %s FROM entries WHERE id = ?", entryCols), id)
-	existing, err := scanEntry(row)
+	row := a.db.QueryRow(fmt.Sprintf("SELECT %s FROM entries WHERE id = ? AND deleted_at IS NULL", entryCols), id)
+	existing, err := a.scanEntry(row)
 	if err != nil {
 		http.Error(w, `{"error":"Entry not found"}`, 404)
 		return
@@ -321,27 +374,57 @@ func handleUpdateEntry(w http.ResponseWriter, r *http.Request) {
 		"blockers", "wins", "private_note", "transcript",
 	}
 	jsonFields := map[string]bool{
-		"tags": true, "action_items_mine": true, "action_items_theirs": true,
-		"notable_quotes": true, "blockers": true, "wins": true,
+		"tags": true, "notable_quotes": true, "blockers": true, "wins": true,
+	}
+	encryptedFields := map[string]bool{
+		"private_note": true, "transcript": true,
+	}
+	// Action items are written through the action_items table instead
+	actionFields := map[string]bool{
+		"action_items_mine": true, "action_items_theirs": true,
 	}
 
 	var setClauses []string
 	var values []any
+	hasActionItems := false
 
 	for _, field := range allowedFields {
 		val, ok := body[field]
 		if !ok {
 			continue
 		}
+		if actionFields[field] {
+			hasActionItems = true
+			continue
+		}
 		setClauses = append(setClauses, field+" = ?")
 		if jsonFields[field] {
 			values = append(values, jsonStringify(val))
+		} else if encryptedFields[field] {
+			values = append(values, a.encryptValue(val))
 		} else {
 			values = append(values, val)
 		}
 	}
 
-	if len(setClauses) == 0 {
+	// Custom fields are merged into the entry's current values
+	if patch, ok := body["custom_fields"]; ok {
+		fields, err := loadCustomFields(a.db, true)
+		if err != nil {
+			a.log.Printf("Failed to load custom fields: %v", err)
+			writeJSON(w, 500, map[string]string{"error": "db error"})
+			return
+		}
+		merged, err := mergeCustomValues(fields, existing.CustomFields, patch)
+		if err != nil {
+			writeJSON(w, 400, map[string]string{"error": err.Error()})
+			return
+		}
+		setClauses = append(setClauses, "custom_fields = ?")
+		values = append(values, customValuesJSON(merged))
+	}
+
+	if len(setClauses) == 0 && !hasActionItems {
 		writeJSON(w, 200, existing)
 		return
 	}
@@ -350,28 +433,62 @@ func handleUpdateEntry(w http.ResponseWriter, r *http.Request) {
 	setClauses = append(setClauses, "updated_at = ?")
 	values = append(values, time.Now().UTC().Format(time.RFC3339))
 
+	tx, err := a.db.Begin()
+	if err != nil {
+		a.log.Printf("Failed to begin transaction: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+
+	before, err := a.readEntry(tx, id)
+	if err != nil {
+		writeJSON(w, 404, map[string]string{"error": "entry not found"})
+		return
+	}
+
 	values = append(values, id)
-	if _, err := DB.Exec(fmt.Sprintf("UPDATE entries SET %s WHERE id = ?", strings.Join(setClauses, ", ")), values...); err != nil {
-		log.Printf("Failed to update entry %s: %v", id, err)
+	if _, err := tx.Exec(fmt.Sprintf("UPDATE entries SET %s WHERE id = ?", strings.Join(setClauses, ", ")), values...); err != nil {
+		a.log.Printf("Failed to update entry %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to update entry"})
+		return
+	}
+
+	if err := syncEntryActionItems(tx, id, body); err != nil {
+		a.log.Printf("Failed to save action items for entry %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to update entry"})
+		return
+	}
+
+	if err := a.recordRevision(tx, before, "update"); err != nil {
+		a.log.Printf("Failed to record revision for entry %s: %v", id, err)
 		writeJSON(w, 500, map[string]string{"error": "failed to update entry"})
 		return
 	}
 
-	row = DB.QueryRow(fmt.Sprintf("SELECT %s FROM entries WHERE id = ?", entryCols), id)
-	updated, err := scanEntry(row)
+	if err := tx.Commit(); err != nil {
+		a.log.Printf("Failed to commit update for entry %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+
+	row = a.db.QueryRow(fmt.Sprintf("SELECT %s FROM entries WHERE id = ?", entryCols), id)
+	updated, err := a.scanEntry(row)
 	if err != nil {
-		log.Printf("Failed to read updated entry %s: %v", id, err)
+		a.log.Printf("Failed to read updated entry %s: %v", id, err)
 		writeJSON(w, 500, map[string]string{"error": "failed to read updated entry"})
 		return
 	}
 	writeJSON(w, 200, updated)
 }
 
-func handleDeleteEntry(w http.ResponseWriter, r *http.Request) {
+// handleDeleteEntry moves an entry to the trash.
+func (a *App) handleDeleteEntry(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	res, err := DB.Exec("DELETE FROM entries WHERE id = ?", id)
+
+	res, err := a.db.Exec("UPDATE entries SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", deletionTime(), id)
 	if err != nil {
-		log.Printf("Failed to delete entry %s: %v", id, err)
+		a.log.Printf("Failed to delete entry %s: %v", id, err)
 		writeJSON(w, 500, map[string]string{"error": "failed to delete entry"})
 		return
 	}
@@ -429,7 +546,7 @@ func scanTeamMember(row interface{ Scan(...any) error }) (TeamMember, error) {
 	return m, nil
 }
 
-func handleUpdatePrepNotes(w http.ResponseWriter, r *http.Request) {
+func (a *App) handleUpdatePrepNotes(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	var body struct {
 		PrepNotes string `json:"prep_notes"`
@@ -444,9 +561,9 @@ func handleUpdatePrepNotes(w http.ResponseWriter, r *http.Request) {
 		val = nil
 	}
 
-	res, err := DB.Exec("UPDATE team_members SET prep_notes = ? WHERE id = ?", val, id)
+	res, err := a.db.Exec("UPDATE team_members SET prep_notes = ? WHERE id = ? AND deleted_at IS NULL", val, id)
 	if err != nil {
-		log.Printf("Failed to update prep notes for %s: %v", id, err)
+		a.log.Printf("Failed to update prep notes for %s: %v", id, err)
 		writeJSON(w, 500, map[string]string{"error": "failed to update prep notes"})
 		return
 	}
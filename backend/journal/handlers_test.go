@@ -0,0 +1,474 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testServer is the full API router of an App with a fresh database, with
+// the mock provider as the only AI provider and JIRA unconfigured unless
+// configure says otherwise.
+type testServer struct {
+	*httptest.Server
+	app *App
+	// mock is the App's provider, nil when configure picked another.
+	mock *mockProvider
+	vars map[string]string
+}
+
+func newTestServer(t *testing.T, configure ...func(c *Config)) *testServer {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	cfg.AIProvider = "mock"
+	cfg.HTTPMaxRetries = 0
+	for _, fn := range configure {
+		fn(&cfg)
+	}
+
+	app := newApp(cfg)
+	if err := app.open(filepath.Join(cfg.DataDir, "people-journal.db")); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.loadEncryption(); err != nil {
+		app.Close()
+		t.Fatal(err)
+	}
+	mock, _ := app.provider.(*mockProvider)
+
+	srv := httptest.NewServer(app.Handler())
+	t.Cleanup(func() {
+		srv.Close()
+		app.Close()
+	})
+	return &testServer{Server: srv, app: app, mock: mock, vars: map[string]string{}}
+}
+
+// do sends a request with body encoded as JSON, after replacing $name in
+// the path and body with saved values.
+func (s *testServer) do(t *testing.T, method, path string, body any) (int, []byte) {
+	t.Helper()
+	var data []byte
+	switch b := body.(type) {
+	case nil:
+	case string:
+		data = []byte(b)
+	default:
+		data, _ = json.Marshal(b)
+	}
+	for name, v := range s.vars {
+		path = strings.ReplaceAll(path, "$"+name, v)
+		data = bytes.ReplaceAll(data, []byte("$"+name), []byte(v))
+	}
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(data)
+	}
+	req, _ := http.NewRequest(method, s.URL+path, r)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody
+}
+
+// apiStep is one request in a table-driven test. save stores the response's
+// "id" under that name for later paths; check inspects the decoded body, or
+// the raw text of a body that isn't JSON.
+type apiStep struct {
+	name   string
+	method string
+	path   string
+	body   any
+	status int
+	save   string
+	check  checkFunc
+}
+
+// checkFunc inspects a decoded response body.
+type checkFunc func(t *testing.T, s *testServer, got any)
+
+func (s *testServer) run(t *testing.T, steps []apiStep) {
+	t.Helper()
+	for _, st := range steps {
+		ok := t.Run(st.name, func(t *testing.T) {
+			status, data := s.do(t, st.method, st.path, st.body)
+			if status != st.status {
+				t.Fatalf("%s %s = %d, want %d: %s", st.method, st.path, status, st.status, data)
+			}
+			var got any
+			if json.Unmarshal(data, &got) != nil {
+				got = string(data)
+			}
+			if st.save != "" {
+				id, _ := field(got, "id").(string)
+				if id == "" {
+					t.Fatalf("no id to save in %s", data)
+				}
+				s.vars[st.save] = id
+				// IDs are millisecond timestamps
+				time.Sleep(2 * time.Millisecond)
+			}
+			if st.check != nil {
+				st.check(t, s, got)
+			}
+		})
+		if !ok {
+			t.FailNow()
+		}
+	}
+}
+
+// field digs into decoded JSON by object keys.
+func field(v any, keys ...string) any {
+	for _, k := range keys {
+		m, _ := v.(map[string]any)
+		v = m[k]
+	}
+	return v
+}
+
+func wantField(want any, keys ...string) checkFunc {
+	return func(t *testing.T, s *testServer, got any) {
+		t.Helper()
+		if g := field(got, keys...); g != want {
+			t.Errorf("%s = %v, want %v", strings.Join(keys, "."), g, want)
+		}
+	}
+}
+
+func wantLen(n int, keys ...string) checkFunc {
+	return func(t *testing.T, s *testServer, got any) {
+		t.Helper()
+		list, _ := field(got, keys...).([]any)
+		if len(list) != n {
+			t.Errorf("len(%s) = %d, want %d: %v", strings.Join(keys, "."), len(list), n, field(got, keys...))
+		}
+	}
+}
+
+func wantContains(sub string, keys ...string) checkFunc {
+	return func(t *testing.T, s *testServer, got any) {
+		t.Helper()
+		if s, _ := field(got, keys...).(string); !strings.Contains(s, sub) {
+			t.Errorf("%s = %q, want it to contain %q", strings.Join(keys, "."), s, sub)
+		}
+	}
+}
+
+func wantPrompts(n int) checkFunc {
+	return func(t *testing.T, s *testServer, got any) {
+		t.Helper()
+		if p := s.mock.prompts(); len(p) != n {
+			t.Errorf("mock was called %d times, want %d", len(p), n)
+		}
+	}
+}
+
+func checks(fns ...checkFunc) checkFunc {
+	return func(t *testing.T, s *testServer, got any) {
+		t.Helper()
+		for _, fn := range fns {
+			fn(t, s, got)
+		}
+	}
+}
+
+// ─── Team and Entries ───────────────────────────────────
+
+func TestTeamCRUD(t *testing.T) {
+	s := newTestServer(t)
+	s.run(t, []apiStep{
+		{name: "empty list", method: "GET", path: "/api/team", status: 200, check: wantLen(0)},
+		{name: "create", method: "POST", path: "/api/team", body: map[string]string{"name": "Sam Lee", "role": "Staff Engineer"}, status: 201, save: "sam",
+			check: checks(wantField("Sam Lee", "name"), wantField("Staff Engineer", "role"), wantField("#888888", "color"))},
+		{name: "create with defaults", method: "POST", path: "/api/team", body: map[string]string{}, status: 201, save: "new",
+			check: checks(wantField("New Member", "name"), wantField("Engineer", "role"))},
+		{name: "list", method: "GET", path: "/api/team", status: 200, check: wantLen(2)},
+		{name: "update", method: "PUT", path: "/api/team/$sam", body: map[string]string{"name": "Sam Lee", "role": "Tech Lead", "color": "#3D405B"}, status: 200,
+			check: checks(wantField("Tech Lead", "role"), wantField("#3D405B", "color"))},
+		{name: "prep notes", method: "PUT", path: "/api/team/$sam/prep-notes", body: map[string]string{"prep_notes": "Ask about the offsite"}, status: 200,
+			check: wantField("Ask about the offsite", "prep_notes")},
+		{name: "delete", method: "DELETE", path: "/api/team/$new", status: 200, check: wantField(true, "deleted")},
+		{name: "deleted member is gone", method: "GET", path: "/api/team", status: 200, check: wantLen(1)},
+		{name: "delete twice", method: "DELETE", path: "/api/team/$new", status: 404},
+		{name: "create with invalid json", method: "POST", path: "/api/team", body: "{", status: 400},
+	})
+}
+
+func TestEntryCRUD(t *testing.T) {
+	s := newTestServer(t)
+	s.run(t, []apiStep{
+		{name: "create member", method: "POST", path: "/api/team", body: map[string]string{"name": "Sam"}, status: 201, save: "sam"},
+		{name: "create entry", method: "POST", path: "/api/entries", status: 201, save: "entry",
+			body: map[string]any{
+				"member_id": "$sam", "date": "2024-05-01T15:00:00Z", "summary": "Talked about the launch.",
+				"morale_score": 4, "growth_score": 3, "tags": []string{"wins"},
+				"action_items_theirs": []map[string]any{{"text": "Write the retro"}},
+				"blockers":            []string{"Waiting on infra review"},
+			},
+			check: checks(wantField("Talked about the launch.", "summary"), wantField(4.0, "morale_score"), wantLen(1, "action_items_theirs"))},
+		{name: "get", method: "GET", path: "/api/entries/$entry", status: 200, check: wantLen(1, "blockers")},
+		{name: "list for member", method: "GET", path: "/api/entries?member_id=$sam", status: 200, check: wantLen(1)},
+		{name: "list for another member", method: "GET", path: "/api/entries?member_id=member-x", status: 200, check: wantLen(0)},
+		{name: "update", method: "PUT", path: "/api/entries/$entry", body: map[string]any{"summary": "Launch went well.", "morale_score": 5}, status: 200,
+			check: checks(wantField("Launch went well.", "summary"), wantField(5.0, "morale_score"), wantField(3.0, "growth_score"))},
+		{name: "revision recorded", method: "GET", path: "/api/entries/$entry/revisions", status: 200, check: wantLen(1)},
+		{name: "delete", method: "DELETE", path: "/api/entries/$entry", status: 200},
+		{name: "get deleted", method: "GET", path: "/api/entries/$entry", status: 404},
+		{name: "list after delete", method: "GET", path: "/api/entries?member_id=$sam", status: 200, check: wantLen(0)},
+		{name: "update missing", method: "PUT", path: "/api/entries/entry-missing", body: map[string]any{"summary": "x"}, status: 404},
+		{name: "create with invalid json", method: "POST", path: "/api/entries", body: "[", status: 400},
+	})
+}
+
+func TestAppsAreIndependent(t *testing.T) {
+	a, b := newTestServer(t), newTestServer(t)
+	a.run(t, []apiStep{
+		{name: "create in a", method: "POST", path: "/api/team", body: map[string]string{"name": "Sam"}, status: 201},
+		{name: "extract in a", method: "POST", path: "/api/extract", body: map[string]any{"transcript": "Sam: Hi.", "member_name": "Sam"}, status: 200, check: wantPrompts(1)},
+	})
+	b.run(t, []apiStep{
+		{name: "b has no members", method: "GET", path: "/api/team", status: 200, check: wantLen(0)},
+		{name: "b's mock wasn't called", method: "GET", path: "/api/usage", status: 200, check: checks(wantField(0.0, "total", "calls"), wantPrompts(0))},
+	})
+}
+
+// ─── Extraction ─────────────────────────────────────────
+
+func TestExtractCaching(t *testing.T) {
+	s := newTestServer(t)
+	transcript := "Dana: How did the launch go?\n\nSam: Really well, the rollback plan helped."
+	req := map[string]any{"transcript": transcript, "member_name": "Sam"}
+
+	s.run(t, []apiStep{
+		{name: "first call extracts", method: "POST", path: "/api/extract", body: req, status: 200,
+			check: checks(wantContains("Mock summary of a 1:1 with Sam", "summary"), wantLen(1, "action_items_mine"), wantPrompts(1))},
+		{name: "same request is cached", method: "POST", path: "/api/extract", body: req, status: 200, check: wantPrompts(1)},
+		{name: "model override is a separate cache entry", method: "POST", path: "/api/extract", status: 200, check: wantPrompts(2),
+			body: map[string]any{"transcript": transcript, "member_name": "Sam", "model": "mock-large"}},
+		{name: "different transcript extracts again", method: "POST", path: "/api/extract", status: 200, check: wantPrompts(3),
+			body: map[string]any{"transcript": transcript + "\n\nDana: Great.", "member_name": "Sam"}},
+		{name: "usage counts calls and cache hits", method: "GET", path: "/api/usage?purpose=extract", status: 200,
+			check: checks(wantField(3.0, "total", "calls"), wantField(1.0, "total", "cache_hits"))},
+	})
+
+	// The streaming endpoint shares the cache
+	status, data := s.do(t, "POST", "/api/extract/stream", req)
+	if status != 200 || !strings.Contains(string(data), "event: result") || strings.Contains(string(data), "event: token") {
+		t.Errorf("cached stream = %d %s", status, data)
+	}
+	if n := len(s.mock.prompts()); n != 3 {
+		t.Errorf("mock was called %d times after a cached stream, want 3", n)
+	}
+}
+
+func TestExtractCustomFields(t *testing.T) {
+	s := newTestServer(t)
+	s.run(t, []apiStep{
+		{name: "define field", method: "POST", path: "/api/custom-fields", status: 201,
+			body: map[string]string{"name": "career_goals", "type": "text", "instructions": "career goals they mentioned"}},
+		{name: "extract", method: "POST", path: "/api/extract", status: 200,
+			body:  map[string]any{"transcript": "Sam: I want to lead a team next year.", "member_name": "Sam"},
+			check: checks(wantField("Mock career_goals", "custom_fields", "career_goals"), wantPrompts(1))},
+	})
+}
+
+// ─── Prep ───────────────────────────────────────────────
+
+// fakeJIRA serves the handful of endpoints prep uses, with one open ticket
+// and one finished one for the user "Sam".
+func fakeJIRA(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "manager@example.com" || pass != "jira-token" {
+			http.Error(w, "unauthorized", 401)
+			return
+		}
+		switch r.URL.Path {
+		case "/rest/api/3/user/search":
+			io.WriteString(w, `[{"accountId":"acct-sam","displayName":"Sam","active":true}]`)
+		case "/rest/api/3/field":
+			io.WriteString(w, `[{"id":"customfield_10016","name":"Story Points"},{"id":"customfield_10014","name":"Epic Name"}]`)
+		case "/rest/api/3/search/jql":
+			var body struct{ JQL string }
+			json.NewDecoder(r.Body).Decode(&body)
+			if strings.Contains(body.JQL, "openSprints") {
+				io.WriteString(w, `{"issues":[{"key":"PJ-42","fields":{"summary":"Ship the importer","status":{"name":"In Progress"},"customfield_10016":3}}]}`)
+			} else {
+				io.WriteString(w, `{"issues":[{"key":"PJ-7","fields":{"summary":"Fix login","status":{"name":"Done"}}}]}`)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPrep(t *testing.T) {
+	entry := map[string]any{
+		"member_id": "$sam", "date": "2024-05-01T15:00:00Z", "summary": "Talked about the launch.",
+		"action_items_mine": []map[string]any{{"text": "Share the promo rubric"}},
+	}
+	// The JIRA account linked on the first briefing is part of the cache
+	// key, so with JIRA the second briefing is generated too.
+	tests := []struct {
+		name    string
+		jira    bool
+		bringUp bool
+		calls   int
+	}{
+		{name: "without JIRA", calls: 1},
+		{name: "with JIRA", jira: true, bringUp: true, calls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var configure []func(c *Config)
+			if tt.jira {
+				jiraURL := fakeJIRA(t).URL
+				configure = append(configure, func(c *Config) {
+					c.JIRABaseURL = jiraURL
+					c.JIRAEmail = "manager@example.com"
+					c.JIRAAPIToken = "jira-token"
+				})
+			}
+			s := newTestServer(t, configure...)
+			s.run(t, []apiStep{
+				{name: "create member", method: "POST", path: "/api/team", body: map[string]string{"name": "Sam"}, status: 201, save: "sam"},
+				{name: "no entries yet", method: "POST", path: "/api/prep", body: map[string]string{"member_id": "$sam"}, status: 200,
+					check: checks(wantField("No entries yet for this team member.", "briefing"), wantPrompts(0))},
+				{name: "create entry", method: "POST", path: "/api/entries", body: entry, status: 201},
+				{name: "prep", method: "POST", path: "/api/prep", body: map[string]string{"member_id": "$sam"}, status: 200,
+					check: checks(wantContains("**Follow up on**", "briefing"), wantLen(1, "open_items_mine"), wantPrompts(1))},
+				{name: "second prep", method: "POST", path: "/api/prep", body: map[string]string{"member_id": "$sam"}, status: 200, check: wantPrompts(tt.calls)},
+				{name: "prep is cached", method: "POST", path: "/api/prep", body: map[string]string{"member_id": "$sam"}, status: 200, check: wantPrompts(tt.calls)},
+				{name: "force skips the cache", method: "POST", path: "/api/prep", body: map[string]any{"member_id": "$sam", "force": true}, status: 200, check: wantPrompts(tt.calls + 1)},
+			})
+
+			_, data := s.do(t, "POST", "/api/prep", map[string]string{"member_id": s.vars["sam"]})
+			var resp PrepResponse
+			json.Unmarshal(data, &resp)
+			if got := strings.Contains(resp.Briefing, "**Bring up**"); got != tt.bringUp {
+				t.Errorf("briefing has Bring up = %v, want %v:\n%s", got, tt.bringUp, resp.Briefing)
+			}
+			prompt := s.mock.prompts()[0]
+			if !tt.jira {
+				if len(resp.JIRAAssigned) != 0 || strings.Contains(prompt, "JIRA") {
+					t.Errorf("JIRA data without JIRA configured: %+v", resp.JIRAAssigned)
+				}
+				return
+			}
+			if len(resp.JIRAAssigned) != 1 || resp.JIRAAssigned[0].Key != "PJ-42" || len(resp.JIRACompleted) != 1 {
+				t.Errorf("jira assigned=%+v completed=%+v", resp.JIRAAssigned, resp.JIRACompleted)
+			}
+			if resp.JIRASprintStats == nil || resp.JIRASprintStats.PointsCommitted != 3 {
+				t.Errorf("sprint stats = %+v", resp.JIRASprintStats)
+			}
+			if !strings.Contains(prompt, "PJ-42") || !strings.Contains(resp.Briefing, "PJ-42") {
+				t.Errorf("ticket missing from prompt or briefing:\n%s", resp.Briefing)
+			}
+			var accountID string
+			s.app.db.QueryRow("SELECT jira_account_id FROM team_members WHERE id = ?", s.vars["sam"]).Scan(&accountID)
+			if accountID != "acct-sam" {
+				t.Errorf("jira_account_id = %q, want it cached", accountID)
+			}
+		})
+	}
+}
+
+// ─── Error Paths ────────────────────────────────────────
+
+func TestErrorPaths(t *testing.T) {
+	extract := map[string]any{"transcript": "Sam: All good.", "member_name": "Sam"}
+	noProvider := func(c *Config) { c.AIProvider = "" }
+	tests := []struct {
+		name      string
+		configure func(c *Config)
+		setup     func(t *testing.T, s *testServer)
+		method    string
+		path      string
+		body      any
+		status    int
+		check     checkFunc
+	}{
+		{name: "extract invalid json", method: "POST", path: "/api/extract", body: "{", status: 400},
+		{name: "extract missing member", method: "POST", path: "/api/extract", body: map[string]string{"transcript": "hi"}, status: 400,
+			check: wantField("transcript and member_name are required", "error")},
+		{name: "extract bad speaker role", method: "POST", path: "/api/extract", status: 400,
+			body: map[string]any{"transcript": "hi", "member_name": "Sam", "speakers": map[string]string{"Sam": "boss"}}},
+		{name: "extract without a provider", method: "POST", path: "/api/extract", body: extract, status: 500,
+			configure: noProvider,
+			check:     wantContains("No API key configured", "error")},
+		{name: "extract with an unknown provider", method: "POST", path: "/api/extract", body: extract, status: 500,
+			configure: func(c *Config) { c.AIProvider = "nope" },
+			check:     wantContains(`unknown AI provider "nope"`, "error")},
+		{name: "extract when the provider fails", method: "POST", path: "/api/extract", body: extract, status: 500,
+			setup: func(t *testing.T, s *testServer) {
+				s.mock.script(mockRule{Contains: "Sam", Status: 503, Reply: "overloaded"})
+			},
+			check: wantField("Failed to extract from transcript", "error")},
+		{name: "extract over budget", method: "POST", path: "/api/extract", body: extract, status: 429,
+			configure: func(c *Config) { c.MonthlyBudgetUSD = new(float64) },
+			check:     checks(wantContains("monthly AI budget reached", "error"), wantPrompts(0))},
+		{name: "extract with unparseable output", method: "POST", path: "/api/extract", body: extract, status: 500,
+			setup: func(t *testing.T, s *testServer) { s.mock.script(mockRule{Contains: "Sam", Reply: "not json"}) },
+			check: checks(wantField("Failed to extract from transcript", "error"), wantPrompts(2))},
+		{name: "prep missing member_id", method: "POST", path: "/api/prep", body: map[string]string{}, status: 400},
+		{name: "prep unknown member", method: "POST", path: "/api/prep", body: map[string]string{"member_id": "member-missing"}, status: 404},
+		{name: "prep when the provider fails", method: "POST", path: "/api/prep", status: 200,
+			body: map[string]string{"member_id": "member-1"},
+			setup: func(t *testing.T, s *testServer) {
+				seedEntry(t, s)
+				s.mock.script(mockRule{Contains: "1:1", Status: 500, Reply: "boom"})
+			},
+			check: wantContains("Failed to generate AI briefing", "briefing")},
+		{name: "prep without a provider", method: "POST", path: "/api/prep", status: 200,
+			body:      map[string]string{"member_id": "member-1"},
+			configure: noProvider,
+			setup:     func(t *testing.T, s *testServer) { seedEntry(t, s) },
+			check:     checks(wantContains("No API key configured", "briefing"), wantLen(1, "open_items_mine"))},
+		{name: "unknown custom field on entry", method: "POST", path: "/api/entries", status: 400,
+			body: map[string]any{"member_id": "member-1", "custom_fields": map[string]string{"nope": "x"}}},
+		{name: "usage with a bad date", method: "GET", path: "/api/usage?from=yesterday", status: 400},
+		{name: "unknown prompt", method: "GET", path: "/api/prompts/nope", status: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var configure []func(c *Config)
+			if tt.configure != nil {
+				configure = append(configure, tt.configure)
+			}
+			s := newTestServer(t, configure...)
+			if tt.setup != nil {
+				tt.setup(t, s)
+			}
+			s.run(t, []apiStep{{name: tt.name, method: tt.method, path: tt.path, body: tt.body, status: tt.status, check: tt.check}})
+		})
+	}
+}
+
+// seedEntry adds member-1 with one entry that has an open action item.
+func seedEntry(t *testing.T, s *testServer) {
+	t.Helper()
+	if _, err := s.app.db.Exec("INSERT INTO team_members (id, name, role, color) VALUES ('member-1', 'Sam', 'Engineer', '#888888')"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := s.app.db.Exec(`INSERT INTO entries (id, member_id, date, summary, tags, action_items_mine, action_items_theirs, notable_quotes, blockers, wins)
+		VALUES ('entry-1', 'member-1', '2024-05-01T15:00:00Z', 'Talked about the launch.', '[]', '[]', '[]', '[]', '[]', '[]')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.app.db.Exec("INSERT INTO action_items (id, entry_id, member_id, owner, text, status, created_at) VALUES ('action-1', 'entry-1', 'member-1', 'manager', 'Share the promo rubric', 'open', '2024-05-01T15:00:00Z')"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,156 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ─── Outbound HTTP ──────────────────────────────────────
+
+// Every request to an AI provider or JIRA goes through the client for its
+// service. Each attempt gets the service's timeout, covering the response
+// body too. 429 and 5xx responses are retried up to HTTPMaxRetries times
+// with exponential backoff and jitter, waiting at least as long as a
+// Retry-After header asks. The caller's context cancels the request and any
+// wait between attempts, so a client that goes away stops the work.
+
+type httpClient struct {
+	service string
+	timeout time.Duration
+	retries int
+	log     *log.Logger
+}
+
+// newHTTPClients builds the client for each outbound service from the
+// App's config.
+func (a *App) newHTTPClients() map[string]*httpClient {
+	timeouts := map[string]time.Duration{
+		"anthropic": a.cfg.AnthropicTimeout,
+		"openai":    a.cfg.OpenAITimeout,
+		"local":     a.cfg.LocalLLMTimeout,
+		"jira":      a.cfg.JIRATimeout,
+	}
+	clients := make(map[string]*httpClient, len(timeouts))
+	for service, timeout := range timeouts {
+		clients[service] = &httpClient{service: service, timeout: timeout, retries: a.cfg.HTTPMaxRetries, log: a.log}
+	}
+	return clients
+}
+
+// outboundClient is shared so connections are reused across services.
+// Timeouts are set per attempt through the request context instead.
+var outboundClient = &http.Client{}
+
+const (
+	defaultMaxRetries = 3
+	retryBaseDelay    = 500 * time.Millisecond
+	retryMaxDelay     = 10 * time.Second
+	// maxRetryAfter is the longest Retry-After that's waited for. A server
+	// asking for longer gets its error passed on rather than holding up the
+	// request that long.
+	maxRetryAfter = 60 * time.Second
+)
+
+// do sends req, retrying as described above. req must be replayable, which
+// it is when made by http.NewRequest with a bytes.Reader or nil body. The
+// final response is returned whatever its status; only transport failures
+// are errors.
+func (c *httpClient) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	retries, timeout := c.retries, c.timeout
+
+	for attempt := 0; ; attempt++ {
+		var attemptCtx context.Context
+		var cancel context.CancelFunc
+		if timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		} else {
+			attemptCtx, cancel = context.WithCancel(ctx)
+		}
+		r := req.Clone(attemptCtx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			r.Body = body
+		}
+
+		resp, err := outboundClient.Do(r)
+		if err != nil {
+			cancel()
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				c.log.Printf("[HTTP] %s gave no response within %s", c.service, timeout)
+			}
+			return nil, err
+		}
+
+		wait, retry := retryDelay(resp, attempt)
+		if !retry || attempt >= retries {
+			resp.Body = cancelOnClose{resp.Body, cancel}
+			return resp, nil
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		cancel()
+
+		c.log.Printf("[HTTP] %s returned %d, retrying in %s (%d/%d)", c.service, resp.StatusCode, wait.Round(time.Millisecond), attempt+1, retries)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s: gave up waiting to retry: %w", c.service, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// retryDelay says whether resp is worth retrying and how long to wait
+// first: Retry-After when the server sends it, otherwise exponential backoff
+// with jitter.
+func retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return 0, false
+	}
+	backoff := min(retryBaseDelay<<attempt, retryMaxDelay)
+	backoff = backoff/2 + rand.N(backoff/2+1)
+	if after, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		if after > maxRetryAfter {
+			return 0, false
+		}
+		return max(after, backoff), true
+	}
+	return backoff, true
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an
+// HTTP date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// cancelOnClose releases an attempt's context once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,205 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers with the given statuses in turn, repeating the
+// last one, and counts the attempts. Each answer may set Retry-After.
+func scriptedServer(t *testing.T, retryAfter string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(attempts.Add(1))
+		if body, _ := io.ReadAll(r.Body); r.Method == "POST" && string(body) != `{"q":1}` {
+			t.Errorf("attempt %d got body %q", n, body)
+		}
+		status := statuses[min(n, len(statuses))-1]
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &attempts
+}
+
+func testHTTPClient(retries int, timeout time.Duration) *httpClient {
+	return &httpClient{service: "test", timeout: timeout, retries: retries, log: log.New(io.Discard, "", 0)}
+}
+
+func TestHTTPClientRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		retries  int
+		attempts int32
+		status   int
+	}{
+		{"success", []int{200}, 3, 1, 200},
+		{"5xx then success", []int{503, 502, 200}, 3, 3, 200},
+		{"429 then success", []int{429, 200}, 3, 2, 200},
+		{"gives up after the retries", []int{500}, 2, 3, 500},
+		{"no retries configured", []int{503, 200}, 0, 1, 503},
+		{"400 isn't retried", []int{400, 200}, 3, 1, 400},
+		{"401 isn't retried", []int{401, 200}, 3, 1, 401},
+		{"404 isn't retried", []int{404, 200}, 3, 1, 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv, attempts := scriptedServer(t, "", tt.statuses...)
+			req, _ := http.NewRequest("POST", srv.URL, bytes.NewReader([]byte(`{"q":1}`)))
+
+			resp, err := testHTTPClient(tt.retries, 5*time.Second).do(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != tt.status || string(body) != http.StatusText(tt.status) {
+				t.Errorf("got %d %q, want %d", resp.StatusCode, body, tt.status)
+			}
+			if n := attempts.Load(); n != tt.attempts {
+				t.Errorf("%d attempts, want %d", n, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestHTTPClientRetryAfter(t *testing.T) {
+	t.Run("waits as long as asked", func(t *testing.T) {
+		t.Parallel()
+		srv, attempts := scriptedServer(t, "1", 429, 200)
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		start := time.Now()
+		resp, err := testHTTPClient(3, 5*time.Second).do(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("retried after %s, want at least the 1s Retry-After", elapsed)
+		}
+		if resp.StatusCode != 200 || attempts.Load() != 2 {
+			t.Errorf("got %d after %d attempts", resp.StatusCode, attempts.Load())
+		}
+	})
+
+	t.Run("gives up when asked to wait over a minute", func(t *testing.T) {
+		t.Parallel()
+		srv, attempts := scriptedServer(t, "120", 503, 200)
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		start := time.Now()
+		resp, err := testHTTPClient(3, 5*time.Second).do(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 503 || attempts.Load() != 1 {
+			t.Errorf("got %d after %d attempts, want the 503 at once", resp.StatusCode, attempts.Load())
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("took %s", elapsed)
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	future := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	for _, tt := range []struct {
+		header   string
+		min, max time.Duration
+		ok       bool
+	}{
+		{"", 0, 0, false},
+		{"0", 0, 0, true},
+		{"7", 7 * time.Second, 7 * time.Second, true},
+		{"-1", 0, 0, false},
+		{"soon", 0, 0, false},
+		{future, 28 * time.Second, 30 * time.Second, true},
+		{past, 0, 0, true},
+	} {
+		got, ok := parseRetryAfter(tt.header)
+		if ok != tt.ok || got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %s, %v, want %s-%s, %v", tt.header, got, ok, tt.min, tt.max, tt.ok)
+		}
+	}
+}
+
+func TestHTTPClientCancellation(t *testing.T) {
+	t.Run("while waiting to retry", func(t *testing.T) {
+		t.Parallel()
+		srv, attempts := scriptedServer(t, "30", 503)
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := testHTTPClient(3, 5*time.Second).do(ctx, req)
+		if err == nil || !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "gave up waiting to retry") {
+			t.Errorf("err = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("took %s to notice the cancellation", elapsed)
+		}
+		if n := attempts.Load(); n != 1 {
+			t.Errorf("%d attempts, want 1", n)
+		}
+	})
+
+	t.Run("during a request", func(t *testing.T) {
+		t.Parallel()
+		gone := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+				close(gone)
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer srv.Close()
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		_, err := testHTTPClient(3, 5*time.Second).do(ctx, req)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+		select {
+		case <-gone:
+		case <-time.After(2 * time.Second):
+			t.Error("the server never saw the request cancelled")
+		}
+	})
+
+	t.Run("attempt timeout", func(t *testing.T) {
+		t.Parallel()
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer srv.Close()
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+
+		_, err := testHTTPClient(3, 100*time.Millisecond).do(context.Background(), req)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("err = %v, want a deadline error", err)
+		}
+	})
+}
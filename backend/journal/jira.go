@@ -1,7 +1,8 @@
-package main
+package journal
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -34,36 +35,46 @@ type JIRAContext struct {
 	JIRABaseURL string           `json:"-"`
 }
 
-// ─── Configuration ──────────────────────────────────────
+// ─── HTTP Client ────────────────────────────────────────
 
-func jiraConfigured() bool {
-	return getEnvNonEmpty("JIRA_BASE_URL") != "" &&
-		getEnvNonEmpty("JIRA_EMAIL") != "" &&
-		getEnvNonEmpty("JIRA_API_TOKEN") != ""
+// jiraClient talks to the JIRA REST API of one site as one user.
+type jiraClient struct {
+	baseURL string
+	email   string
+	token   string
+	http    *httpClient
+	log     *log.Logger
 }
 
-// ─── HTTP Client ────────────────────────────────────────
-
-func jiraRequest(method, path string, body io.Reader) ([]byte, error) {
-	baseURL := strings.TrimRight(getEnvNonEmpty("JIRA_BASE_URL"), "/")
-	email := getEnvNonEmpty("JIRA_EMAIL")
-	token := getEnvNonEmpty("JIRA_API_TOKEN")
+func (a *App) newJIRAClient() *jiraClient {
+	return &jiraClient{
+		baseURL: strings.TrimRight(a.cfg.JIRABaseURL, "/"),
+		email:   a.cfg.JIRAEmail,
+		token:   a.cfg.JIRAAPIToken,
+		http:    a.http["jira"],
+		log:     a.log,
+	}
+}
 
-	fullURL := baseURL + path
-	log.Printf("[JIRA] %s %s", method, fullURL)
+// request sends an authenticated request to the JIRA REST API and returns
+// the body of a 2xx response. body must be nil or a *bytes.Reader so the
+// request can be retried.
+func (c *jiraClient) request(ctx context.Context, method, path string, body io.Reader) ([]byte, error) {
+	fullURL := c.baseURL + path
+	c.log.Printf("[JIRA] %s %s", method, fullURL)
 
-	req, err := http.NewRequest(method, fullURL, body)
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("jira: failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(email, token)
+	req.SetBasicAuth(c.email, c.token)
 	req.Header.Set("Accept", "application/json")
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.http.do(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("jira: request failed: %w", err)
 	}
@@ -74,7 +85,7 @@ func jiraRequest(method, path string, body io.Reader) ([]byte, error) {
 		return nil, fmt.Errorf("jira: failed to read response: %w", err)
 	}
 
-	log.Printf("[JIRA] %s %s → %d (%d bytes)", method, path, resp.StatusCode, len(data))
+	c.log.Printf("[JIRA] %s %s → %d (%d bytes)", method, path, resp.StatusCode, len(data))
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, fmt.Errorf("jira: %s %s returned %d: %s", method, path, resp.StatusCode, string(data))
@@ -85,11 +96,11 @@ func jiraRequest(method, path string, body io.Reader) ([]byte, error) {
 
 // ─── User Search ────────────────────────────────────────
 
-func resolveJIRAUser(displayName string) (string, error) {
-	log.Printf("[JIRA] Searching for user: %q", displayName)
+func (c *jiraClient) resolveUser(ctx context.Context, displayName string) (string, error) {
+	c.log.Printf("[JIRA] Searching for user: %q", displayName)
 	path := "/rest/api/3/user/search?query=" + url.QueryEscape(displayName)
 
-	data, err := jiraRequest("GET", path, nil)
+	data, err := c.request(ctx, "GET", path, nil)
 	if err != nil {
 		return "", fmt.Errorf("jira user search failed: %w", err)
 	}
@@ -99,7 +110,7 @@ func resolveJIRAUser(displayName string) (string, error) {
 		return "", fmt.Errorf("jira: failed to parse user search response: %w", err)
 	}
 
-	log.Printf("[JIRA] User search returned %d results", len(users))
+	c.log.Printf("[JIRA] User search returned %d results", len(users))
 
 	// Filter to active users only
 	var activeUsers []map[string]any
@@ -119,7 +130,7 @@ func resolveJIRAUser(displayName string) (string, error) {
 		name := stringField(u, "displayName")
 		if strings.EqualFold(name, displayName) {
 			accountID := stringField(u, "accountId")
-			log.Printf("[JIRA] Resolved %q → %s (exact match)", displayName, accountID)
+			c.log.Printf("[JIRA] Resolved %q → %s (exact match)", displayName, accountID)
 			return accountID, nil
 		}
 	}
@@ -127,13 +138,13 @@ func resolveJIRAUser(displayName string) (string, error) {
 	// Fall back to first active user
 	accountID := stringField(activeUsers[0], "accountId")
 	fallbackName := stringField(activeUsers[0], "displayName")
-	log.Printf("[JIRA] No exact match for %q, using first active user: %q (%s)", displayName, fallbackName, accountID)
+	c.log.Printf("[JIRA] No exact match for %q, using first active user: %q (%s)", displayName, fallbackName, accountID)
 	return accountID, nil
 }
 
 // ─── JQL Search ─────────────────────────────────────────
 
-func searchJIRA(jql string, fields []string) ([]map[string]any, error) {
+func (c *jiraClient) search(ctx context.Context, jql string, fields []string) ([]map[string]any, error) {
 	reqBody := map[string]any{
 		"jql":        jql,
 		"fields":     fields,
@@ -141,7 +152,7 @@ func searchJIRA(jql string, fields []string) ([]map[string]any, error) {
 	}
 	b, _ := json.Marshal(reqBody)
 
-	data, err := jiraRequest("POST", "/rest/api/3/search/jql", bytes.NewReader(b))
+	data, err := c.request(ctx, "POST", "/rest/api/3/search/jql", bytes.NewReader(b))
 	if err != nil {
 		return nil, err
 	}
@@ -227,15 +238,15 @@ func stringField(m map[string]any, key string) string {
 
 // ─── Field Discovery ────────────────────────────────────
 
-// discoverJIRAFields queries the JIRA field definitions to find the actual
+// discoverFields queries the JIRA field definitions to find the actual
 // custom field IDs for story points and epic name, which vary per instance.
 // Returns all candidate story points fields (there can be multiple) and one epic name field.
-func discoverJIRAFields() (storyPointsFields []string, epicNameField string) {
+func (c *jiraClient) discoverFields(ctx context.Context) (storyPointsFields []string, epicNameField string) {
 	epicNameField = "customfield_10014" // fallback
 
-	data, err := jiraRequest("GET", "/rest/api/3/field", nil)
+	data, err := c.request(ctx, "GET", "/rest/api/3/field", nil)
 	if err != nil {
-		log.Printf("[JIRA] Failed to fetch field definitions: %v", err)
+		c.log.Printf("[JIRA] Failed to fetch field definitions: %v", err)
 		storyPointsFields = []string{"story_points"}
 		return
 	}
@@ -245,7 +256,7 @@ func discoverJIRAFields() (storyPointsFields []string, epicNameField string) {
 		Name string `json:"name"`
 	}
 	if err := json.Unmarshal(data, &fields); err != nil {
-		log.Printf("[JIRA] Failed to parse field definitions: %v", err)
+		c.log.Printf("[JIRA] Failed to parse field definitions: %v", err)
 		storyPointsFields = []string{"story_points"}
 		return
 	}
@@ -254,11 +265,11 @@ func discoverJIRAFields() (storyPointsFields []string, epicNameField string) {
 		nameLower := strings.ToLower(f.Name)
 		if nameLower == "story points" || nameLower == "story point estimate" {
 			storyPointsFields = append(storyPointsFields, f.ID)
-			log.Printf("[JIRA] Discovered story points field: %s (%s)", f.ID, f.Name)
+			c.log.Printf("[JIRA] Discovered story points field: %s (%s)", f.ID, f.Name)
 		}
 		if nameLower == "epic name" {
 			epicNameField = f.ID
-			log.Printf("[JIRA] Discovered epic name field: %s (%s)", f.ID, f.Name)
+			c.log.Printf("[JIRA] Discovered epic name field: %s (%s)", f.ID, f.Name)
 		}
 	}
 
@@ -271,25 +282,25 @@ func discoverJIRAFields() (storyPointsFields []string, epicNameField string) {
 
 // ─── Activity Fetch ─────────────────────────────────────
 
-func fetchJIRAActivity(accountID, sinceDate string) (JIRAContext, error) {
-	ctx := JIRAContext{
+func (c *jiraClient) fetchActivity(ctx context.Context, accountID, sinceDate string) (JIRAContext, error) {
+	activity := JIRAContext{
 		Assigned:    []JIRATicket{},
 		Completed:   []JIRATicket{},
 		Blocked:     []JIRATicket{},
-		JIRABaseURL: strings.TrimRight(getEnvNonEmpty("JIRA_BASE_URL"), "/"),
+		JIRABaseURL: c.baseURL,
 	}
 
 	// Discover the right field IDs for this JIRA instance
-	spFields, epicField := discoverJIRAFields()
+	spFields, epicField := c.discoverFields(ctx)
 
 	fields := []string{"summary", "status", "priority", "flagged", epicField}
 	fields = append(fields, spFields...)
 
 	// Query 1: Assigned in open sprints
 	assignedJQL := fmt.Sprintf(`assignee = "%s" AND sprint in openSprints() ORDER BY status ASC, rank ASC`, accountID)
-	assignedIssues, err := searchJIRA(assignedJQL, fields)
+	assignedIssues, err := c.search(ctx, assignedJQL, fields)
 	if err != nil {
-		log.Printf("JIRA: failed to fetch assigned issues: %v", err)
+		c.log.Printf("JIRA: failed to fetch assigned issues: %v", err)
 	}
 
 	var totalCommitted, totalCompleted int
@@ -311,7 +322,7 @@ func fetchJIRAActivity(accountID, sinceDate string) (JIRAContext, error) {
 						pointFields = append(pointFields, fmt.Sprintf("%s=%.0f", k, f))
 					}
 				}
-				log.Printf("[JIRA] First issue %s fields with numeric values: %v", ticket.Key, pointFields)
+				c.log.Printf("[JIRA] First issue %s fields with numeric values: %v", ticket.Key, pointFields)
 			}
 		}
 
@@ -325,30 +336,30 @@ func fetchJIRAActivity(accountID, sinceDate string) (JIRAContext, error) {
 		totalCommitted += points
 
 		if ticket.Flagged {
-			ctx.Blocked = append(ctx.Blocked, ticket)
+			activity.Blocked = append(activity.Blocked, ticket)
 		}
-		ctx.Assigned = append(ctx.Assigned, ticket)
+		activity.Assigned = append(activity.Assigned, ticket)
 	}
 
 	// Query 2: Completed since date
 	completedJQL := fmt.Sprintf(`assignee = "%s" AND status = Done AND resolved >= "%s" ORDER BY resolved DESC`, accountID, sinceDate)
-	completedIssues, err := searchJIRA(completedJQL, fields)
+	completedIssues, err := c.search(ctx, completedJQL, fields)
 	if err != nil {
-		log.Printf("JIRA: failed to fetch completed issues: %v", err)
+		c.log.Printf("JIRA: failed to fetch completed issues: %v", err)
 	}
 
 	for _, issue := range completedIssues {
 		ticket := parseJIRAIssue(issue, epicField)
-		ctx.Completed = append(ctx.Completed, ticket)
+		activity.Completed = append(activity.Completed, ticket)
 	}
 
 	// Calculate sprint stats if we have any data
 	if len(assignedIssues) > 0 || len(completedIssues) > 0 {
-		ctx.SprintStats = &JIRASprintStats{
+		activity.SprintStats = &JIRASprintStats{
 			PointsCommitted: totalCommitted,
 			PointsCompleted: totalCompleted,
 		}
 	}
 
-	return ctx, nil
+	return activity, nil
 }
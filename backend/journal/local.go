@@ -0,0 +1,172 @@
+package journal
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultLocalModel = "llama3.1"
+
+// localProvider talks to a model server on this machine so transcripts never
+// leave it. LocalLLMAPI picks the wire format: "openai" (default) for any
+// OpenAI-compatible server such as llama.cpp, LM Studio or Ollama's /v1, or
+// "ollama" for Ollama's native /api/chat.
+type localProvider struct {
+	baseURL string
+	api     string
+	model   string
+	apiKey  string
+	http    *httpClient
+}
+
+func newLocalProvider(a *App) Provider {
+	baseURL := strings.TrimRight(a.cfg.LocalLLMBaseURL, "/")
+	if baseURL == "" {
+		return nil
+	}
+	return &localProvider{
+		baseURL: baseURL,
+		api:     cmp.Or(strings.ToLower(a.cfg.LocalLLMAPI), "openai"),
+		model:   cmp.Or(a.cfg.LocalLLMModel, defaultLocalModel),
+		apiKey:  a.cfg.LocalLLMAPIKey,
+		http:    a.http["local"],
+	}
+}
+
+func (p *localProvider) Name() string { return "local" }
+
+func (p *localProvider) Complete(req CompletionRequest) (string, error) {
+	switch p.api {
+	case "ollama":
+		return p.completeOllama(req)
+	case "openai":
+		return openAIChatCompletion(p.http, p.baseURL+"/v1/chat/completions", p.apiKey, req.model(p.model), req)
+	default:
+		return "", fmt.Errorf("local: unsupported LOCAL_LLM_API %q (want \"openai\" or \"ollama\")", p.api)
+	}
+}
+
+func (p *localProvider) Stream(req CompletionRequest, onDelta func(string) error) (string, error) {
+	switch p.api {
+	case "ollama":
+		return p.streamOllama(req, onDelta)
+	case "openai":
+		return openAIChatStream(p.http, p.baseURL+"/v1/chat/completions", p.apiKey, req.model(p.model), req, onDelta)
+	default:
+		return "", fmt.Errorf("local: unsupported LOCAL_LLM_API %q (want \"openai\" or \"ollama\")", p.api)
+	}
+}
+
+// ollamaChat posts to Ollama's native chat endpoint. With stream set, the
+// response body is newline-delimited JSON rather than a single object.
+func (p *localProvider) ollamaChat(req CompletionRequest, stream bool) (*http.Response, error) {
+	body := map[string]any{
+		"model":    req.model(p.model),
+		"stream":   stream,
+		"messages": []map[string]string{{"role": "user", "content": req.Prompt}},
+		"options":  map[string]any{"num_predict": req.maxTokens()},
+	}
+	b, _ := json.Marshal(body)
+
+	httpReq, _ := http.NewRequestWithContext(req.context(), "POST", p.baseURL+"/api/chat", bytes.NewReader(b))
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.http.do(req.context(), httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("local request failed: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, &ProviderError{Provider: "local", StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	return resp, nil
+}
+
+// ollamaChatResponse is a response, or one line of a stream. Token counts
+// come on the final line, where done is true.
+type ollamaChatResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+func (p *localProvider) completeOllama(req CompletionRequest) (string, error) {
+	resp, err := p.ollamaChat(req, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+
+	var result ollamaChatResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to parse local response: %w", err)
+	}
+	req.report("local", req.model(p.model), result.PromptEvalCount, result.EvalCount)
+	return result.Message.Content, nil
+}
+
+func (p *localProvider) streamOllama(req CompletionRequest, onDelta func(string) error) (string, error) {
+	resp, err := p.ollamaChat(req, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	var input, output int
+	err = readLines(resp.Body, func(line string) error {
+		if strings.TrimSpace(line) == "" {
+			return nil
+		}
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("failed to parse local stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("local stream error: %s", chunk.Error)
+		}
+		if chunk.EvalCount > 0 {
+			input, output = chunk.PromptEvalCount, chunk.EvalCount
+		}
+		if chunk.Message.Content == "" {
+			return nil
+		}
+		sb.WriteString(chunk.Message.Content)
+		return onDelta(chunk.Message.Content)
+	})
+	req.report("local", req.model(p.model), input, output)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// providerIsOffline reports whether every provider p may call runs locally.
+func providerIsOffline(p Provider) bool {
+	switch v := p.(type) {
+	case *localProvider:
+		return true
+	case *meteredProvider:
+		return providerIsOffline(v.Provider)
+	case *fallbackProvider:
+		for _, c := range v.chain {
+			if !providerIsOffline(c) {
+				return false
+			}
+		}
+		return len(v.chain) > 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,140 @@
+package journal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testApp is an App without a database, built from DefaultConfig so tests
+// don't pick up keys from the developer's environment.
+func testApp(t *testing.T, configure func(c *Config)) *App {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	configure(&cfg)
+	return newApp(cfg)
+}
+
+func TestLocalProviderOpenAICompatible(t *testing.T) {
+	var got struct {
+		Model     string `json:"model"`
+		MaxTokens int    `json:"max_tokens"`
+		Messages  []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"choices":[{"message":{"content":"{\"summary\":\"ok\"}"}}]}`))
+	}))
+	defer srv.Close()
+
+	a := testApp(t, func(c *Config) {
+		c.LocalLLMBaseURL = srv.URL + "/"
+		c.LocalLLMModel = "qwen2.5"
+	})
+	p, err := a.provider, a.providerErr
+	if err != nil {
+		t.Fatalf("newProvider: %v", err)
+	}
+	req := a.extractionCompletion(extractRequest{MemberName: "Sam", Transcript: "hello"})
+	req.MaxTokens = 2000
+	text, err := p.Complete(req)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if text != `{"summary":"ok"}` {
+		t.Errorf("text = %q", text)
+	}
+	if got.Model != "qwen2.5" || got.MaxTokens != 2000 {
+		t.Errorf("request model=%q max_tokens=%d", got.Model, got.MaxTokens)
+	}
+	if len(got.Messages) != 1 || !strings.Contains(got.Messages[0].Content, "report named Sam") {
+		t.Errorf("prompt not forwarded: %+v", got.Messages)
+	}
+}
+
+func TestLocalProviderOllama(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"**Follow up on**\n- thing"}}`))
+	}))
+	defer srv.Close()
+
+	a := testApp(t, func(c *Config) {
+		c.LocalLLMBaseURL = srv.URL
+		c.LocalLLMAPI = "ollama"
+	})
+	p, err := a.provider, a.providerErr
+	if err != nil {
+		t.Fatalf("newProvider: %v", err)
+	}
+	text, err := p.Complete(CompletionRequest{Prompt: a.buildPrepPrompt(PromptMember{Name: "Sam"}, nil, nil)})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if !strings.HasPrefix(text, "**Follow up on**") {
+		t.Errorf("text = %q", text)
+	}
+	if got["stream"] != false || got["model"] != defaultLocalModel {
+		t.Errorf("request = %v", got)
+	}
+}
+
+func TestLocalProviderErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", 503)
+	}))
+	defer srv.Close()
+	a := testApp(t, func(c *Config) { c.LocalLLMBaseURL = srv.URL })
+	_, err := a.provider.Complete(CompletionRequest{Prompt: "hi"})
+	if !shouldFallback(err) {
+		t.Errorf("err = %v, want a 5xx ProviderError", err)
+	}
+}
+
+func TestLocalProviderTakesPrecedence(t *testing.T) {
+	configure := func(c *Config) {
+		c.AnthropicAPIKey = "sk-ant-test"
+		c.LocalLLMBaseURL = "http://127.0.0.1:11434"
+	}
+	a := testApp(t, configure)
+	p, err := a.provider, a.providerErr
+	if err != nil {
+		t.Fatalf("newProvider: %v", err)
+	}
+	if p.Name() != "local" || !providerIsOffline(p) {
+		t.Errorf("provider = %s offline=%v, want local only", p.Name(), providerIsOffline(p))
+	}
+
+	p = testApp(t, func(c *Config) {
+		configure(c)
+		c.AIProvider = "local,anthropic"
+	}).provider
+	if p.Name() != "local,anthropic" || providerIsOffline(p) {
+		t.Errorf("provider = %s offline=%v, want mixed chain", p.Name(), providerIsOffline(p))
+	}
+}
+
+func TestConfigReportsOfflineMode(t *testing.T) {
+	a := testApp(t, func(c *Config) { c.LocalLLMBaseURL = "http://127.0.0.1:11434" })
+
+	rec := httptest.NewRecorder()
+	a.handleGetConfig(rec, httptest.NewRequest("GET", "/api/config", nil))
+
+	var config map[string]any
+	json.NewDecoder(rec.Body).Decode(&config)
+	if config["ai_provider"] != "local" || config["ai_offline"] != true {
+		t.Errorf("config = %v", config)
+	}
+}
@@ -0,0 +1,512 @@
+package journal
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// migration is one numbered schema change. Versions start at 1 and must be
+// contiguous; each one runs in its own transaction and is recorded in
+// schema_migrations. Never edit a migration that has shipped — add a new one.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{1, "baseline schema", migrateBaseline},
+	{2, "team_members.jira_account_id", func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, "team_members", "jira_account_id", "TEXT")
+	}},
+	{3, "team_members.prep_notes", func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, "team_members", "prep_notes", "TEXT")
+	}},
+	{4, "entries_fts full-text index", migrateEntriesFTS},
+	{5, "action_items table", migrateActionItems},
+	{6, "entry_revisions table", migrateEntryRevisions},
+	{7, "soft delete columns", migrateSoftDelete},
+	{8, "encryption key table", migrateEncryption},
+	{9, "speaker_labels table", migrateSpeakerLabels},
+	{10, "tags table", migrateTags},
+	{11, "prompt templates", migratePromptTemplates},
+	{12, "custom fields", migrateCustomFields},
+	{13, "ai_calls table", migrateAICalls},
+	{14, "redaction_terms table", migrateRedactionTerms},
+}
+
+// schemaVersion is the version this binary migrates databases to.
+func schemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate brings db up to schemaVersion. It refuses to touch a database that
+// was migrated by a newer binary, since this one can't know what changed.
+func migrate(db *sql.DB, logger *log.Logger) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if current > schemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d); upgrade People Journal", current, schemaVersion())
+	}
+
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return fmt.Errorf("migration %q has version %d, want %d", m.name, m.version, i+1)
+		}
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		logger.Printf("Applied migration %d: %s", m.version, m.name)
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		m.version, m.name, time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addColumnIfMissing lets early migrations run against databases that got
+// the column from the ad-hoc ALTER TABLE calls that predate this system.
+func addColumnIfMissing(tx *sql.Tx, table, column, decl string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl))
+	return err
+}
+
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// ─── Migrations ─────────────────────────────────────────
+
+// migrateBaseline creates the original schema. It uses IF NOT EXISTS because
+// databases created before versioning already have these tables.
+func migrateBaseline(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS team_members (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			role TEXT NOT NULL,
+			color TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS entries (
+			id TEXT PRIMARY KEY,
+			member_id TEXT NOT NULL REFERENCES team_members(id),
+			date TEXT NOT NULL,
+			summary TEXT,
+			morale_score INTEGER,
+			growth_score INTEGER,
+			morale_rationale TEXT,
+			growth_rationale TEXT,
+			tags TEXT,
+			action_items_mine TEXT,
+			action_items_theirs TEXT,
+			notable_quotes TEXT,
+			blockers TEXT,
+			wins TEXT,
+			private_note TEXT,
+			transcript TEXT,
+			created_at TEXT,
+			updated_at TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS cache (
+			key TEXT NOT NULL,
+			category TEXT NOT NULL,
+			value TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (key, category)
+		)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ftsFlatten turns a JSON array column into plain delimited text so snippets
+// don't show brackets and quotes. Anything that isn't valid JSON is indexed
+// as-is rather than failing the write.
+func ftsFlatten(col string) string {
+	return fmt.Sprintf("CASE WHEN json_valid(%[1]s) THEN (SELECT group_concat(value, ' · ') FROM json_each(%[1]s)) ELSE %[1]s END", col)
+}
+
+// ftsValues is the column list written into entries_fts for row alias r.
+func ftsValues(r string) string {
+	return strings.Join([]string{
+		r + ".rowid",
+		r + ".summary",
+		r + ".transcript",
+		ftsFlatten(r + ".notable_quotes"),
+		ftsFlatten(r + ".blockers"),
+		ftsFlatten(r + ".wins"),
+		"trim(coalesce(" + r + ".morale_rationale, '') || ' ' || coalesce(" + r + ".growth_rationale, ''))",
+		r + ".private_note",
+	}, ", ")
+}
+
+const ftsColumns = "rowid, summary, transcript, notable_quotes, blockers, wins, rationales, private_note"
+
+// migrateEntriesFTS adds a standalone FTS5 index over the searchable entry
+// text, kept in sync by triggers, and backfills it from existing entries.
+func migrateEntriesFTS(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE VIRTUAL TABLE entries_fts USING fts5(
+			summary, transcript, notable_quotes, blockers, wins, rationales, private_note,
+			tokenize = 'porter unicode61'
+		)`,
+		fmt.Sprintf(`CREATE TRIGGER entries_fts_insert AFTER INSERT ON entries BEGIN
+			INSERT INTO entries_fts (%s) SELECT %s FROM entries AS n WHERE n.rowid = new.rowid;
+		END`, ftsColumns, ftsValues("n")),
+		`CREATE TRIGGER entries_fts_delete AFTER DELETE ON entries BEGIN
+			DELETE FROM entries_fts WHERE rowid = old.rowid;
+		END`,
+		fmt.Sprintf(`CREATE TRIGGER entries_fts_update AFTER UPDATE ON entries BEGIN
+			DELETE FROM entries_fts WHERE rowid = old.rowid;
+			INSERT INTO entries_fts (%s) SELECT %s FROM entries AS n WHERE n.rowid = new.rowid;
+		END`, ftsColumns, ftsValues("n")),
+		fmt.Sprintf(`INSERT INTO entries_fts (%s) SELECT %s FROM entries AS e`, ftsColumns, ftsValues("e")),
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateActionItems moves action items out of the entries JSON columns into
+// their own table. The JSON columns stay as a denormalized copy and are
+// rewritten with the new item IDs.
+func migrateActionItems(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE action_items (
+			id TEXT PRIMARY KEY,
+			entry_id TEXT NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+			member_id TEXT NOT NULL,
+			owner TEXT NOT NULL CHECK (owner IN ('manager', 'member')),
+			text TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'done')),
+			position INTEGER NOT NULL DEFAULT 0,
+			due_date TEXT,
+			created_at TEXT NOT NULL,
+			completed_at TEXT
+		)`,
+		`CREATE INDEX action_items_entry ON action_items (entry_id, owner, position)`,
+		`CREATE INDEX action_items_open ON action_items (status, member_id)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	for owner, col := range map[string]string{"manager": "action_items_mine", "member": "action_items_theirs"} {
+		// Items are {"text", "completed"} objects; plain strings are accepted too.
+		if _, err := tx.Exec(fmt.Sprintf(`
+			INSERT INTO action_items (id, entry_id, member_id, owner, text, status, position, created_at, completed_at)
+			SELECT
+				'action-' || e.id || '-' || ? || '-' || j.key,
+				e.id, e.member_id, ?,
+				CASE j.type WHEN 'object' THEN json_extract(j.value, '$.text') ELSE j.value END,
+				CASE WHEN j.type = 'object' AND json_extract(j.value, '$.completed') THEN 'done' ELSE 'open' END,
+				j.key,
+				COALESCE(e.created_at, e.date),
+				CASE WHEN j.type = 'object' AND json_extract(j.value, '$.completed')
+					THEN COALESCE(e.updated_at, e.created_at, e.date) END
+			FROM entries e, json_each(e.%[1]s) j
+			WHERE json_valid(e.%[1]s)
+				AND j.type IN ('object', 'text')
+				AND trim(COALESCE(CASE j.type WHEN 'object' THEN json_extract(j.value, '$.text') ELSE j.value END, '')) != ''`,
+			col), owner, owner); err != nil {
+			return fmt.Errorf("backfill %s: %w", col, err)
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf(`
+			UPDATE entries SET %s = (
+				SELECT json_group_array(json_object(
+					'id', a.id,
+					'text', a.text,
+					'completed', json(CASE a.status WHEN 'done' THEN 'true' ELSE 'false' END)
+				))
+				FROM (SELECT * FROM action_items WHERE entry_id = entries.id AND owner = ? ORDER BY position) a
+			)
+			WHERE id IN (SELECT entry_id FROM action_items WHERE owner = ?)`,
+			col), owner, owner); err != nil {
+			return fmt.Errorf("rewrite %s: %w", col, err)
+		}
+	}
+	return nil
+}
+
+// migrateEntryRevisions adds the per-entry edit history. Entries that already
+// exist start with no revisions.
+func migrateEntryRevisions(tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE TABLE entry_revisions (
+		entry_id TEXT NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+		revision INTEGER NOT NULL,
+		reason TEXT NOT NULL,
+		changed_fields TEXT NOT NULL,
+		snapshot TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (entry_id, revision)
+	)`)
+	return err
+}
+
+// migrateSoftDelete adds deleted_at to entries and team_members. Rows with a
+// deleted_at are in the trash and hidden from everything but /api/trash.
+func migrateSoftDelete(tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE team_members ADD COLUMN deleted_at TEXT`,
+		`ALTER TABLE entries ADD COLUMN deleted_at TEXT`,
+		`CREATE INDEX entries_deleted ON entries (deleted_at)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateEncryption adds the table recording the encryption key setup, and
+// recreates the full-text triggers so encrypted transcripts and private notes
+// are left out of the index rather than indexed as ciphertext.
+func migrateEncryption(tx *sql.Tx) error {
+	plain := func(col string) string {
+		return fmt.Sprintf("CASE WHEN substr(%[1]s, 1, 4) = 'enc:' THEN NULL ELSE %[1]s END", col)
+	}
+	values := strings.NewReplacer(
+		"n.transcript", plain("n.transcript"),
+		"n.private_note", plain("n.private_note"),
+	).Replace(ftsValues("n"))
+
+	stmts := []string{
+		`CREATE TABLE encryption (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			kdf TEXT NOT NULL,
+			salt TEXT,
+			iterations INTEGER,
+			key_check TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		)`,
+		`DROP TRIGGER entries_fts_insert`,
+		`DROP TRIGGER entries_fts_update`,
+		fmt.Sprintf(`CREATE TRIGGER entries_fts_insert AFTER INSERT ON entries BEGIN
+			INSERT INTO entries_fts (%s) SELECT %s FROM entries AS n WHERE n.rowid = new.rowid;
+		END`, ftsColumns, values),
+		fmt.Sprintf(`CREATE TRIGGER entries_fts_update AFTER UPDATE ON entries BEGIN
+			DELETE FROM entries_fts WHERE rowid = old.rowid;
+			INSERT INTO entries_fts (%s) SELECT %s FROM entries AS n WHERE n.rowid = new.rowid;
+		END`, ftsColumns, values),
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateSpeakerLabels adds the per-member mapping from transcript speaker
+// labels to manager, member or other.
+func migrateSpeakerLabels(tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE TABLE speaker_labels (
+		member_id TEXT NOT NULL REFERENCES team_members(id),
+		label TEXT NOT NULL COLLATE NOCASE,
+		speaker TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (member_id, label)
+	)`)
+	return err
+}
+
+// migrateTags moves the tag taxonomy into the database, seeded with the list
+// that used to be built in.
+func migrateTags(tx *sql.Tx) error {
+	if _, err := tx.Exec(`CREATE TABLE tags (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		description TEXT NOT NULL DEFAULT '',
+		color TEXT NOT NULL DEFAULT '',
+		archived INTEGER NOT NULL DEFAULT 0,
+		position INTEGER NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	)`); err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for i, name := range defaultTags {
+		if _, err := tx.Exec(
+			"INSERT INTO tags (id, name, position, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+			fmt.Sprintf("tag-%d", i+1), name, i+1, now, now,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migratePromptTemplates adds saved versions of the extraction and prep
+// prompts. Nothing is seeded; without a saved version the built-in default is
+// used.
+func migratePromptTemplates(tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE TABLE prompt_templates (
+		name TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		body TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (name, version)
+	)`)
+	return err
+}
+
+// migrateCustomFields adds custom field definitions and the entries column
+// holding their values, and rebuilds the full-text index with a column for
+// the values' text. FTS5 tables can't gain columns, so the index is dropped
+// and backfilled.
+func migrateCustomFields(tx *sql.Tx) error {
+	plain := func(col string) string {
+		return fmt.Sprintf("CASE WHEN substr(%[1]s, 1, 4) = 'enc:' THEN NULL ELSE %[1]s END", col)
+	}
+	columns := ftsColumns + ", custom_fields"
+	values := strings.NewReplacer(
+		"n.transcript", plain("n.transcript"),
+		"n.private_note", plain("n.private_note"),
+	).Replace(ftsValues("n")) + ", " +
+		"CASE WHEN json_valid(n.custom_fields) THEN (SELECT group_concat(value, ' · ') FROM json_tree(n.custom_fields) WHERE type = 'text') END"
+
+	stmts := []string{
+		`ALTER TABLE entries ADD COLUMN custom_fields TEXT NOT NULL DEFAULT '{}'`,
+		`CREATE TABLE custom_fields (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			type TEXT NOT NULL,
+			instructions TEXT NOT NULL,
+			archived INTEGER NOT NULL DEFAULT 0,
+			position INTEGER NOT NULL,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		)`,
+		`DROP TRIGGER entries_fts_insert`,
+		`DROP TRIGGER entries_fts_update`,
+		`DROP TRIGGER entries_fts_delete`,
+		`DROP TABLE entries_fts`,
+		`CREATE VIRTUAL TABLE entries_fts USING fts5(
+			summary, transcript, notable_quotes, blockers, wins, rationales, private_note, custom_fields,
+			tokenize = 'porter unicode61'
+		)`,
+		fmt.Sprintf(`CREATE TRIGGER entries_fts_insert AFTER INSERT ON entries BEGIN
+			INSERT INTO entries_fts (%s) SELECT %s FROM entries AS n WHERE n.rowid = new.rowid;
+		END`, columns, values),
+		`CREATE TRIGGER entries_fts_delete AFTER DELETE ON entries BEGIN
+			DELETE FROM entries_fts WHERE rowid = old.rowid;
+		END`,
+		fmt.Sprintf(`CREATE TRIGGER entries_fts_update AFTER UPDATE ON entries BEGIN
+			DELETE FROM entries_fts WHERE rowid = old.rowid;
+			INSERT INTO entries_fts (%s) SELECT %s FROM entries AS n WHERE n.rowid = new.rowid;
+		END`, columns, values),
+		fmt.Sprintf(`INSERT INTO entries_fts (%s) SELECT %s FROM entries AS n`, columns, values),
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateAICalls adds the log of model calls and cache hits behind
+// /api/usage and the monthly budget.
+func migrateAICalls(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE ai_calls (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at TEXT NOT NULL,
+			provider TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL DEFAULT '',
+			purpose TEXT NOT NULL,
+			input_tokens INTEGER NOT NULL DEFAULT 0,
+			output_tokens INTEGER NOT NULL DEFAULT 0,
+			latency_ms INTEGER NOT NULL DEFAULT 0,
+			cache_hit INTEGER NOT NULL DEFAULT 0,
+			cost_usd REAL NOT NULL DEFAULT 0,
+			error TEXT
+		)`,
+		`CREATE INDEX idx_ai_calls_created_at ON ai_calls (created_at)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateRedactionTerms adds the user's deny-list of names and terms to
+// redact from transcripts.
+func migrateRedactionTerms(tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE TABLE redaction_terms (
+		id TEXT PRIMARY KEY,
+		term TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		created_at TEXT NOT NULL
+	)`)
+	return err
+}
@@ -0,0 +1,215 @@
+package journal
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// loadFixture creates a database file from a SQL script in testdata.
+func loadFixture(t *testing.T, name string) string {
+	t.Helper()
+	script, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "people-journal.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(script)); err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return path
+}
+
+func appliedVersions(t *testing.T, db *sql.DB) []int {
+	t.Helper()
+	rows, err := db.Query("SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var v int
+		rows.Scan(&v)
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), "fresh.db"), log.Default())
+	if err != nil {
+		t.Fatalf("openDB: %v", err)
+	}
+	defer db.Close()
+
+	if got := appliedVersions(t, db); len(got) != schemaVersion() {
+		t.Errorf("applied %v, want %d migrations", got, schemaVersion())
+	}
+	if _, err := db.Exec("INSERT INTO team_members (id, name, role, color, jira_account_id, prep_notes) VALUES ('m', 'n', 'r', 'c', 'j', 'p')"); err != nil {
+		t.Errorf("insert with migrated columns: %v", err)
+	}
+}
+
+func TestMigrateBaselineFixture(t *testing.T) {
+	path := loadFixture(t, "baseline.sql")
+
+	db, err := openDB(path, log.Default())
+	if err != nil {
+		t.Fatalf("openDB: %v", err)
+	}
+	defer db.Close()
+
+	if got := appliedVersions(t, db); len(got) != schemaVersion() {
+		t.Errorf("applied %v, want %d migrations", got, schemaVersion())
+	}
+
+	m, err := scanTeamMember(db.QueryRow("SELECT id, name, role, color, jira_account_id, prep_notes FROM team_members WHERE id = 'member-1'"))
+	if err != nil {
+		t.Fatalf("read member after upgrade: %v", err)
+	}
+	if m.Name != "Sam Rivera" || m.JiraAccountID != nil || m.PrepNotes != nil {
+		t.Errorf("member = %+v", m)
+	}
+
+	e, err := (&App{db: db}).scanEntry(db.QueryRow("SELECT " + entryCols + " FROM entries WHERE id = 'entry-1'"))
+	if err != nil {
+		t.Fatalf("read entry after upgrade: %v", err)
+	}
+	if *e.Summary != "Talked about the on-call rotation." || len(e.ActionItemsTheirs) != 1 || !e.ActionItemsTheirs[0].Completed {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestMigrateLegacyAlteredDatabase(t *testing.T) {
+	// Databases from before versioning may already have the columns that
+	// the ad-hoc ALTER TABLE calls added.
+	path := loadFixture(t, "baseline.sql")
+	db, _ := sql.Open("sqlite", path)
+	db.Exec("ALTER TABLE team_members ADD COLUMN jira_account_id TEXT")
+	db.Exec("ALTER TABLE team_members ADD COLUMN prep_notes TEXT")
+	db.Exec("UPDATE team_members SET prep_notes = 'ask about pages' WHERE id = 'member-1'")
+	db.Close()
+
+	db, err := openDB(path, log.Default())
+	if err != nil {
+		t.Fatalf("openDB: %v", err)
+	}
+	defer db.Close()
+
+	var notes string
+	db.QueryRow("SELECT prep_notes FROM team_members WHERE id = 'member-1'").Scan(&notes)
+	if notes != "ask about pages" {
+		t.Errorf("prep_notes = %q", notes)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	path := loadFixture(t, "baseline.sql")
+	for i := 0; i < 2; i++ {
+		db, err := openDB(path, log.Default())
+		if err != nil {
+			t.Fatalf("openDB #%d: %v", i+1, err)
+		}
+		if got := appliedVersions(t, db); len(got) != schemaVersion() {
+			t.Errorf("run %d: applied %v", i+1, got)
+		}
+		db.Close()
+	}
+}
+
+func TestMigrateRefusesNewerDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newer.db")
+	db, err := openDB(path, log.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'from the future', '2099-01-01T00:00:00Z')", schemaVersion()+1)
+	db.Close()
+
+	_, err = openDB(path, log.Default())
+	if err == nil || !strings.Contains(err.Error(), "newer than this binary") {
+		t.Fatalf("err = %v, want newer-schema error", err)
+	}
+}
+
+func TestMigrationRollsBackOnFailure(t *testing.T) {
+	path := loadFixture(t, "baseline.sql")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	saved := migrations
+	defer func() { migrations = saved }()
+	migrations = append(append([]migration(nil), saved...), migration{
+		version: len(saved) + 1,
+		name:    "broken",
+		up: func(tx *sql.Tx) error {
+			if _, err := tx.Exec("CREATE TABLE half_done (id TEXT)"); err != nil {
+				return err
+			}
+			_, err := tx.Exec("NOT VALID SQL")
+			return err
+		},
+	})
+
+	if err := migrate(db, log.Default()); err == nil {
+		t.Fatal("migrate succeeded, want error")
+	}
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_done'").Scan(&n)
+	if n != 0 {
+		t.Error("failed migration left half_done table behind")
+	}
+	if got := appliedVersions(t, db); len(got) != len(saved) {
+		t.Errorf("applied %v, want the %d good migrations", got, len(saved))
+	}
+}
+
+func TestMigrateBackfillsActionItems(t *testing.T) {
+	db, err := openDB(loadFixture(t, "baseline.sql"), log.Default())
+	if err != nil {
+		t.Fatalf("openDB: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, owner, text, status, completed_at IS NOT NULL FROM action_items WHERE entry_id = 'entry-1' ORDER BY owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var id, owner, text, status string
+		var completed bool
+		rows.Scan(&id, &owner, &text, &status, &completed)
+		got = append(got, strings.Join([]string{id, owner, text, status, strconv.FormatBool(completed)}, "|"))
+	}
+	want := []string{
+		"action-entry-1-manager-0|manager|Raise on-call load with the team|open|false",
+		"action-entry-1-member-0|member|Draft runbook changes|done|true",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("action_items =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	e, err := (&App{db: db}).scanEntry(db.QueryRow("SELECT " + entryCols + " FROM entries WHERE id = 'entry-1'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.ActionItemsMine) != 1 || e.ActionItemsMine[0].ID != "action-entry-1-manager-0" {
+		t.Errorf("action_items_mine not rewritten with IDs: %+v", e.ActionItemsMine)
+	}
+}
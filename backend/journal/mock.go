@@ -0,0 +1,206 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// mockProvider is a deterministic stand-in for a model, selected with
+// AI_PROVIDER=mock and used by default in test mode. Scripted rules are
+// tried first: the first whose Contains appears in the prompt answers it.
+// Otherwise the reply is built from the prompt itself, so extraction gets
+// valid JSON for the member, tags and custom fields it asks for and prep
+// gets a briefing in the expected format. The same prompt always gets the
+// same reply.
+type mockProvider struct {
+	mu sync.Mutex
+	// scripted is set by script and tried before fileRules, which come from
+	// the MockScript file.
+	scripted  []mockRule
+	fileRules []mockRule
+	sent      []string
+}
+
+// mockRule scripts one reply. A non-zero Status fails the call with a
+// ProviderError instead, with Reply as the body.
+type mockRule struct {
+	Contains string `json:"contains"`
+	Reply    string `json:"reply"`
+	Status   int    `json:"status"`
+}
+
+// newMockProvider loads rules from the JSON file at MockScript, if set.
+func newMockProvider(a *App) Provider {
+	p := &mockProvider{}
+	if path := a.cfg.MockScript; path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &p.fileRules)
+		}
+		if err != nil {
+			p.fileRules = []mockRule{{Status: 500, Reply: fmt.Sprintf("mock: can't load MOCK_AI_SCRIPT: %v", err)}}
+		}
+	}
+	return p
+}
+
+// script replaces the scripted rules and forgets past prompts.
+func (p *mockProvider) script(rules ...mockRule) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.scripted = rules
+	p.sent = nil
+}
+
+// prompts returns the prompts sent to the mock since it was last scripted,
+// oldest first.
+func (p *mockProvider) prompts() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.sent...)
+}
+
+func (p *mockProvider) Name() string { return "mock" }
+
+func (p *mockProvider) Complete(req CompletionRequest) (string, error) {
+	p.mu.Lock()
+	p.sent = append(p.sent, req.Prompt)
+	rules := append(append([]mockRule(nil), p.scripted...), p.fileRules...)
+	p.mu.Unlock()
+
+	if err := req.context().Err(); err != nil {
+		return "", err
+	}
+	reply := ""
+	for _, rule := range rules {
+		if !strings.Contains(req.Prompt, rule.Contains) {
+			continue
+		}
+		if rule.Status != 0 {
+			return "", &ProviderError{Provider: "mock", StatusCode: rule.Status, Body: rule.Reply}
+		}
+		reply = rule.Reply
+		break
+	}
+	if reply == "" {
+		reply = mockReply(req.Prompt)
+	}
+	req.report("mock", req.model("mock"), len(req.Prompt)/4, len(reply)/4)
+	return reply, nil
+}
+
+// Stream relays the reply in fixed-size pieces, so streaming handlers see
+// more than one token event.
+func (p *mockProvider) Stream(req CompletionRequest, onDelta func(string) error) (string, error) {
+	text, err := p.Complete(req)
+	if err != nil {
+		return "", err
+	}
+	for rest := text; rest != ""; {
+		n := min(len(rest), 16)
+		if err := onDelta(rest[:n]); err != nil {
+			return "", err
+		}
+		rest = rest[n:]
+	}
+	return text, nil
+}
+
+// ─── Default Replies ────────────────────────────────────
+
+var (
+	mockReportName  = regexp.MustCompile(`report named ([^.\n]+)\.`)
+	mockPrepName    = regexp.MustCompile(`1:1 meeting with ([^.\n]+)\.`)
+	mockTagList     = regexp.MustCompile(`tags from this list: ([^"\]\n]*)`)
+	mockCustomField = regexp.MustCompile(`^\s+"([a-z][a-z0-9_]*)": (<|\["|")`)
+	mockTicketKey   = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-\d+\b`)
+)
+
+func mockReply(prompt string) string {
+	switch {
+	case strings.Contains(prompt, `"action_items_theirs"`) || strings.Contains(prompt, "Merge the partial extractions"):
+		return mockExtraction(prompt)
+	case strings.Contains(prompt, "**Follow up on**"):
+		return mockBriefing(prompt)
+	default:
+		return "Mock response."
+	}
+}
+
+func mockExtraction(prompt string) string {
+	name := "the report"
+	if m := mockReportName.FindStringSubmatch(prompt); m != nil {
+		name = m[1]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(prompt))
+	sum := h.Sum32()
+
+	tags := []string{}
+	if m := mockTagList.FindStringSubmatch(prompt); m != nil {
+		if first, _, _ := strings.Cut(m[1], ","); strings.TrimSpace(first) != "" {
+			tags = append(tags, strings.TrimSpace(first))
+		}
+	}
+	morale, growth := int(sum%5)+1, int(sum/5%5)+1
+
+	result := map[string]any{
+		"summary":             fmt.Sprintf("Mock summary of a 1:1 with %s (%08x).", name, sum),
+		"tags":                tags,
+		"action_items_mine":   []string{"Follow up with " + name},
+		"action_items_theirs": []string{"Share an update"},
+		"morale_score":        morale,
+		"morale_rationale":    fmt.Sprintf("Mock morale rationale for %s.", name),
+		"growth_score":        growth,
+		"growth_rationale":    fmt.Sprintf("Mock growth rationale for %s.", name),
+		"notable_quotes":      []string{},
+		"blockers":            []string{},
+		"wins":                []string{"Mock win"},
+	}
+
+	// Custom fields are listed one per line inside "custom_fields": { ... }
+	if _, block, ok := strings.Cut(prompt, `"custom_fields": {`); ok {
+		block, _, _ = strings.Cut(block, "\n  }")
+		custom := map[string]any{}
+		for _, line := range strings.Split(block, "\n") {
+			m := mockCustomField.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			switch m[2] {
+			case "<":
+				custom[m[1]] = 3
+			case `["`:
+				custom[m[1]] = []string{"Mock " + m[1]}
+			default:
+				custom[m[1]] = "Mock " + m[1]
+			}
+		}
+		result["custom_fields"] = custom
+	}
+
+	b, _ := json.Marshal(result)
+	return string(b)
+}
+
+func mockBriefing(prompt string) string {
+	name := "them"
+	if m := mockPrepName.FindStringSubmatch(prompt); m != nil {
+		name = m[1]
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Follow up on**\n- Open action items with %s\n\n**Watch for**\n- Changes in morale since the last 1:1\n", name)
+	if strings.Contains(prompt, "**Bring up**\n") {
+		ticket := "their current tickets"
+		if key := mockTicketKey.FindString(prompt); key != "" {
+			ticket = key
+		}
+		fmt.Fprintf(&sb, "\n**Bring up**\n- Progress on %s\n", ticket)
+	}
+	return sb.String()
+}
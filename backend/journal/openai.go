@@ -0,0 +1,164 @@
+package journal
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultOpenAIModel = "gpt-4o"
+
+type openAIProvider struct {
+	apiKey string
+	model  string
+	http   *httpClient
+}
+
+func newOpenAIProvider(a *App) Provider {
+	if a.cfg.OpenAIAPIKey == "" {
+		return nil
+	}
+	return &openAIProvider{
+		apiKey: a.cfg.OpenAIAPIKey,
+		model:  cmp.Or(a.cfg.OpenAIModel, defaultOpenAIModel),
+		http:   a.http["openai"],
+	}
+}
+
+func (p *openAIProvider) Name() string { return "openai" }
+
+const openAIChatURL = "https://api.openai.com/v1/chat/completions"
+
+func (p *openAIProvider) Complete(req CompletionRequest) (string, error) {
+	return openAIChatCompletion(p.http, openAIChatURL, p.apiKey, req.model(p.model), req)
+}
+
+func (p *openAIProvider) Stream(req CompletionRequest, onDelta func(string) error) (string, error) {
+	return openAIChatStream(p.http, openAIChatURL, p.apiKey, req.model(p.model), req, onDelta)
+}
+
+// openAIChatRequest posts to a Chat Completions endpoint. It is shared by the
+// OpenAI provider and OpenAI-compatible local servers; apiKey may be empty.
+func openAIChatRequest(client *httpClient, url, apiKey, model string, req CompletionRequest, stream bool) (*http.Response, error) {
+	name := client.service
+	body := map[string]any{
+		"model":      model,
+		"max_tokens": req.maxTokens(),
+		"messages":   []map[string]string{{"role": "user", "content": req.Prompt}},
+	}
+	if stream {
+		body["stream"] = true
+		// Only OpenAI itself is known to accept stream_options; local servers
+		// may reject it
+		if name == "openai" {
+			body["stream_options"] = map[string]bool{"include_usage": true}
+		}
+	}
+	b, _ := json.Marshal(body)
+
+	httpReq, _ := http.NewRequestWithContext(req.context(), "POST", url, bytes.NewReader(b))
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.do(req.context(), httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", name, err)
+	}
+	if resp.StatusCode != 200 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, &ProviderError{Provider: name, StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	return resp, nil
+}
+
+// openAIUsage is the usage block of a response, or of the final stream chunk
+// when stream_options.include_usage is set.
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u *openAIUsage) report(name, model string, req CompletionRequest) {
+	if u == nil {
+		req.report(name, model, 0, 0)
+		return
+	}
+	req.report(name, model, u.PromptTokens, u.CompletionTokens)
+}
+
+func openAIChatCompletion(client *httpClient, url, apiKey, model string, req CompletionRequest) (string, error) {
+	name := client.service
+	resp, err := openAIChatRequest(client, url, apiKey, model, req, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to parse %s response: %w", name, err)
+	}
+	result.Usage.report(name, model, req)
+
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("%s returned no choices", name)
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+func openAIChatStream(client *httpClient, url, apiKey, model string, req CompletionRequest, onDelta func(string) error) (string, error) {
+	name := client.service
+	resp, err := openAIChatRequest(client, url, apiKey, model, req, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	var usage *openAIUsage
+	err = readSSEData(resp.Body, func(data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to parse %s stream chunk: %w", name, err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return nil
+		}
+		sb.WriteString(chunk.Choices[0].Delta.Content)
+		return onDelta(chunk.Choices[0].Delta.Content)
+	})
+	usage.report(name, model, req)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
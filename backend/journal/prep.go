@@ -0,0 +1,373 @@
+package journal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type PrepResponse struct {
+	Briefing           string           `json:"briefing"`
+	OpenItemsMine      []PrepActionItem `json:"open_items_mine"`
+	OpenItemsTheirs    []PrepActionItem `json:"open_items_theirs"`
+	RecentTags         []TagCount       `json:"recent_tags"`
+	UnresolvedBlockers []string         `json:"unresolved_blockers"`
+	MoraleScores       []ScorePoint     `json:"morale_scores"`
+	GrowthScores       []ScorePoint     `json:"growth_scores"`
+	JIRAAssigned       []JIRATicket     `json:"jira_assigned,omitempty"`
+	JIRACompleted      []JIRATicket     `json:"jira_completed,omitempty"`
+	JIRABlocked        []JIRATicket     `json:"jira_blocked,omitempty"`
+	JIRASprintStats    *JIRASprintStats `json:"jira_sprint_stats,omitempty"`
+	JIRABoardURL       string           `json:"jira_board_url,omitempty"`
+}
+
+type PrepActionItem struct {
+	ID      string  `json:"id,omitempty"`
+	Text    string  `json:"text"`
+	Date    string  `json:"date"`
+	DueDate *string `json:"due_date,omitempty"`
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type ScorePoint struct {
+	Date  string `json:"date"`
+	Score int    `json:"score"`
+}
+
+// buildPrepPrompt renders the prep template. JIRA is only passed to the
+// template when there's activity to talk about.
+func (a *App) buildPrepPrompt(member PromptMember, entries []Entry, jira *JIRAContext) string {
+	return a.renderPrompt(promptPrep, a.prepPromptData(member, entries, jira))
+}
+
+func (a *App) prepPromptData(member PromptMember, entries []Entry, jira *JIRAContext) PrepPromptData {
+	if jira != nil && len(jira.Assigned) == 0 && len(jira.Completed) == 0 && len(jira.Blocked) == 0 {
+		jira = nil
+	}
+	return PrepPromptData{Member: member, Entries: entries, JIRA: jira, Tags: a.activeTags()}
+}
+
+func computeStructuredPrep(entries []Entry) ([]TagCount, []string, []ScorePoint, []ScorePoint) {
+	tagCounts := map[string]int{}
+	var blockers []string
+	var moraleScores, growthScores []ScorePoint
+
+	for _, e := range entries {
+		for _, t := range e.Tags {
+			tagCounts[t]++
+		}
+		blockers = append(blockers, e.Blockers...)
+		if e.MoraleScore != nil {
+			moraleScores = append(moraleScores, ScorePoint{Date: e.Date, Score: *e.MoraleScore})
+		}
+		if e.GrowthScore != nil {
+			growthScores = append(growthScores, ScorePoint{Date: e.Date, Score: *e.GrowthScore})
+		}
+	}
+
+	var tags []TagCount
+	for t, c := range tagCounts {
+		tags = append(tags, TagCount{Tag: t, Count: c})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Count > tags[j].Count })
+
+	return tags, blockers, moraleScores, growthScores
+}
+
+type prepRequest struct {
+	MemberID  string `json:"member_id"`
+	Force     bool   `json:"force"`
+	Model     string `json:"model"`
+	MaxTokens int    `json:"max_tokens"`
+}
+
+// prepJob is everything gathered for a briefing before the model is called.
+// When done is set, resp is already complete (cache hit or no entries) and
+// no model call is needed.
+type prepJob struct {
+	app    *App
+	key    string
+	prompt string
+	resp   PrepResponse
+	done   bool
+}
+
+// decodePrepRequest reads and validates the request body, writing the error
+// response itself when it fails.
+func decodePrepRequest(w http.ResponseWriter, r *http.Request) (prepRequest, bool) {
+	var body prepRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return body, false
+	}
+	if body.MemberID == "" {
+		writeJSON(w, 400, map[string]string{"error": "member_id is required"})
+		return body, false
+	}
+	return body, true
+}
+
+// loadPrep fetches the member, recent entries, cached briefing and JIRA
+// activity. On failure it writes the error response and returns nil.
+func (a *App) loadPrep(ctx context.Context, w http.ResponseWriter, body prepRequest) *prepJob {
+	// Fetch member name and JIRA account ID
+	member := PromptMember{ID: body.MemberID}
+	var jiraAccountID sql.NullString
+	err := a.db.QueryRow("SELECT name, role, jira_account_id FROM team_members WHERE id = ? AND deleted_at IS NULL", body.MemberID).Scan(&member.Name, &member.Role, &jiraAccountID)
+	if err != nil {
+		writeJSON(w, 404, map[string]string{"error": "member not found"})
+		return nil
+	}
+
+	entries, err := a.recentEntries(body.MemberID)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return nil
+	}
+
+	if len(entries) == 0 {
+		return &prepJob{app: a, resp: PrepResponse{Briefing: "No entries yet for this team member."}, done: true}
+	}
+
+	// Open action items come from every entry, not just the recent ones
+	openMine, openTheirs, err := a.openActionItems(body.MemberID)
+	if err != nil {
+		a.log.Printf("Failed to load open action items for %s: %v", body.MemberID, err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return nil
+	}
+
+	// Build cache key from member ID + entry IDs + updated_at + jira_account_id + today's date
+	// Today's date ensures JIRA data refreshes daily (ticket statuses change constantly)
+	keyParts := []string{body.MemberID, time.Now().Format("2006-01-02")}
+	for _, e := range entries {
+		keyParts = append(keyParts, e.ID)
+		if e.UpdatedAt != nil {
+			keyParts = append(keyParts, *e.UpdatedAt)
+		}
+	}
+	if jiraAccountID.Valid {
+		keyParts = append(keyParts, jiraAccountID.String)
+	}
+	for _, item := range append(openMine, openTheirs...) {
+		keyParts = append(keyParts, item.ID)
+	}
+	key := cacheKey(keyParts...)
+
+	// Check cache (skip if force refresh)
+	if !body.Force {
+		if cached, ok := a.cache.get(key, "prep"); ok {
+			var result PrepResponse
+			if err := json.Unmarshal([]byte(cached), &result); err == nil {
+				return &prepJob{app: a, key: key, resp: result, done: true}
+			}
+		}
+	}
+
+	// Compute structured data
+	tags, blockers, moraleScores, growthScores := computeStructuredPrep(entries)
+
+	jiraCtx, accountID := a.prepJIRAContext(ctx, body.MemberID, member.Name, jiraAccountID, entries[len(entries)-1].Date)
+
+	resp := PrepResponse{
+		OpenItemsMine:      openMine,
+		OpenItemsTheirs:    openTheirs,
+		RecentTags:         tags,
+		UnresolvedBlockers: blockers,
+		MoraleScores:       moraleScores,
+		GrowthScores:       growthScores,
+	}
+	if jiraCtx != nil {
+		resp.JIRAAssigned = jiraCtx.Assigned
+		resp.JIRACompleted = jiraCtx.Completed
+		resp.JIRABlocked = jiraCtx.Blocked
+		resp.JIRASprintStats = jiraCtx.SprintStats
+		if jiraCtx.JIRABaseURL != "" {
+			resp.JIRABoardURL = fmt.Sprintf("%s/jira/people/%s", jiraCtx.JIRABaseURL, accountID)
+		}
+	}
+
+	return &prepJob{
+		app:    a,
+		key:    key,
+		prompt: a.buildPrepPrompt(member, entries, jiraCtx),
+		resp:   resp,
+	}
+}
+
+// recentEntries returns the last PrepLookback entries for a member, newest
+// first.
+func (a *App) recentEntries(memberID string) ([]Entry, error) {
+	rows, err := a.db.Query(
+		fmt.Sprintf("SELECT %s FROM entries WHERE member_id = ? AND deleted_at IS NULL ORDER BY date DESC LIMIT ?", entryCols),
+		memberID, a.cfg.PrepLookback,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		e, err := a.scanEntry(rows)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// prepJIRAContext fetches a member's JIRA activity since sinceDate, resolving
+// and caching their account ID on first use. It returns nil when JIRA isn't
+// configured or the fetch fails, along with the account ID used.
+func (a *App) prepJIRAContext(ctx context.Context, memberID, memberName string, jiraAccountID sql.NullString, sinceDate string) (*JIRAContext, string) {
+	if a.jira == nil {
+		a.log.Printf("[JIRA] Not configured, skipping")
+		return nil, ""
+	}
+	a.log.Printf("[JIRA] Fetching activity for %s (member_id=%s)", memberName, memberID)
+	var accountID string
+	if jiraAccountID.Valid {
+		accountID = jiraAccountID.String
+		a.log.Printf("[JIRA] Using cached account ID: %s", accountID)
+	} else {
+		a.log.Printf("[JIRA] No cached account ID, resolving by name...")
+		resolved, err := a.jira.resolveUser(ctx, memberName)
+		if err != nil {
+			a.log.Printf("[JIRA] User resolution failed: %v", err)
+			return nil, ""
+		}
+		accountID = resolved
+		if _, err := a.db.Exec("UPDATE team_members SET jira_account_id = ? WHERE id = ?", resolved, memberID); err != nil {
+			a.log.Printf("[JIRA] Failed to cache account ID: %v", err)
+		}
+		a.log.Printf("[JIRA] Cached account ID %s for %s", resolved, memberName)
+	}
+
+	a.log.Printf("[JIRA] Fetching activity for account %s since %s", accountID, sinceDate)
+	activity, err := a.jira.fetchActivity(ctx, accountID, sinceDate)
+	if err != nil {
+		a.log.Printf("[JIRA] Activity fetch failed: %v", err)
+		return nil, accountID
+	}
+	a.log.Printf("[JIRA] Got %d assigned, %d completed, %d blocked tickets",
+		len(activity.Assigned), len(activity.Completed), len(activity.Blocked))
+	return &activity, accountID
+}
+
+func (j *prepJob) completion(body prepRequest) CompletionRequest {
+	return CompletionRequest{
+		Prompt:    j.prompt,
+		Model:     body.Model,
+		MaxTokens: body.MaxTokens,
+	}
+}
+
+// noProvider fills in the briefing for when no AI provider can be used; the
+// structured data is still returned but not cached.
+func (j *prepJob) noProvider(err error) {
+	if errors.Is(err, errBudgetExceeded) {
+		j.resp.Briefing = "Monthly AI budget reached. Showing structured data only."
+		return
+	}
+	if err != errNoProvider {
+		j.app.log.Printf("[AI] %v", err)
+	}
+	j.resp.Briefing = "No API key configured. Showing structured data only."
+}
+
+// recordCacheHit records a briefing served from the cache. A job with no
+// cache key was done without needing the model at all.
+func (j *prepJob) recordCacheHit() {
+	if j.key != "" {
+		j.app.recordCacheHit(purposePrep)
+	}
+}
+
+// finish fills in the briefing from the model output and caches the response.
+// Failed briefings aren't cached so the next request tries again.
+func (j *prepJob) finish(briefingText string, aiErr error) {
+	if aiErr != nil {
+		j.app.log.Println("Prep briefing generation failed:", aiErr)
+		j.resp.Briefing = "Failed to generate AI briefing. Showing structured data only."
+		return
+	}
+	j.resp.Briefing = strings.TrimSpace(briefingText)
+
+	respJSON, _ := json.Marshal(j.resp)
+	j.app.cache.set(j.key, "prep", string(respJSON))
+}
+
+func (a *App) handlePrep(w http.ResponseWriter, r *http.Request) {
+	body, ok := decodePrepRequest(w, r)
+	if !ok {
+		return
+	}
+
+	job := a.loadPrep(r.Context(), w, body)
+	if job == nil {
+		return
+	}
+	if job.done {
+		job.recordCacheHit()
+		writeJSON(w, 200, job.resp)
+		return
+	}
+
+	provider, err := a.aiProvider(r.Context(), purposePrep)
+	if err != nil {
+		job.noProvider(err)
+		writeJSON(w, 200, job.resp)
+		return
+	}
+
+	job.finish(provider.Complete(job.completion(body)))
+	writeJSON(w, 200, job.resp)
+}
+
+// handlePrepStream is the SSE variant of handlePrep. The briefing is relayed
+// as "token" events, followed by a "result" event with the full PrepResponse.
+func (a *App) handlePrepStream(w http.ResponseWriter, r *http.Request) {
+	body, ok := decodePrepRequest(w, r)
+	if !ok {
+		return
+	}
+
+	job := a.loadPrep(r.Context(), w, body)
+	if job == nil {
+		return
+	}
+
+	sse, err := newSSEWriter(w)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if job.done {
+		job.recordCacheHit()
+		sse.send("result", job.resp)
+		return
+	}
+
+	provider, err := a.aiProvider(r.Context(), purposePrep)
+	if err != nil {
+		job.noProvider(err)
+		sse.send("result", job.resp)
+		return
+	}
+
+	job.finish(streamCompletion(provider, job.completion(body), sse.token))
+	sse.send("result", job.resp)
+}
@@ -0,0 +1,603 @@
+package journal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Prompts are text/template templates. The built-in defaults below are
+// version 0; saving a template adds a new numbered version to
+// prompt_templates and the newest one is used. Resetting saves the default
+// as a new version, so a reset can be undone like any other edit.
+
+const (
+	promptExtraction = "extraction"
+	promptChunk      = "chunk"
+	promptMerge      = "merge"
+	promptPrep       = "prep"
+)
+
+// promptNames lists the templates in the order they're shown.
+var promptNames = []string{promptExtraction, promptChunk, promptMerge, promptPrep}
+
+// PromptMember is the team member a prompt is about. Name is always set; for
+// extraction, ID and Role are only set when the request includes member_id.
+type PromptMember struct {
+	ID   string
+	Name string
+	Role string
+}
+
+// ExtractionPromptData is what the extraction template renders.
+//
+//	.Member        PromptMember
+//	.Transcript    the transcript, with speaker markers when .Attributed
+//	.Attributed    turns are marked (manager), (report) or (other)
+//	.Tags          the active taxonomy: []Tag with .Name, .Description, .Color
+//	.CustomFields  active custom fields: []CustomField with .Name, .Type
+//	               (text, list or score) and .Instructions
+type ExtractionPromptData struct {
+	Member       PromptMember
+	Transcript   string
+	Attributed   bool
+	Redacted     bool
+	Tags         []Tag
+	CustomFields []CustomField
+}
+
+// ChunkPromptData is what the chunk template renders for each part of a
+// transcript too long to extract in one go.
+//
+//	.Part        this part's number, from 1
+//	.Total       how many parts the transcript was split into
+//	.Extraction  the extraction template rendered with this part's transcript
+type ChunkPromptData struct {
+	Part       int
+	Total      int
+	Extraction string
+}
+
+// MergePromptData is what the merge template renders once every part of a
+// long transcript has been extracted.
+//
+//	.Member        PromptMember
+//	.Partials      each part's extraction as indented JSON, in order
+//	.Tags          the active taxonomy
+//	.CustomFields  active custom fields
+type MergePromptData struct {
+	Member       PromptMember
+	Partials     []string
+	Tags         []Tag
+	CustomFields []CustomField
+}
+
+// PrepPromptData is what the prep briefing template renders.
+//
+//	.Member   PromptMember
+//	.Entries  the most recent entries, newest first: []Entry with .Date,
+//	          .Summary, .MoraleScore, .MoraleRationale, .GrowthScore,
+//	          .GrowthRationale, .Tags, .ActionItemsMine, .ActionItemsTheirs
+//	          (each with .Text and .Completed), .Blockers, .Wins,
+//	          .NotableQuotes, .PrivateNote, .CustomFields (name → value)
+//	.JIRA     nil without JIRA activity, else .Assigned, .Completed and
+//	          .Blocked tickets (.Key, .Summary, .Status, .Flagged, .EpicName)
+//	          and .SprintStats (.PointsCommitted, .PointsCompleted; may be nil)
+//	.Tags     the active taxonomy
+type PrepPromptData struct {
+	Member  PromptMember
+	Entries []Entry
+	JIRA    *JIRAContext
+	Tags    []Tag
+}
+
+// promptFuncs are available to every template.
+var promptFuncs = template.FuncMap{
+	// join joins a list of strings: {{join .Blockers "; "}}
+	"join": func(items []string, sep string) string { return strings.Join(items, sep) },
+	// names lists tag names: {{join (names .Tags) ", "}}
+	"names": tagNames,
+	// described keeps the tags that have a description.
+	"described": func(tags []Tag) []Tag {
+		var out []Tag
+		for _, t := range tags {
+			if t.Description != "" {
+				out = append(out, t)
+			}
+		}
+		return out
+	},
+	// inc adds one, for numbering from 1 in a range.
+	"inc": func(i int) int { return i + 1 },
+}
+
+const defaultExtractionTemplate = `You are helping an engineering manager process a 1:1 meeting transcript with their report named {{.Member.Name}}. Extract structured information and respond ONLY with a JSON object (no markdown, no backticks, no preamble). The JSON should have these fields:
+
+{
+  "summary": "2-4 sentence summary of the key discussion points",
+  "tags": ["array of relevant tags from this list: {{join (names .Tags) ", "}}"],
+  "action_items_mine": ["action items for the manager"],
+  "action_items_theirs": ["action items for {{.Member.Name}}"],
+  "morale_score": <1-5 integer, your best read on their energy/morale based on tone>,
+  "morale_rationale": "1-2 sentence explanation of why you gave this morale score, citing specific things from the conversation",
+  "growth_score": <1-5 integer, signals of professional growth or stagnation>,
+  "growth_rationale": "1-2 sentence explanation of why you gave this growth score, citing specific things from the conversation",
+  "notable_quotes": ["{{if .Attributed}}1-2 notable or important things {{.Member.Name}} said, copied verbatim from turns marked (report) only, never from the manager's turns{{else}}1-2 notable or important things {{.Member.Name}} said, verbatim if possible{{end}}"],
+  "blockers": ["any blockers or frustrations mentioned"],
+  "wins": ["any wins, accomplishments, or positive things mentioned"]{{with .CustomFields}},
+  "custom_fields": {
+{{range $i, $f := .}}{{if $i}},
+{{end}}    "{{.Name}}": {{if eq .Type "score"}}<1-5 integer, or null if it didn't come up: {{.Instructions}}>{{else if eq .Type "list"}}["{{.Instructions}}"]{{else}}"{{.Instructions}}"{{end}}{{end}}
+  }{{end}}
+}
+
+{{with described .Tags}}What the tags mean:
+{{range .}}- {{.Name}}: {{.Description}}
+{{end}}
+{{end}}{{if .Attributed}}Each turn in the transcript starts with the speaker's name and who they are: (manager) is the manager, (report) is {{.Member.Name}} and (other) is anyone else. Go by these markers, not by guessing from the content, when deciding who said or committed to what.
+
+{{end}}{{if .Redacted}}Some details in the transcript have been replaced with placeholders such as [EMAIL_1] or [TERM_2]. Wherever you refer to one of those details, write its placeholder exactly as it appears.
+
+{{end}}Here is the transcript:
+
+{{.Transcript}}`
+
+const defaultChunkTemplate = `This is part {{.Part}} of {{.Total}} of a long 1:1 transcript. Consecutive parts overlap by a few lines. Extract only what appears in this part; the parts will be merged afterwards.
+
+{{.Extraction}}`
+
+const defaultMergeTemplate = `You are helping an engineering manager process a long 1:1 meeting transcript with their report named {{.Member.Name}}. The transcript was split into {{len .Partials}} overlapping parts and each part was extracted separately. Merge the partial extractions below into a single result for the whole meeting and respond ONLY with a JSON object (no markdown, no backticks, no preamble) with the same fields as the partials.
+
+- "summary": 2-4 sentences covering the whole meeting, not a list of part summaries
+- "morale_score" and "growth_score": one 1-5 integer each for the whole meeting, weighing the parts by how much they reveal
+- "morale_rationale" and "growth_rationale": 1-2 sentences each
+- "tags": only tags from this list: {{join (names .Tags) ", "}}
+- list fields: combine the parts and remove duplicates, including items that say the same thing in different words
+
+{{if .CustomFields}}- "custom_fields": the same keys as the partials; merge text values into one, combine and dedupe lists, and give one score for the whole meeting (null if no part has one)
+
+{{end}}{{range $i, $p := .Partials}}--- Part {{inc $i}} ---
+{{$p}}
+
+{{end}}`
+
+const defaultPrepTemplate = `You are helping an engineering manager prepare for a 1:1 meeting with {{.Member.Name}}. Below are the last {{len .Entries}} meeting entries (newest first). Generate a concise bullet-point briefing with these {{if .JIRA}}three{{else}}two{{end}} sections:
+
+**Follow up on**
+**Watch for**
+{{if .JIRA}}**Bring up**
+{{end}}
+Follow up on = open action items and unresolved topics to revisit.
+Watch for = morale/growth concerns or patterns worth probing.
+{{if .JIRA}}Bring up = topics grounded in their current JIRA activity worth discussing.
+
+When an action item from a previous 1:1 clearly maps to a JIRA ticket, reference the ticket status instead of treating it as a separate open item.
+{{end}}
+Keep bullets short and scannable. No narrative prose.
+Use this exact format — section headers as **bold text** on their own line, bullets as - dashes:
+
+**Follow up on**
+- bullet one
+- bullet two
+
+**Watch for**
+- bullet one
+
+{{if .JIRA}}**Bring up**
+- bullet one
+
+{{end}}{{range $i, $e := .Entries}}--- Entry {{inc $i}} ({{.Date}}) ---
+{{with .Summary}}Summary: {{.}}
+{{end}}{{with .MoraleScore}}Morale: {{.}}/5{{with $e.MoraleRationale}} ({{.}}){{end}}
+{{end}}{{with .GrowthScore}}Growth: {{.}}/5{{with $e.GrowthRationale}} ({{.}}){{end}}
+{{end}}{{with .Tags}}Tags: {{join . ", "}}
+{{end}}{{with .ActionItemsMine}}My action items:
+{{range .}}  {{if .Completed}}[x]{{else}}[ ]{{end}} {{.Text}}
+{{end}}{{end}}{{with .ActionItemsTheirs}}{{$.Member.Name}}'s action items:
+{{range .}}  {{if .Completed}}[x]{{else}}[ ]{{end}} {{.Text}}
+{{end}}{{end}}{{with .Blockers}}Blockers: {{join . "; "}}
+{{end}}{{with .Wins}}Wins: {{join . "; "}}
+{{end}}{{with .NotableQuotes}}Notable quotes: {{join . "; "}}
+{{end}}
+{{end}}{{with .JIRA}}--- Current JIRA Activity ---
+{{with .Assigned}}Assigned tickets (current sprint):
+{{range .}}  - {{.Key}}: {{.Summary}} [{{.Status}}{{if .Flagged}}, flagged{{end}}]{{with .EpicName}} (Epic: {{.}}){{end}}
+{{end}}{{end}}{{with .Completed}}Recently completed:
+{{range .}}  - {{.Key}}: {{.Summary}}{{with .EpicName}} (Epic: {{.}}){{end}}
+{{end}}{{end}}{{with .Blocked}}Blocked/flagged:
+{{range .}}  - {{.Key}}: {{.Summary}}
+{{end}}{{end}}{{with .SprintStats}}Sprint stats: {{.PointsCompleted}}/{{.PointsCommitted}} points completed
+{{end}}
+{{end}}`
+
+type promptSpec struct {
+	description string
+	body        string
+	sample      func(*App) any
+}
+
+var promptSpecs = map[string]promptSpec{
+	promptExtraction: {"Turns a 1:1 transcript into the extraction JSON", defaultExtractionTemplate, func(a *App) any { return a.sampleExtractionData() }},
+	promptChunk:      {"Wraps the extraction prompt for one part of a long transcript", defaultChunkTemplate, func(a *App) any { return a.sampleChunkData() }},
+	promptMerge:      {"Merges the extractions of a long transcript's parts into one", defaultMergeTemplate, func(a *App) any { return a.sampleMergeData() }},
+	promptPrep:       {"Writes the prep briefing from recent entries and JIRA activity", defaultPrepTemplate, func(a *App) any { return a.samplePrepData() }},
+}
+
+// ─── Rendering ──────────────────────────────────────────
+
+// activePrompt returns the newest saved version of a template, or the
+// built-in default (version 0) when none has been saved.
+func (a *App) activePrompt(name string) (body string, version int) {
+	spec := promptSpecs[name]
+	if a.db == nil {
+		return spec.body, 0
+	}
+	err := a.db.QueryRow("SELECT body, version FROM prompt_templates WHERE name = ? ORDER BY version DESC LIMIT 1", name).Scan(&body, &version)
+	if err == sql.ErrNoRows {
+		return spec.body, 0
+	}
+	if err != nil {
+		a.log.Printf("Failed to load %s prompt template, using the default: %v", name, err)
+		return spec.body, 0
+	}
+	return body, version
+}
+
+func executePrompt(body string, data any) (string, error) {
+	tmpl, err := template.New("prompt").Funcs(promptFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// renderPrompt renders the active template for name. A saved template that
+// fails to render falls back to the default so extraction and prep keep
+// working.
+func (a *App) renderPrompt(name string, data any) string {
+	body, version := a.activePrompt(name)
+	out, err := executePrompt(body, data)
+	if err == nil {
+		return out
+	}
+	a.log.Printf("Failed to render %s prompt version %d, using the default: %v", name, version, err)
+	out, err = executePrompt(promptSpecs[name].body, data)
+	if err != nil {
+		a.log.Printf("Failed to render default %s prompt: %v", name, err)
+	}
+	return out
+}
+
+// checkPrompt parses body and renders it against sample data, so templates
+// that reference missing fields are rejected when saved rather than at
+// extraction time.
+func (a *App) checkPrompt(name, body string) error {
+	_, err := executePrompt(body, promptSpecs[name].sample(a))
+	return err
+}
+
+const sampleTranscript = "Dana: How did the launch go?\n\nSam: Better than expected. The rollback plan made it much less stressful."
+
+func (a *App) sampleExtractionData() ExtractionPromptData {
+	return ExtractionPromptData{
+		Member:     PromptMember{ID: "member-1", Name: "Sam", Role: "Engineer"},
+		Transcript: sampleTranscript,
+		Attributed: true,
+		Tags:       a.activeTags(),
+		CustomFields: []CustomField{
+			{Name: "career_goals", Type: fieldText, Instructions: "career goals they mentioned"},
+			{Name: "feedback_given", Type: fieldList, Instructions: "feedback the manager gave"},
+			{Name: "attrition_risk", Type: fieldScore, Instructions: "how likely they seem to leave"},
+		},
+	}
+}
+
+func (a *App) sampleChunkData() ChunkPromptData {
+	extraction, _ := executePrompt(defaultExtractionTemplate, a.sampleExtractionData())
+	return ChunkPromptData{Part: 1, Total: 2, Extraction: extraction}
+}
+
+func (a *App) sampleMergeData() MergePromptData {
+	return a.mergePromptData(a.sampleExtractionData(), samplePartials())
+}
+
+func samplePartials() []ExtractionResult {
+	morale, growth := 4, 3
+	return []ExtractionResult{
+		{Summary: "Talked about the launch.", Tags: []string{"wins"}, MoraleScore: &morale, GrowthScore: &growth,
+			ActionItemsTheirs: []string{"Write the launch retro"}, Wins: []string{"Launch shipped on time"}},
+		{Summary: "Talked about the next project.", MoraleScore: &morale, ActionItemsMine: []string{"Share the promo rubric"},
+			CustomFields: map[string]any{"career_goals": "Lead a project next half"}},
+	}
+}
+
+func (a *App) samplePrepData() PrepPromptData {
+	summary, rationale, note, score := "Talked about the launch.", "Upbeat about the rollout.", "Follow up on promo timing.", 4
+	return PrepPromptData{
+		Member: PromptMember{ID: "member-1", Name: "Sam", Role: "Engineer"},
+		Entries: []Entry{{
+			ID: "entry-1", MemberID: "member-1", Date: "2024-05-01T15:00:00Z",
+			Summary: &summary, MoraleScore: &score, MoraleRationale: &rationale, GrowthScore: &score, GrowthRationale: &rationale,
+			Tags:              []string{"wins"},
+			ActionItemsMine:   []ActionItem{{Text: "Share the promo rubric", Completed: true}},
+			ActionItemsTheirs: []ActionItem{{Text: "Write the launch retro"}},
+			NotableQuotes:     []string{"The rollback plan made it much less stressful."},
+			Blockers:          []string{"Waiting on infra review"},
+			Wins:              []string{"Launch shipped on time"},
+			PrivateNote:       &note,
+			CustomFields:      map[string]any{"career_goals": "Lead a project next half", "attrition_risk": 2},
+		}},
+		JIRA: &JIRAContext{
+			Assigned:    []JIRATicket{{Key: "ENG-1", Summary: "Launch retro", Status: "In Progress", Flagged: true, EpicName: "Launch"}},
+			Completed:   []JIRATicket{{Key: "ENG-2", Summary: "Rollback plan", EpicName: "Launch"}},
+			Blocked:     []JIRATicket{{Key: "ENG-3", Summary: "Infra review"}},
+			SprintStats: &JIRASprintStats{PointsCommitted: 10, PointsCompleted: 6},
+		},
+		Tags: a.activeTags(),
+	}
+}
+
+// clearPromptCache drops cached results made with the previous version of a
+// template.
+func (a *App) clearPromptCache(name string) {
+	switch name {
+	case promptExtraction, promptChunk, promptMerge:
+		a.clearExtractionCache()
+	case promptPrep:
+		if err := a.cache.clear("prep"); err != nil {
+			a.log.Printf("Failed to clear prep cache: %v", err)
+		}
+	}
+}
+
+// ─── Handlers ───────────────────────────────────────────
+
+type PromptTemplate struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Version     int    `json:"version"`
+	Body        string `json:"body,omitempty"`
+	IsDefault   bool   `json:"is_default"`
+	DefaultBody string `json:"default_body,omitempty"`
+	Note        string `json:"note,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+}
+
+func promptFromRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("name")
+	if _, ok := promptSpecs[name]; !ok {
+		writeJSON(w, 404, map[string]string{"error": fmt.Sprintf("unknown prompt %q; prompts are %s", name, strings.Join(promptNames, ", "))})
+		return "", false
+	}
+	return name, true
+}
+
+func (a *App) currentPrompt(name string) PromptTemplate {
+	spec := promptSpecs[name]
+	p := PromptTemplate{Name: name, Description: spec.description, DefaultBody: spec.body}
+	p.Body, p.Version = a.activePrompt(name)
+	p.IsDefault = p.Body == spec.body
+	if p.Version > 0 {
+		a.db.QueryRow("SELECT note, created_at FROM prompt_templates WHERE name = ? AND version = ?", name, p.Version).Scan(&p.Note, &p.CreatedAt)
+	}
+	return p
+}
+
+// handleGetPrompts lists the templates in use, without their bodies.
+func (a *App) handleGetPrompts(w http.ResponseWriter, r *http.Request) {
+	prompts := []PromptTemplate{}
+	for _, name := range promptNames {
+		p := a.currentPrompt(name)
+		p.Body, p.DefaultBody = "", ""
+		prompts = append(prompts, p)
+	}
+	writeJSON(w, 200, prompts)
+}
+
+func (a *App) handleGetPrompt(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptFromRequest(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, 200, a.currentPrompt(name))
+}
+
+// savePrompt checks body and stores it as the next version of name.
+func (a *App) savePrompt(w http.ResponseWriter, name, body, note string) {
+	if strings.TrimSpace(body) == "" {
+		writeJSON(w, 400, map[string]string{"error": "body is required"})
+		return
+	}
+	if err := a.checkPrompt(name, body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid template: " + err.Error()})
+		return
+	}
+	_, err := a.db.Exec(`
+		INSERT INTO prompt_templates (name, version, body, note, created_at)
+		VALUES (?, (SELECT COALESCE(MAX(version), 0) + 1 FROM prompt_templates WHERE name = ?), ?, ?, ?)`,
+		name, name, body, note, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		a.log.Printf("Failed to save %s prompt: %v", name, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to save prompt"})
+		return
+	}
+	a.clearPromptCache(name)
+	writeJSON(w, 200, a.currentPrompt(name))
+}
+
+// handleUpdatePrompt saves a new version. The template must render against
+// sample data for its data model.
+func (a *App) handleUpdatePrompt(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptFromRequest(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Body string `json:"body"`
+		Note string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return
+	}
+	a.savePrompt(w, name, body.Body, body.Note)
+}
+
+// handleResetPrompt saves the built-in default as a new version.
+func (a *App) handleResetPrompt(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptFromRequest(w, r)
+	if !ok {
+		return
+	}
+	a.savePrompt(w, name, promptSpecs[name].body, "reset to default")
+}
+
+func (a *App) handleGetPromptVersions(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptFromRequest(w, r)
+	if !ok {
+		return
+	}
+	rows, err := a.db.Query("SELECT version, body, note, created_at FROM prompt_templates WHERE name = ? ORDER BY version DESC", name)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer rows.Close()
+	versions := []PromptTemplate{}
+	for rows.Next() {
+		p := PromptTemplate{Name: name}
+		if err := rows.Scan(&p.Version, &p.Body, &p.Note, &p.CreatedAt); err != nil {
+			a.log.Printf("Failed to scan %s prompt version: %v", name, err)
+			continue
+		}
+		p.IsDefault = p.Body == promptSpecs[name].body
+		p.Body = ""
+		versions = append(versions, p)
+	}
+	writeJSON(w, 200, versions)
+}
+
+// handleGetPromptVersion returns one saved version; version 0 is the
+// built-in default.
+func (a *App) handleGetPromptVersion(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptFromRequest(w, r)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || version < 0 {
+		writeJSON(w, 400, map[string]string{"error": "version must be a number"})
+		return
+	}
+	p := PromptTemplate{Name: name, Description: promptSpecs[name].description, Version: version, Body: promptSpecs[name].body}
+	if version > 0 {
+		err := a.db.QueryRow("SELECT body, note, created_at FROM prompt_templates WHERE name = ? AND version = ?", name, version).
+			Scan(&p.Body, &p.Note, &p.CreatedAt)
+		if err != nil {
+			writeJSON(w, 404, map[string]string{"error": "version not found"})
+			return
+		}
+	}
+	p.IsDefault = p.Body == promptSpecs[name].body
+	writeJSON(w, 200, p)
+}
+
+// handlePreviewPrompt renders a template with real data without calling the
+// model. Body fields: body (an unsaved template; defaults to the active one),
+// member_id, and for extraction, chunk and merge member_name and transcript.
+// Prep needs a member_id; the others fall back to a sample transcript. Chunk
+// renders the transcript's first part and merge renders sample partials.
+func (a *App) handlePreviewPrompt(w http.ResponseWriter, r *http.Request) {
+	name, ok := promptFromRequest(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Body       string `json:"body"`
+		MemberID   string `json:"member_id"`
+		MemberName string `json:"member_name"`
+		Transcript string `json:"transcript"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return
+	}
+	tmpl, version := body.Body, -1
+	if tmpl == "" {
+		tmpl, version = a.activePrompt(name)
+	}
+
+	member := PromptMember{ID: body.MemberID, Name: body.MemberName}
+	if body.MemberID != "" {
+		err := a.db.QueryRow("SELECT name, role FROM team_members WHERE id = ? AND deleted_at IS NULL", body.MemberID).Scan(&member.Name, &member.Role)
+		if err != nil {
+			writeJSON(w, 404, map[string]string{"error": "member not found"})
+			return
+		}
+	}
+
+	var data any
+	switch name {
+	case promptExtraction, promptChunk, promptMerge:
+		sample := a.sampleExtractionData()
+		if member.Name == "" {
+			member = sample.Member
+		}
+		req := extractRequest{Transcript: body.Transcript, MemberName: member.Name, MemberID: body.MemberID}
+		if req.Transcript == "" {
+			req.Transcript = sample.Transcript
+		}
+		a.attributeSpeakers(&req)
+		extraction := a.extractionPromptData(req)
+		switch name {
+		case promptExtraction:
+			data = extraction
+		case promptChunk:
+			chunks := chunkTranscript(req.Transcript, a.chunkThreshold(), defaultOverlapTurns)
+			if len(chunks) == 0 {
+				chunks = []string{req.Transcript}
+			}
+			extraction.Transcript = chunks[0]
+			data = ChunkPromptData{Part: 1, Total: len(chunks), Extraction: a.renderPrompt(promptExtraction, extraction)}
+		case promptMerge:
+			data = a.mergePromptData(extraction, samplePartials())
+		}
+	case promptPrep:
+		if body.MemberID == "" {
+			writeJSON(w, 400, map[string]string{"error": "member_id is required to preview the prep prompt"})
+			return
+		}
+		entries, err := a.recentEntries(body.MemberID)
+		if err != nil {
+			writeJSON(w, 500, map[string]string{"error": "db error"})
+			return
+		}
+		var jiraAccountID sql.NullString
+		a.db.QueryRow("SELECT jira_account_id FROM team_members WHERE id = ?", body.MemberID).Scan(&jiraAccountID)
+		var jira *JIRAContext
+		if len(entries) > 0 {
+			jira, _ = a.prepJIRAContext(r.Context(), body.MemberID, member.Name, jiraAccountID, entries[len(entries)-1].Date)
+		}
+		data = a.prepPromptData(member, entries, jira)
+	}
+
+	out, err := executePrompt(tmpl, data)
+	if err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid template: " + err.Error()})
+		return
+	}
+	res := map[string]any{"prompt": out}
+	if version >= 0 {
+		res["version"] = version
+	}
+	writeJSON(w, 200, res)
+}
@@ -0,0 +1,179 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+)
+
+// ─── Provider Interface ─────────────────────────────────
+
+// Provider is an AI backend that turns a single-turn prompt into text.
+type Provider interface {
+	Name() string
+	Complete(req CompletionRequest) (string, error)
+}
+
+// CompletionRequest carries a prompt plus optional per-call overrides.
+// Zero values fall back to the provider's configured model and defaultMaxTokens.
+type CompletionRequest struct {
+	Prompt    string
+	Model     string
+	MaxTokens int
+
+	// ctx cancels the call; see context.
+	ctx context.Context
+	// usage collects what the provider reports about the call; see report.
+	usage *callUsage
+}
+
+const defaultMaxTokens = 1000
+
+func (r CompletionRequest) maxTokens() int {
+	if r.MaxTokens > 0 {
+		return r.MaxTokens
+	}
+	return defaultMaxTokens
+}
+
+// context is the context the call runs under: the incoming request's, for
+// calls made through aiProvider.
+func (r CompletionRequest) context() context.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
+	return context.Background()
+}
+
+func (r CompletionRequest) model(fallback string) string {
+	if r.Model != "" {
+		return r.Model
+	}
+	return fallback
+}
+
+// ProviderError is returned when a provider answers with a non-200 status.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+var errNoProvider = errors.New("No API key configured. Set ANTHROPIC_API_KEY, OPENAI_API_KEY or LOCAL_LLM_BASE_URL in .env")
+
+// ─── Registry ───────────────────────────────────────────
+
+// providerConstructors maps a provider name to a constructor that returns
+// nil when the provider has no credentials configured.
+var providerConstructors = map[string]func(*App) Provider{
+	"anthropic": newAnthropicProvider,
+	"openai":    newOpenAIProvider,
+	"local":     newLocalProvider,
+	"mock":      newMockProvider,
+}
+
+// defaultProviderChain is the order tried when AIProvider is unset.
+var defaultProviderChain = []string{"anthropic", "openai"}
+
+// newProvider resolves the provider from AIProvider. A single name selects
+// that provider explicitly; a comma-separated list ("anthropic,openai") builds
+// a fallback chain. Unset means the local provider alone when one is
+// configured, so offline setups never fall back to a cloud API, and otherwise
+// every configured cloud provider in default order.
+func (a *App) newProvider() (Provider, error) {
+	names := defaultProviderChain
+	explicit := false
+	if a.cfg.LocalLLMBaseURL != "" {
+		names = []string{"local"}
+	}
+	if v := a.cfg.AIProvider; v != "" {
+		names = nil
+		for _, n := range strings.Split(v, ",") {
+			if n = strings.ToLower(strings.TrimSpace(n)); n != "" {
+				names = append(names, n)
+			}
+		}
+		explicit = true
+	}
+
+	var chain []Provider
+	for _, name := range names {
+		construct, ok := providerConstructors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown AI provider %q", name)
+		}
+		p := construct(a)
+		if p == nil {
+			if explicit {
+				a.log.Printf("[AI] Provider %q selected but not configured, skipping", name)
+			}
+			continue
+		}
+		chain = append(chain, p)
+	}
+
+	switch len(chain) {
+	case 0:
+		return nil, errNoProvider
+	case 1:
+		return chain[0], nil
+	default:
+		return &fallbackProvider{chain: chain, log: a.log}, nil
+	}
+}
+
+// ─── Fallback Chain ─────────────────────────────────────
+
+// fallbackProvider tries each provider in turn, moving on only when the
+// failure looks transient (5xx or timeout). A model override is assumed to
+// name a model of the primary provider and is dropped for the fallbacks.
+type fallbackProvider struct {
+	chain []Provider
+	log   *log.Logger
+}
+
+func (f *fallbackProvider) Name() string {
+	names := make([]string, len(f.chain))
+	for i, p := range f.chain {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f *fallbackProvider) Complete(req CompletionRequest) (string, error) {
+	var err error
+	for i, p := range f.chain {
+		if i > 0 {
+			req.Model = ""
+		}
+		var text string
+		text, err = p.Complete(req)
+		if err == nil {
+			return text, nil
+		}
+		if !shouldFallback(err) {
+			return "", err
+		}
+		f.log.Printf("[AI] %s failed (%v), trying next provider", p.Name(), err)
+	}
+	return "", err
+}
+
+func shouldFallback(err error) bool {
+	var perr *ProviderError
+	if errors.As(err, &perr) {
+		return perr.StatusCode >= 500
+	}
+	var nerr net.Error
+	if errors.As(err, &nerr) {
+		return nerr.Timeout()
+	}
+	return false
+}
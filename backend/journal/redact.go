@@ -0,0 +1,318 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Before a transcript is sent to a cloud model, contact details, money
+// figures, health details, credentials and anything on the user's deny-list
+// are swapped for placeholders such as [EMAIL_1]. The same value always gets
+// the same placeholder within a request, and the placeholders the model
+// copies into its answer are swapped back before the result is returned.
+// REDACT_TRANSCRIPTS picks when this happens: "cloud" (the default) for any
+// provider that isn't local, "always", or "off".
+
+// Redaction is one redacted value in the report returned with an extraction.
+type Redaction struct {
+	Placeholder string `json:"placeholder"`
+	Kind        string `json:"kind"`
+	Value       string `json:"value"`
+	Count       int    `json:"count"`
+}
+
+// redactionDetectors run in order, so credentials are caught before the
+// email or phone patterns can claim part of them. Cards run before phones,
+// so a number written in groups of four counts as a card. group is the
+// submatch that gets replaced; 0 is the whole match. Amounts only take
+// thousands separators, so a comma after a figure stays in the text. Near a
+// pay word a number still needs a currency symbol, a k suffix or thousands
+// separators, so "raise this in 2 weeks" isn't an amount.
+var redactionDetectors = []struct {
+	kind  string
+	re    *regexp.Regexp
+	group int
+}{
+	{"secret", regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|passphrase|secret|token|api[ _-]?key)\s*[:=]\s*(\S+)`), 1},
+	{"secret", regexp.MustCompile(`\b(?:sk-(?:ant-)?[A-Za-z0-9_-]{16,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,})`), 0},
+	{"email", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`), 0},
+	{"id", regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), 0},
+	{"card", regexp.MustCompile(`\b(?:\d{4}[ -]?){3}\d{1,4}\b`), 0},
+	{"phone", regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{3}\)\s?|\b\d{3}[\s.-])\d{3}[\s.-]\d{4}\b`), 0},
+	{"money", regexp.MustCompile(`(?i)[$€£]\s?\d+(?:,\d{3})*(?:\.\d+)?(?:\s?(?:k|m|million|thousand)\b)?|\b\d+(?:,\d{3})*(?:\.\d+)?\s?(?:usd|eur|gbp|dollars|euros|pounds)\b`), 0},
+	{"money", regexp.MustCompile(`(?i)\b(?:salary|comp|compensation|raise|bonus|offer|equity|pay)\b[^.\n]{0,30}?([$€£]\s?\d+(?:,\d{3})*(?:\.\d+)?|\b\d+(?:\.\d+)?\s?k\b|\b\d{1,3}(?:,\d{3})+(?:\.\d+)?\b)`), 1},
+	{"health", regexp.MustCompile(`(?i)\b(?:diagnos\w*|therap(?:y|ist)|psychiatrist|depress(?:ion|ed)|anxiety|panic attacks?|burn(?:ed|t)? ?out|pregnan\w*|miscarriage|cancer|chemo\w*|surgery|disabilit(?:y|ies)|adhd|autis\w*|medication|rehab|medical leave|sick leave)\b`), 0},
+}
+
+// placeholderPattern matches placeholders in model output. Models sometimes
+// change their case, so the lookup is done upper-cased.
+var placeholderPattern = regexp.MustCompile(`(?i)\[[a-z]+_\d+\]`)
+
+type redactor struct {
+	terms  []string
+	byKey  map[string]*Redaction
+	byName map[string]*Redaction
+	counts map[string]int
+	items  []*Redaction
+}
+
+// newRedactor returns the redactor for calls to provider, or nil when
+// RedactTranscripts says its transcripts go as they are.
+func (a *App) newRedactor(provider Provider) *redactor {
+	switch mode := strings.ToLower(a.cfg.RedactTranscripts); mode {
+	case "off":
+		return nil
+	case "always":
+	case "", "cloud":
+		if providerIsOffline(provider) {
+			return nil
+		}
+	default:
+		a.log.Printf("[AI] Unknown REDACT_TRANSCRIPTS %q, redacting for cloud providers", mode)
+		if providerIsOffline(provider) {
+			return nil
+		}
+	}
+	return &redactor{
+		terms:  a.redactionTermList(),
+		byKey:  map[string]*Redaction{},
+		byName: map[string]*Redaction{},
+		counts: map[string]int{},
+	}
+}
+
+// placeholder returns the placeholder for value, assigning the next one of
+// its kind the first time it's seen.
+func (r *redactor) placeholder(kind, value string) string {
+	key := kind + "\x00" + strings.ToLower(value)
+	if red, ok := r.byKey[key]; ok {
+		red.Count++
+		return red.Placeholder
+	}
+	r.counts[kind]++
+	red := &Redaction{
+		Placeholder: fmt.Sprintf("[%s_%d]", strings.ToUpper(kind), r.counts[kind]),
+		Kind:        kind,
+		Value:       value,
+		Count:       1,
+	}
+	r.byKey[key] = red
+	r.byName[red.Placeholder] = red
+	r.items = append(r.items, red)
+	return red.Placeholder
+}
+
+// replace swaps every match of re (or of its group) in s for a placeholder.
+func (r *redactor) replace(s, kind string, re *regexp.Regexp, group int) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 || start < last {
+			continue
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(r.placeholder(kind, s[start:end]))
+		last = end
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+// redact returns s with everything the detectors and the deny-list match
+// replaced by placeholders.
+func (r *redactor) redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, d := range redactionDetectors {
+		s = r.replace(s, d.kind, d.re, d.group)
+	}
+	for _, term := range r.terms {
+		s = r.replace(s, "term", termPattern(term), 0)
+	}
+	return s
+}
+
+// termPattern matches term case-insensitively as a whole word. Word
+// boundaries only apply at ends that are word characters, so terms like
+// "C++" still match.
+func termPattern(term string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(term)
+	if isWordByte(term[0]) {
+		pattern = `\b` + pattern
+	}
+	if isWordByte(term[len(term)-1]) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile("(?i)" + pattern)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// restore puts the original values back in place of the placeholders in s.
+// Placeholders the model made up are left as they are.
+func (r *redactor) restore(s string) string {
+	if r == nil || len(r.items) == 0 {
+		return s
+	}
+	return placeholderPattern.ReplaceAllStringFunc(s, func(p string) string {
+		if red, ok := r.byName[strings.ToUpper(p)]; ok {
+			return red.Value
+		}
+		return p
+	})
+}
+
+func (r *redactor) restoreAll(items []string) {
+	for i := range items {
+		items[i] = r.restore(items[i])
+	}
+}
+
+// restoreResult restores every text field of an extraction, custom fields
+// included, and attaches the report.
+func (r *redactor) restoreResult(res *ExtractionResult) {
+	if r == nil || len(r.items) == 0 {
+		return
+	}
+	res.Summary = r.restore(res.Summary)
+	res.MoraleRationale = r.restore(res.MoraleRationale)
+	res.GrowthRationale = r.restore(res.GrowthRationale)
+	for _, items := range [][]string{res.ActionItemsMine, res.ActionItemsTheirs, res.NotableQuotes, res.Blockers, res.Wins} {
+		r.restoreAll(items)
+	}
+	for name, v := range res.CustomFields {
+		switch v := v.(type) {
+		case string:
+			res.CustomFields[name] = r.restore(v)
+		case []string:
+			r.restoreAll(v)
+		case []any:
+			for i, item := range v {
+				if s, ok := item.(string); ok {
+					v[i] = r.restore(s)
+				}
+			}
+		}
+	}
+	res.Redactions = r.report()
+}
+
+func (r *redactor) report() []Redaction {
+	out := make([]Redaction, len(r.items))
+	for i, red := range r.items {
+		out[i] = *red
+	}
+	return out
+}
+
+// ─── Deny-list ──────────────────────────────────────────
+
+type RedactionTerm struct {
+	ID        string `json:"id"`
+	Term      string `json:"term"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (a *App) loadRedactionTerms() ([]RedactionTerm, error) {
+	rows, err := a.db.Query("SELECT id, term, created_at FROM redaction_terms ORDER BY term COLLATE NOCASE")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	terms := []RedactionTerm{}
+	for rows.Next() {
+		var t RedactionTerm
+		if err := rows.Scan(&t.ID, &t.Term, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+	return terms, rows.Err()
+}
+
+// redactionTermList is the deny-list longest first, so "Dana Kim" is
+// replaced as a whole before "Dana" can match part of it.
+func (a *App) redactionTermList() []string {
+	if a.db == nil {
+		return nil
+	}
+	terms, err := a.loadRedactionTerms()
+	if err != nil {
+		a.log.Printf("Failed to load redaction terms: %v", err)
+		return nil
+	}
+	list := make([]string, len(terms))
+	for i, t := range terms {
+		list[i] = t.Term
+	}
+	sort.SliceStable(list, func(i, j int) bool { return len(list[i]) > len(list[j]) })
+	return list
+}
+
+func (a *App) handleGetRedactionTerms(w http.ResponseWriter, r *http.Request) {
+	terms, err := a.loadRedactionTerms()
+	if err != nil {
+		a.log.Printf("Failed to load redaction terms: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, terms)
+}
+
+func (a *App) handleCreateRedactionTerm(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Term string `json:"term"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return
+	}
+	term := strings.Join(strings.Fields(body.Term), " ")
+	if term == "" {
+		writeJSON(w, 400, map[string]string{"error": "term is required"})
+		return
+	}
+	var taken int
+	a.db.QueryRow("SELECT COUNT(*) FROM redaction_terms WHERE term = ?", term).Scan(&taken)
+	if taken > 0 {
+		writeJSON(w, 409, map[string]string{"error": fmt.Sprintf("%q is already on the deny-list", term)})
+		return
+	}
+
+	t := RedactionTerm{
+		ID:        fmt.Sprintf("term-%d", time.Now().UnixMilli()),
+		Term:      term,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if _, err := a.db.Exec("INSERT INTO redaction_terms (id, term, created_at) VALUES (?, ?, ?)", t.ID, t.Term, t.CreatedAt); err != nil {
+		a.log.Printf("Failed to create redaction term: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "failed to add term"})
+		return
+	}
+	a.clearExtractionCache()
+	writeJSON(w, 201, t)
+}
+
+func (a *App) handleDeleteRedactionTerm(w http.ResponseWriter, r *http.Request) {
+	res, err := a.db.Exec("DELETE FROM redaction_terms WHERE id = ?", r.PathValue("id"))
+	if err != nil {
+		a.log.Printf("Failed to delete redaction term: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "failed to delete term"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeJSON(w, 404, map[string]string{"error": "term not found"})
+		return
+	}
+	a.clearExtractionCache()
+	writeJSON(w, 200, map[string]bool{"deleted": true})
+}
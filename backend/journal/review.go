@@ -0,0 +1,301 @@
+package journal
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// A review document is one member's 1:1 history for a date range as a single
+// Markdown file, for pasting into a performance review tool. Sections are
+// plain headings, lists and tables so it also prints cleanly to PDF.
+
+type reviewOptions struct {
+	from, to       string
+	excludePrivate bool
+}
+
+// reviewDateRange turns from/to query values into bounds for RFC 3339 date
+// columns. A bare YYYY-MM-DD upper bound includes that whole day.
+func reviewDateRange(from, to string) (string, string) {
+	if len(to) == len("2006-01-02") {
+		to += "T23:59:59Z"
+	}
+	return from, to
+}
+
+// reviewLine flattens free text onto one line so it can't break list or
+// table structure.
+func reviewLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var reviewTableCell = strings.NewReplacer("|", "\\|")
+
+func reviewDate(date string) string {
+	if len(date) >= len("2006-01-02") {
+		return date[:len("2006-01-02")]
+	}
+	return date
+}
+
+// scoreTrend describes a score series as "3 → 4 → 4 (average 3.7)".
+func scoreTrend(points []ScorePoint) string {
+	if len(points) == 0 {
+		return "no scores recorded"
+	}
+	parts := make([]string, len(points))
+	sum := 0
+	for i, p := range points {
+		parts[i] = fmt.Sprint(p.Score)
+		sum += p.Score
+	}
+	return fmt.Sprintf("%s (average %.1f)", strings.Join(parts, " → "), float64(sum)/float64(len(points)))
+}
+
+type completedActionItem struct {
+	text, owner, entryDate string
+	completedAt            sql.NullString
+}
+
+func (a *App) loadReviewActionItems(memberID string, opts reviewOptions) ([]completedActionItem, error) {
+	where := []string{"a.member_id = ?", "a.status = 'done'"}
+	args := []any{memberID}
+	from, to := reviewDateRange(opts.from, opts.to)
+	if from != "" {
+		where = append(where, "COALESCE(a.completed_at, e.date) >= ?")
+		args = append(args, from)
+	}
+	if to != "" {
+		where = append(where, "COALESCE(a.completed_at, e.date) <= ?")
+		args = append(args, to)
+	}
+
+	rows, err := a.db.Query(`
+		SELECT a.text, a.owner, e.date, a.completed_at
+		FROM action_items a
+		JOIN entries e ON e.id = a.entry_id AND e.deleted_at IS NULL
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY COALESCE(a.completed_at, e.date), a.position`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []completedActionItem
+	for rows.Next() {
+		var item completedActionItem
+		if err := rows.Scan(&item.text, &item.owner, &item.entryDate, &item.completedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (a *App) loadReviewEntries(memberID string, opts reviewOptions) ([]Entry, error) {
+	where := "WHERE deleted_at IS NULL AND member_id = ?"
+	args := []any{memberID}
+	from, to := reviewDateRange(opts.from, opts.to)
+	if from != "" {
+		where += " AND date >= ?"
+		args = append(args, from)
+	}
+	if to != "" {
+		where += " AND date <= ?"
+		args = append(args, to)
+	}
+
+	rows, err := a.db.Query(fmt.Sprintf("SELECT %s FROM entries %s ORDER BY date", entryCols, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		e, err := a.scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// buildReviewMarkdown renders the review document. entries must be oldest
+// first.
+func buildReviewMarkdown(m TeamMember, entries []Entry, completed []completedActionItem, opts reviewOptions) string {
+	var sb strings.Builder
+	w := func(format string, args ...any) { fmt.Fprintf(&sb, format, args...) }
+
+	period := "All time"
+	switch {
+	case opts.from != "" && opts.to != "":
+		period = opts.from + " to " + opts.to
+	case opts.from != "":
+		period = "Since " + opts.from
+	case opts.to != "":
+		period = "Up to " + opts.to
+	}
+	w("# %s — 1:1 history\n\n", reviewLine(m.Name))
+	count := fmt.Sprintf("%d entries", len(entries))
+	if len(entries) == 1 {
+		count = "1 entry"
+	}
+	w("%s · %s · %s · generated %s\n\n", reviewLine(m.Role), period, count, time.Now().Format("2006-01-02"))
+
+	if len(entries) == 0 {
+		w("No 1:1 entries in this period.\n")
+		return sb.String()
+	}
+
+	tags, _, morale, growth := computeStructuredPrep(entries)
+
+	w("## Morale and growth\n\n")
+	w("- **Morale:** %s\n", scoreTrend(morale))
+	w("- **Growth:** %s\n\n", scoreTrend(growth))
+	w("| Date | Morale | Growth | Notes |\n|---|---|---|---|\n")
+	for _, e := range entries {
+		score := func(s *int) string {
+			if s == nil {
+				return "–"
+			}
+			return fmt.Sprint(*s)
+		}
+		var notes []string
+		if e.MoraleRationale != nil && *e.MoraleRationale != "" {
+			notes = append(notes, reviewLine(*e.MoraleRationale))
+		}
+		if e.GrowthRationale != nil && *e.GrowthRationale != "" {
+			notes = append(notes, reviewLine(*e.GrowthRationale))
+		}
+		w("| %s | %s | %s | %s |\n", reviewDate(e.Date), score(e.MoraleScore), score(e.GrowthScore),
+			reviewTableCell.Replace(strings.Join(notes, " ")))
+	}
+	w("\n")
+
+	if len(tags) > 0 {
+		parts := make([]string, len(tags))
+		for i, t := range tags {
+			parts[i] = fmt.Sprintf("%s (%d)", t.Tag, t.Count)
+		}
+		w("**Recurring topics:** %s\n\n", strings.Join(parts, ", "))
+	}
+
+	list := func(title string, pick func(Entry) []string, quote bool) {
+		w("## %s\n\n", title)
+		n := 0
+		for _, e := range entries {
+			for _, item := range pick(e) {
+				if item = reviewLine(item); item == "" {
+					continue
+				}
+				if quote {
+					w("> %s\n>\n> — %s\n\n", item, reviewDate(e.Date))
+				} else {
+					w("- %s _(%s)_\n", item, reviewDate(e.Date))
+				}
+				n++
+			}
+		}
+		if n == 0 {
+			w("None recorded.\n")
+		}
+		if n == 0 || !quote {
+			w("\n")
+		}
+	}
+	list("Wins", func(e Entry) []string { return e.Wins }, false)
+	list("Blockers", func(e Entry) []string { return e.Blockers }, false)
+	list("Notable quotes", func(e Entry) []string { return e.NotableQuotes }, true)
+
+	w("## Completed action items\n\n")
+	if len(completed) == 0 {
+		w("None recorded.\n")
+	}
+	for _, a := range completed {
+		who := "Manager"
+		if a.owner == ownerMember {
+			who = reviewLine(m.Name)
+		}
+		when := reviewDate(a.entryDate)
+		if a.completedAt.Valid {
+			when = reviewDate(a.completedAt.String)
+		}
+		w("- %s — %s _(done %s, from the 1:1 on %s)_\n", reviewLine(a.text), who, when, reviewDate(a.entryDate))
+	}
+	w("\n")
+
+	w("## 1:1 summaries\n\n")
+	for _, e := range entries {
+		w("### %s\n\n", reviewDate(e.Date))
+		if e.Summary != nil && *e.Summary != "" {
+			w("%s\n\n", strings.TrimSpace(*e.Summary))
+		}
+		if len(e.Tags) > 0 {
+			w("_Topics: %s_\n\n", strings.Join(e.Tags, ", "))
+		}
+		if opts.excludePrivate {
+			continue
+		}
+		if e.PrivateNote != nil && strings.TrimSpace(*e.PrivateNote) != "" {
+			w("**Private note:** %s\n\n", strings.TrimSpace(*e.PrivateNote))
+		}
+		if e.Transcript != nil && strings.TrimSpace(*e.Transcript) != "" {
+			fence := "```"
+			for strings.Contains(*e.Transcript, fence) {
+				fence += "`"
+			}
+			w("**Transcript:**\n\n%s\n%s\n%s\n\n", fence, strings.TrimSpace(*e.Transcript), fence)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// handleReviewExport renders one member's history as Markdown. Query:
+// from, to (YYYY-MM-DD, inclusive) and exclude_private=true to leave out
+// private notes and transcripts.
+func (a *App) handleReviewExport(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	q := r.URL.Query()
+	opts := reviewOptions{
+		from:           q.Get("from"),
+		to:             q.Get("to"),
+		excludePrivate: q.Get("exclude_private") == "true" || q.Get("exclude_private") == "1",
+	}
+
+	m, err := scanTeamMember(a.db.QueryRow(
+		"SELECT id, name, role, color, jira_account_id, prep_notes FROM team_members WHERE id = ? AND deleted_at IS NULL", id))
+	if err != nil {
+		writeJSON(w, 404, map[string]string{"error": "member not found"})
+		return
+	}
+
+	entries, err := a.loadReviewEntries(id, opts)
+	if err != nil {
+		a.log.Printf("Failed to load entries for review export of %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	completed, err := a.loadReviewActionItems(id, opts)
+	if err != nil {
+		a.log.Printf("Failed to load action items for review export of %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+
+	filename := unsafeFilename.ReplaceAllString(strings.ToLower(m.Name), "-") + "-review"
+	if opts.from != "" || opts.to != "" {
+		filename += "-" + unsafeFilename.ReplaceAllString(opts.from+"_"+opts.to, "")
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.md"`, filename))
+	w.WriteHeader(200)
+	w.Write([]byte(buildReviewMarkdown(m, entries, completed, opts)))
+}
@@ -0,0 +1,106 @@
+package journal
+
+import (
+	"strings"
+	"testing"
+)
+
+// wantMarkdown checks a review document for lines that must and must not
+// appear.
+func wantMarkdown(has []string, lacks ...string) checkFunc {
+	return func(t *testing.T, s *testServer, got any) {
+		t.Helper()
+		doc, _ := got.(string)
+		for _, sub := range has {
+			if !strings.Contains(doc, sub) {
+				t.Errorf("review is missing %q:\n%s", sub, doc)
+			}
+		}
+		for _, sub := range lacks {
+			if strings.Contains(doc, sub) {
+				t.Errorf("review contains %q:\n%s", sub, doc)
+			}
+		}
+	}
+}
+
+func TestReviewExport(t *testing.T) {
+	s := newTestServer(t)
+	s.run(t, []apiStep{
+		{name: "create member", method: "POST", path: "/api/team", body: map[string]string{"name": "Sam Lee", "role": "Engineer"}, status: 201, save: "sam"},
+		{name: "january", method: "POST", path: "/api/entries", status: 201,
+			body: map[string]any{
+				"member_id": "$sam", "date": "2024-01-15T15:00:00Z", "summary": "January check-in.", "morale_score": 3,
+				"wins": []string{"Shipped search"}, "private_note": "Ask about the reorg.", "transcript": "Sam: January transcript.",
+				"action_items_mine": []map[string]any{{"text": "Send the promo rubric", "completed": true}},
+			}},
+		{name: "end of march", method: "POST", path: "/api/entries", status: 201,
+			body: map[string]any{
+				"member_id": "$sam", "date": "2024-03-31T18:00:00Z", "summary": "End of March.", "morale_score": 4,
+				"wins": []string{"Led the incident review"}, "private_note": "Promo case is strong.",
+				"action_items_theirs": []map[string]any{{"text": "Write the design doc", "completed": true}, {"text": "Plan Q2"}},
+			}},
+		{name: "april", method: "POST", path: "/api/entries", status: 201,
+			body: map[string]any{"member_id": "$sam", "date": "2024-04-01T09:00:00Z", "summary": "April.", "wins": []string{"Onboarded Jo"}}},
+	})
+	// Completing an item stamps today's date; pin them to known days.
+	for text, at := range map[string]string{"Send the promo rubric": "2024-03-02T10:00:00Z", "Write the design doc": "2024-04-03T09:00:00Z"} {
+		if _, err := s.app.db.Exec("UPDATE action_items SET completed_at = ? WHERE text = ?", at, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.run(t, []apiStep{
+		{name: "all time", method: "GET", path: "/api/team/$sam/review", status: 200,
+			check: wantMarkdown([]string{
+				"# Sam Lee — 1:1 history", "Engineer · All time · 3 entries",
+				"- **Morale:** 3 → 4 (average 3.5)",
+				"- Shipped search _(2024-01-15)_", "- Onboarded Jo _(2024-04-01)_",
+				"- Send the promo rubric — Manager _(done 2024-03-02, from the 1:1 on 2024-01-15)_",
+				"- Write the design doc — Sam Lee _(done 2024-04-03, from the 1:1 on 2024-03-31)_",
+				"**Private note:** Ask about the reorg.", "**Transcript:**\n\n```\nSam: January transcript.\n```",
+			}, "Plan Q2")},
+		{name: "range includes the whole to day", method: "GET", path: "/api/team/$sam/review?from=2024-02-01&to=2024-03-31", status: 200,
+			check: wantMarkdown([]string{
+				"2024-02-01 to 2024-03-31 · 1 entry", "### 2024-03-31", "- Led the incident review _(2024-03-31)_",
+				// Completed in range, from an earlier 1:1.
+				"- Send the promo rubric — Manager _(done 2024-03-02",
+			}, "January check-in.", "April.", "Write the design doc")},
+		{name: "from only", method: "GET", path: "/api/team/$sam/review?from=2024-04-01", status: 200,
+			check: wantMarkdown([]string{"Since 2024-04-01 · 1 entry", "### 2024-04-01", "- Write the design doc — Sam Lee _(done 2024-04-03"},
+				"End of March.", "Send the promo rubric")},
+		{name: "to only", method: "GET", path: "/api/team/$sam/review?to=2024-03-01", status: 200,
+			check: wantMarkdown([]string{"Up to 2024-03-01 · 1 entry", "### 2024-01-15", "## Completed action items\n\nNone recorded."},
+				"End of March.", "Send the promo rubric")},
+		{name: "empty range", method: "GET", path: "/api/team/$sam/review?from=2025-01-01", status: 200,
+			check: wantMarkdown([]string{"0 entries", "No 1:1 entries in this period."}, "## Wins")},
+		{name: "exclude private", method: "GET", path: "/api/team/$sam/review?exclude_private=true", status: 200,
+			check: wantMarkdown([]string{"January check-in.", "End of March."},
+				"Private note", "Ask about the reorg.", "Promo case is strong.", "Transcript", "January transcript.")},
+		{name: "unknown member", method: "GET", path: "/api/team/member-missing/review", status: 404},
+	})
+
+	status, _ := s.do(t, "DELETE", "/api/team/$sam", nil)
+	if status != 200 {
+		t.Fatalf("delete member = %d", status)
+	}
+	s.run(t, []apiStep{
+		{name: "trashed member", method: "GET", path: "/api/team/$sam/review", status: 404},
+	})
+}
+
+func TestReviewExportHeaders(t *testing.T) {
+	s := newTestServer(t)
+	seedEntry(t, s)
+	resp, err := s.Client().Get(s.URL + "/api/team/member-1/review?from=2024-05-01&to=2024-05-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/markdown; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != `attachment; filename="sam-review-2024-05-01_2024-05-31.md"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+}
@@ -0,0 +1,282 @@
+package journal
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Every change to an entry stores a full snapshot of the entry as it was
+// before the change in entry_revisions, along with the fields the change
+// touched. Revisions are numbered per entry starting at 1, so revision N is
+// the entry as it stood before its Nth edit.
+
+type EntryRevision struct {
+	EntryID       string   `json:"entry_id"`
+	Revision      int      `json:"revision"`
+	Reason        string   `json:"reason"`
+	ChangedFields []string `json:"changed_fields"`
+	CreatedAt     string   `json:"created_at"`
+	Snapshot      *Entry   `json:"snapshot,omitempty"`
+}
+
+type FieldDiff struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// revisionIgnoredFields aren't user content and don't count as changes.
+var revisionIgnoredFields = map[string]bool{
+	"id": true, "member_id": true, "created_at": true, "updated_at": true,
+}
+
+// entryFields flattens an entry into its JSON fields for diffing.
+func entryFields(e Entry) map[string]json.RawMessage {
+	b, _ := json.Marshal(e)
+	var m map[string]json.RawMessage
+	json.Unmarshal(b, &m)
+	return m
+}
+
+// diffEntries lists the user-editable fields that differ between a and b,
+// in entryColumns order.
+func diffEntries(a, b Entry) []FieldDiff {
+	fa, fb := entryFields(a), entryFields(b)
+	diffs := []FieldDiff{}
+	for _, col := range entryColumns {
+		if revisionIgnoredFields[col] {
+			continue
+		}
+		if !bytes.Equal(fa[col], fb[col]) {
+			diffs = append(diffs, FieldDiff{Field: col, From: fa[col], To: fb[col]})
+		}
+	}
+	return diffs
+}
+
+func (a *App) readEntry(tx dbtx, id string) (Entry, error) {
+	return a.scanEntry(tx.QueryRow(fmt.Sprintf("SELECT %s FROM entries WHERE id = ? AND deleted_at IS NULL", entryCols), id))
+}
+
+// recordRevision stores before as a new revision of its entry if the entry
+// has changed since. Call it inside the transaction that made the change.
+func (a *App) recordRevision(tx dbtx, before Entry, reason string) error {
+	after, err := a.readEntry(tx, before.ID)
+	if err != nil {
+		return err
+	}
+	diffs := diffEntries(before, after)
+	if len(diffs) == 0 {
+		return nil
+	}
+	changed := make([]string, len(diffs))
+	for i, d := range diffs {
+		changed[i] = d.Field
+	}
+
+	snapshot, _ := json.Marshal(before)
+	_, err = tx.Exec(`
+		INSERT INTO entry_revisions (entry_id, revision, reason, changed_fields, snapshot, created_at)
+		VALUES (?, (SELECT COALESCE(MAX(revision), 0) + 1 FROM entry_revisions WHERE entry_id = ?), ?, ?, ?, ?)`,
+		before.ID, before.ID, reason, jsonStringify(changed), a.encryptField(string(snapshot)), time.Now().UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+func (a *App) scanRevision(row interface{ Scan(...any) error }, withSnapshot bool) (EntryRevision, error) {
+	var rev EntryRevision
+	var changed, snapshot string
+	if err := row.Scan(&rev.EntryID, &rev.Revision, &rev.Reason, &changed, &snapshot, &rev.CreatedAt); err != nil {
+		return rev, err
+	}
+	rev.ChangedFields = parseJSONArray(changed)
+	if withSnapshot {
+		plain, err := a.decryptField(snapshot)
+		if err != nil {
+			return rev, fmt.Errorf("snapshot for %s revision %d: %w", rev.EntryID, rev.Revision, err)
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(plain), &e); err != nil {
+			return rev, fmt.Errorf("corrupt snapshot for %s revision %d: %w", rev.EntryID, rev.Revision, err)
+		}
+		rev.Snapshot = &e
+	}
+	return rev, nil
+}
+
+const revisionCols = "entry_id, revision, reason, changed_fields, snapshot, created_at"
+
+func (a *App) getRevision(tx dbtx, entryID string, revision int) (EntryRevision, error) {
+	return a.scanRevision(tx.QueryRow(
+		`SELECT `+revisionCols+` FROM entry_revisions
+		WHERE entry_id = ? AND revision = ? AND entry_id IN (SELECT id FROM entries WHERE deleted_at IS NULL)`,
+		entryID, revision,
+	), true)
+}
+
+// ─── Handlers ───────────────────────────────────────────
+
+func (a *App) handleGetRevisions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	rows, err := a.db.Query(`
+		SELECT `+revisionCols+` FROM entry_revisions
+		WHERE entry_id = ? AND entry_id IN (SELECT id FROM entries WHERE deleted_at IS NULL)
+		ORDER BY revision DESC`, id)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer rows.Close()
+
+	revisions := []EntryRevision{}
+	for rows.Next() {
+		rev, err := a.scanRevision(rows, false)
+		if err != nil {
+			a.log.Printf("Failed to scan revision: %v", err)
+			continue
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, revisions)
+}
+
+func (a *App) handleGetRevision(w http.ResponseWriter, r *http.Request) {
+	revision, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid revision"})
+		return
+	}
+	rev, err := a.getRevision(a.db, r.PathValue("id"), revision)
+	if err == sql.ErrNoRows {
+		writeJSON(w, 404, map[string]string{"error": "revision not found"})
+		return
+	} else if err != nil {
+		a.log.Printf("Failed to read revision: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, rev)
+}
+
+// resolveRevision returns the entry as of a revision number, or the current
+// entry for "current" or an empty string.
+func (a *App) resolveRevision(entryID, ref string) (Entry, error) {
+	if ref == "" || ref == "current" {
+		return a.readEntry(a.db, entryID)
+	}
+	n, err := strconv.Atoi(ref)
+	if err != nil {
+		return Entry{}, sql.ErrNoRows
+	}
+	rev, err := a.getRevision(a.db, entryID, n)
+	if err != nil {
+		return Entry{}, err
+	}
+	return *rev.Snapshot, nil
+}
+
+// handleDiffRevisions compares two versions of an entry. from and to are
+// revision numbers or "current"; to defaults to the current entry.
+func (a *App) handleDiffRevisions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	q := r.URL.Query()
+	if q.Get("from") == "" {
+		writeJSON(w, 400, map[string]string{"error": "from is required"})
+		return
+	}
+
+	from, err := a.resolveRevision(id, q.Get("from"))
+	if err != nil {
+		writeJSON(w, 404, map[string]string{"error": "from revision not found"})
+		return
+	}
+	to, err := a.resolveRevision(id, q.Get("to"))
+	if err != nil {
+		writeJSON(w, 404, map[string]string{"error": "to revision not found"})
+		return
+	}
+	writeJSON(w, 200, diffEntries(from, to))
+}
+
+// handleRestoreRevision puts an entry back to a previous revision. The state
+// being replaced is itself recorded, so a restore can be undone.
+func (a *App) handleRestoreRevision(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	revision, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid revision"})
+		return
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+
+	current, err := a.readEntry(tx, id)
+	if err != nil {
+		writeJSON(w, 404, map[string]string{"error": "entry not found"})
+		return
+	}
+	rev, err := a.getRevision(tx, id, revision)
+	if err != nil {
+		writeJSON(w, 404, map[string]string{"error": "revision not found"})
+		return
+	}
+	snap := rev.Snapshot
+
+	if _, err := tx.Exec(`
+		UPDATE entries SET summary = ?, morale_score = ?, growth_score = ?,
+			morale_rationale = ?, growth_rationale = ?,
+			tags = ?, notable_quotes = ?, blockers = ?, wins = ?,
+			private_note = ?, transcript = ?, custom_fields = ?, updated_at = ?
+		WHERE id = ?`,
+		snap.Summary, snap.MoraleScore, snap.GrowthScore,
+		snap.MoraleRationale, snap.GrowthRationale,
+		jsonStringify(snap.Tags), jsonStringify(snap.NotableQuotes), jsonStringify(snap.Blockers), jsonStringify(snap.Wins),
+		a.encryptPtr(snap.PrivateNote), a.encryptPtr(snap.Transcript), customValuesJSON(snap.CustomFields), time.Now().UTC().Format(time.RFC3339),
+		id,
+	); err != nil {
+		a.log.Printf("Failed to restore entry %s to revision %d: %v", id, revision, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to restore revision"})
+		return
+	}
+	if err := syncActionItems(tx, id, ownerManager, snap.ActionItemsMine); err != nil {
+		a.log.Printf("Failed to restore action items for entry %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to restore revision"})
+		return
+	}
+	if err := syncActionItems(tx, id, ownerMember, snap.ActionItemsTheirs); err != nil {
+		a.log.Printf("Failed to restore action items for entry %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to restore revision"})
+		return
+	}
+	if err := a.recordRevision(tx, current, fmt.Sprintf("restore %d", revision)); err != nil {
+		a.log.Printf("Failed to record revision for entry %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to restore revision"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+
+	restored, err := a.readEntry(a.db, id)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "failed to read restored entry"})
+		return
+	}
+	writeJSON(w, 200, restored)
+}
@@ -0,0 +1,139 @@
+package journal
+
+import (
+	"fmt"
+	"testing"
+)
+
+// wantDiffFields checks which fields a revision diff lists, in order.
+func wantDiffFields(fields ...string) checkFunc {
+	return func(t *testing.T, s *testServer, got any) {
+		diffs, _ := got.([]any)
+		var names []string
+		for _, d := range diffs {
+			names = append(names, fmt.Sprint(field(d, "field")))
+		}
+		if fmt.Sprint(names) != fmt.Sprint(fields) {
+			t.Errorf("diff fields = %v, want %v", names, fields)
+		}
+	}
+}
+
+// wantActionItems checks the texts and completion of the action items at
+// keys, marking completed ones "(done)".
+func wantActionItems(want []string, keys ...string) checkFunc {
+	return func(t *testing.T, s *testServer, got any) {
+		items, _ := field(got, keys...).([]any)
+		var texts []string
+		for _, it := range items {
+			text := fmt.Sprint(field(it, "text"))
+			if field(it, "completed") == true {
+				text += " (done)"
+			}
+			texts = append(texts, text)
+		}
+		if fmt.Sprint(texts) != fmt.Sprint(want) {
+			t.Errorf("%v = %q, want %q", keys, texts, want)
+		}
+	}
+}
+
+func TestRevisionDiffAndRestore(t *testing.T) {
+	for _, encrypted := range []bool{false, true} {
+		t.Run(fmt.Sprintf("encrypted=%v", encrypted), func(t *testing.T) {
+			s := newTestServer(t, func(c *Config) {
+				if encrypted {
+					c.EncryptionKeyfile = writeKeyfile(t, 3)
+				}
+			})
+			s.run(t, []apiStep{
+				{name: "create member", method: "POST", path: "/api/team", body: map[string]string{"name": "Sam"}, status: 201, save: "sam"},
+				{name: "create entry", method: "POST", path: "/api/entries", status: 201, save: "entry",
+					body: map[string]any{
+						"member_id": "$sam", "date": "2024-05-01T15:00:00Z", "summary": "Talked about the launch.",
+						"morale_score": 3, "private_note": "Thinking about a team change.",
+						"action_items_mine": []map[string]any{{"text": "Share the rollout doc"}, {"text": "Book the offsite"}},
+					}},
+				{name: "no revisions yet", method: "GET", path: "/api/entries/$entry/revisions", status: 200, check: wantLen(0)},
+				{name: "unchanged edit", method: "PUT", path: "/api/entries/$entry", body: map[string]any{"summary": "Talked about the launch."}, status: 200},
+				{name: "unchanged edit isn't recorded", method: "GET", path: "/api/entries/$entry/revisions", status: 200, check: wantLen(0)},
+				{name: "first edit", method: "PUT", path: "/api/entries/$entry", status: 200,
+					body: map[string]any{"summary": "Launch went well.", "morale_score": 4, "private_note": "Decided to stay."}},
+				{name: "second edit", method: "PUT", path: "/api/entries/$entry", status: 200,
+					body:  map[string]any{"action_items_mine": []map[string]any{{"text": "Share the rollout doc", "completed": true}, {"text": "Write the promo case"}}},
+					check: wantActionItems([]string{"Share the rollout doc (done)", "Write the promo case"}, "action_items_mine")},
+				{name: "list newest first", method: "GET", path: "/api/entries/$entry/revisions", status: 200,
+					check: checks(wantLen(2), func(t *testing.T, s *testServer, got any) {
+						revs, _ := got.([]any)
+						if len(revs) != 2 {
+							return
+						}
+						if field(revs[0], "revision") != 2.0 || field(revs[0], "snapshot") != nil {
+							t.Errorf("first listed = %v, want revision 2 without a snapshot", revs[0])
+						}
+						if fmt.Sprint(field(revs[1], "changed_fields")) != "[summary morale_score private_note]" {
+							t.Errorf("revision 1 changed %v", field(revs[1], "changed_fields"))
+						}
+					})},
+				{name: "get revision 1", method: "GET", path: "/api/entries/$entry/revisions/1", status: 200,
+					check: checks(
+						wantField("Talked about the launch.", "snapshot", "summary"),
+						wantField("Thinking about a team change.", "snapshot", "private_note"),
+					)},
+				{name: "diff two revisions", method: "GET", path: "/api/entries/$entry/revisions/diff?from=1&to=2", status: 200,
+					check: wantDiffFields("summary", "morale_score", "private_note")},
+				{name: "diff against current", method: "GET", path: "/api/entries/$entry/revisions/diff?from=1", status: 200,
+					check: checks(
+						wantDiffFields("summary", "morale_score", "action_items_mine", "private_note"),
+						func(t *testing.T, s *testServer, got any) {
+							diffs, _ := got.([]any)
+							if len(diffs) > 0 && (field(diffs[0], "from") != "Talked about the launch." || field(diffs[0], "to") != "Launch went well.") {
+								t.Errorf("summary diff = %v", diffs[0])
+							}
+						},
+					)},
+				{name: "diff with itself", method: "GET", path: "/api/entries/$entry/revisions/diff?from=2&to=2", status: 200, check: wantLen(0)},
+				{name: "restore revision 1", method: "POST", path: "/api/entries/$entry/revisions/1/restore", status: 200,
+					check: checks(
+						wantField("Talked about the launch.", "summary"),
+						wantField(3.0, "morale_score"),
+						wantField("Thinking about a team change.", "private_note"),
+						wantActionItems([]string{"Share the rollout doc", "Book the offsite"}, "action_items_mine"),
+					)},
+				{name: "restore is persisted", method: "GET", path: "/api/entries/$entry", status: 200,
+					check: checks(
+						wantField("Talked about the launch.", "summary"),
+						wantField("Thinking about a team change.", "private_note"),
+						wantActionItems([]string{"Share the rollout doc", "Book the offsite"}, "action_items_mine"),
+					)},
+				{name: "team action items follow the restore", method: "GET", path: "/api/action-items?member_id=$sam", status: 200, check: wantLen(2)},
+				{name: "restore is recorded", method: "GET", path: "/api/entries/$entry/revisions", status: 200,
+					check: checks(wantLen(3), func(t *testing.T, s *testServer, got any) {
+						revs, _ := got.([]any)
+						if len(revs) > 0 && field(revs[0], "reason") != "restore 1" {
+							t.Errorf("reason = %v, want restore 1", field(revs[0], "reason"))
+						}
+					})},
+				{name: "restore can be undone", method: "GET", path: "/api/entries/$entry/revisions/3", status: 200,
+					check: checks(
+						wantField("Decided to stay.", "snapshot", "private_note"),
+						wantActionItems([]string{"Share the rollout doc (done)", "Write the promo case"}, "snapshot", "action_items_mine"),
+					)},
+				{name: "missing revision", method: "GET", path: "/api/entries/$entry/revisions/9", status: 404},
+				{name: "invalid revision", method: "GET", path: "/api/entries/$entry/revisions/latest", status: 400},
+				{name: "diff without from", method: "GET", path: "/api/entries/$entry/revisions/diff", status: 400},
+				{name: "diff from a missing revision", method: "GET", path: "/api/entries/$entry/revisions/diff?from=9", status: 404},
+				{name: "restore a missing revision", method: "POST", path: "/api/entries/$entry/revisions/9/restore", status: 404},
+				{name: "restore on a missing entry", method: "POST", path: "/api/entries/entry-missing/revisions/1/restore", status: 404},
+			})
+
+			if encrypted {
+				var snapshot string
+				s.app.db.QueryRow("SELECT snapshot FROM entry_revisions WHERE revision = 1").Scan(&snapshot)
+				if !isEncrypted(snapshot) {
+					t.Errorf("revision snapshot is stored unencrypted: %.40q", snapshot)
+				}
+			}
+		})
+	}
+}
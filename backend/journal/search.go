@@ -0,0 +1,164 @@
+package journal
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type SearchResult struct {
+	Entry
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+}
+
+// Snippet highlights are marked with control characters in SQL so the rest of
+// the snippet can be HTML-escaped before they become <mark> tags.
+const (
+	snippetOpen  = "\x02"
+	snippetClose = "\x03"
+)
+
+// ftsQuery turns free text into an FTS5 query that can't fail to parse: every
+// word is quoted and the words are ANDed together. "Quoted phrases" are kept
+// as phrases and a trailing * on a word makes it a prefix match.
+func ftsQuery(q string) string {
+	var terms []string
+	var cur strings.Builder
+	inPhrase := false
+
+	flush := func() {
+		term := strings.TrimSpace(cur.String())
+		cur.Reset()
+		prefix := !inPhrase && strings.HasSuffix(term, "*")
+		term = strings.Trim(term, "*")
+		if strings.IndexFunc(term, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			return
+		}
+		quoted := `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		if prefix {
+			quoted += "*"
+		}
+		terms = append(terms, quoted)
+	}
+
+	for _, r := range q {
+		switch {
+		case r == '"':
+			flush()
+			inPhrase = !inPhrase
+		case unicode.IsSpace(r) && !inPhrase:
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return strings.Join(terms, " ")
+}
+
+func (a *App) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	match := ftsQuery(q.Get("q"))
+	if match == "" {
+		writeJSON(w, 400, map[string]string{"error": "q is required"})
+		return
+	}
+
+	limit := 50
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 && v <= 200 {
+		limit = v
+	}
+
+	where := []string{"entries_fts MATCH ?", "e.deleted_at IS NULL"}
+	args := []any{snippetOpen, snippetClose, match}
+	if v := q.Get("member_id"); v != "" {
+		where = append(where, "e.member_id = ?")
+		args = append(args, v)
+	}
+	if v := q.Get("field"); v != "" {
+		if !a.customFieldExists(v) {
+			writeJSON(w, 400, map[string]string{"error": "unknown custom field " + strconv.Quote(v)})
+			return
+		}
+		where = append(where, entryHasField)
+		args = append(args, v)
+	}
+	if v := q.Get("tag"); v != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM json_each(e.tags) WHERE json_each.value = ?)")
+		args = append(args, v)
+	}
+	// Dates are RFC 3339 strings, so a bare YYYY-MM-DD compares correctly
+	// as a lower bound; the upper bound gets a suffix to include that day.
+	if v := q.Get("from"); v != "" {
+		where = append(where, "e.date >= ?")
+		args = append(args, v)
+	}
+	if v := q.Get("to"); v != "" {
+		if len(v) == len("2006-01-02") {
+			v += "T23:59:59Z"
+		}
+		where = append(where, "e.date <= ?")
+		args = append(args, v)
+	}
+	args = append(args, limit)
+
+	// bm25 weights follow the fts column order: summary, transcript,
+	// notable_quotes, blockers, wins, rationales, private_note, custom_fields.
+	rows, err := a.db.Query(`
+		SELECT `+entryColsAs("e")+`,
+			snippet(entries_fts, -1, ?, ?, '…', 16),
+			bm25(entries_fts, 5.0, 1.0, 3.0, 2.0, 2.0, 2.0, 2.0, 2.0) AS rank
+		FROM entries_fts
+		JOIN entries e ON e.rowid = entries_fts.rowid
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY rank
+		LIMIT ?`, args...)
+	if err != nil {
+		a.log.Printf("Search failed for %q: %v", match, err)
+		writeJSON(w, 500, map[string]string{"error": "search failed"})
+		return
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var res SearchResult
+		var snippet string
+		e, err := a.scanEntry(scanAppend(rows, &snippet, &res.Rank))
+		if err != nil {
+			a.log.Printf("Failed to scan search result: %v", err)
+			continue
+		}
+		res.Entry = e
+		res.Snippet = highlightSnippet(snippet)
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, results)
+}
+
+// highlightSnippet HTML-escapes an FTS snippet and turns the match markers
+// into <mark> tags, so it is safe to render as HTML.
+func highlightSnippet(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, snippetOpen, "<mark>")
+	return strings.ReplaceAll(s, snippetClose, "</mark>")
+}
+
+// scanAppend wraps a row so scanEntry's Scan call also fills extra trailing
+// columns selected after entryCols.
+func scanAppend(row interface{ Scan(...any) error }, extra ...any) interface{ Scan(...any) error } {
+	return scanFunc(func(dest ...any) error {
+		return row.Scan(append(dest, extra...)...)
+	})
+}
+
+type scanFunc func(dest ...any) error
+
+func (f scanFunc) Scan(dest ...any) error { return f(dest...) }
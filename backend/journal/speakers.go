@@ -0,0 +1,304 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"people-journal/transcript"
+)
+
+// Speaker labels map the names a transcript tool uses ("Dana Kim",
+// "Speaker 2") to who they are in a member's 1:1s: the manager, the member,
+// or someone else. They're saved per member so a mapping set once applies to
+// every later transcript with that person.
+const speakerOther = "other"
+
+type SpeakerLabel struct {
+	MemberID string `json:"member_id"`
+	Label    string `json:"label"`
+	Speaker  string `json:"speaker"`
+}
+
+// roleTags are how attributed turns are marked in the prompt, e.g.
+// "Sam Ortiz (report): ...".
+var roleTags = map[string]string{
+	ownerManager: "manager",
+	ownerMember:  "report",
+	speakerOther: "other",
+}
+
+func labelKey(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
+
+// validateSpeakerLabels checks a label → speaker mapping from a request.
+func validateSpeakerLabels(labels map[string]string) error {
+	for label, speaker := range labels {
+		if strings.TrimSpace(label) == "" {
+			return fmt.Errorf("speaker labels must not be empty")
+		}
+		if _, ok := roleTags[speaker]; !ok {
+			return fmt.Errorf("speaker for %q must be manager, member or other", label)
+		}
+	}
+	return nil
+}
+
+// loadSpeakerLabels returns a member's saved mapping, keyed by label as it
+// was saved.
+func loadSpeakerLabels(q dbtx, memberID string) (map[string]string, error) {
+	rows, err := q.Query("SELECT label, speaker FROM speaker_labels WHERE member_id = ? ORDER BY label", memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	labels := map[string]string{}
+	for rows.Next() {
+		var label, speaker string
+		if err := rows.Scan(&label, &speaker); err != nil {
+			return nil, err
+		}
+		labels[label] = speaker
+	}
+	return labels, rows.Err()
+}
+
+// saveSpeakerLabels adds or updates labels for a member. Labels match
+// case-insensitively, so "dana" replaces "Dana".
+func saveSpeakerLabels(q dbtx, memberID string, labels map[string]string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for label, speaker := range labels {
+		_, err := q.Exec(`
+			INSERT INTO speaker_labels (member_id, label, speaker, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (member_id, label) DO UPDATE SET label = excluded.label, speaker = excluded.speaker, updated_at = excluded.updated_at`,
+			memberID, strings.TrimSpace(label), speaker, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveSpeakers decides who each transcript speaker is. Known labels come
+// first; failing that, a label matching the member's full or first name is
+// the member. In a two-person transcript where one side is known, the other
+// side is the other person in the 1:1. Speakers that can't be placed are
+// left out.
+func resolveSpeakers(speakers []string, memberName string, known map[string]string) map[string]string {
+	byKey := map[string]string{}
+	for label, speaker := range known {
+		byKey[labelKey(label)] = speaker
+	}
+
+	roles := map[string]string{}
+	for _, s := range speakers {
+		if speaker, ok := byKey[labelKey(s)]; ok {
+			roles[s] = speaker
+		} else if namesMatch(s, memberName) {
+			roles[s] = ownerMember
+		}
+	}
+
+	if len(speakers) == 2 && len(roles) == 1 {
+		a, b := speakers[0], speakers[1]
+		if _, ok := roles[a]; !ok {
+			a, b = b, a
+		}
+		switch roles[a] {
+		case ownerMember:
+			roles[b] = ownerManager
+		case ownerManager:
+			roles[b] = ownerMember
+		}
+	}
+	return roles
+}
+
+// namesMatch reports whether a speaker label names the member: the same full
+// name, or the same first name when either side only has one.
+func namesMatch(label, name string) bool {
+	l, n := strings.Fields(labelKey(label)), strings.Fields(labelKey(name))
+	if len(l) == 0 || len(n) == 0 {
+		return false
+	}
+	if strings.Join(l, " ") == strings.Join(n, " ") {
+		return true
+	}
+	return (len(l) == 1 || len(n) == 1) && l[0] == n[0]
+}
+
+// attribution is a transcript rewritten with each turn marked by who is
+// speaking. reportTurns and otherTurns hold the text the member said and
+// everything anyone else said, for checking quotes afterwards.
+type attribution struct {
+	transcript  string
+	roles       map[string]string
+	reportTurns []string
+	otherTurns  []string
+}
+
+// attributeTranscript parses text into speaker turns and, if any turn can be
+// attributed to the member, renders it with role tags. Otherwise the text is
+// returned unchanged and reportTurns is empty.
+func attributeTranscript(text, memberName string, known map[string]string) attribution {
+	a := attribution{transcript: text}
+	parsed, err := transcript.Parse("", []byte(text))
+	if err != nil {
+		return a
+	}
+	a.roles = resolveSpeakers(parsed.Speakers(), memberName, known)
+
+	var hasReport bool
+	for _, speaker := range a.roles {
+		hasReport = hasReport || speaker == ownerMember
+	}
+	if !hasReport {
+		return a
+	}
+
+	parts := make([]string, len(parsed.Turns))
+	for i, t := range parsed.Turns {
+		speaker, ok := a.roles[t.Speaker]
+		switch {
+		case ok:
+			parts[i] = fmt.Sprintf("%s (%s): %s", t.Speaker, roleTags[speaker], t.Text)
+		case t.Speaker != "":
+			parts[i] = t.Speaker + ": " + t.Text
+		default:
+			parts[i] = t.Text
+		}
+		if speaker == ownerMember {
+			a.reportTurns = append(a.reportTurns, t.Text)
+		} else {
+			a.otherTurns = append(a.otherTurns, t.Text)
+		}
+	}
+	a.transcript = strings.Join(parts, "\n\n")
+	return a
+}
+
+// ─── Quote checking ─────────────────────────────────────
+
+func quoteWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// quoteOverlap is the share of the quote's words that appear in turns, and
+// whether the quote appears in them word for word.
+func quoteOverlap(quote string, turns []string) (float64, bool) {
+	words := quoteWords(quote)
+	if len(words) == 0 {
+		return 0, false
+	}
+	text := " " + strings.Join(quoteWords(strings.Join(turns, " ")), " ") + " "
+	if strings.Contains(text, " "+strings.Join(words, " ")+" ") {
+		return 1, true
+	}
+	found := 0
+	for _, w := range words {
+		if strings.Contains(text, " "+w+" ") {
+			found++
+		}
+	}
+	return float64(found) / float64(len(words)), false
+}
+
+// minQuoteOverlap is how much of a paraphrased quote has to come from the
+// member's own turns for it to be kept.
+const minQuoteOverlap = 0.6
+
+// keepReportQuotes drops notable quotes that weren't said by the member: ones
+// found word for word in someone else's turns, and ones that match the
+// member's turns less well than everyone else's.
+func keepReportQuotes(result *ExtractionResult, a attribution) {
+	if len(a.reportTurns) == 0 {
+		return
+	}
+	kept := []string{}
+	for _, q := range result.NotableQuotes {
+		report, exact := quoteOverlap(q, a.reportTurns)
+		other, otherExact := quoteOverlap(q, a.otherTurns)
+		if exact || (!otherExact && report >= minQuoteOverlap && report >= other) {
+			kept = append(kept, q)
+			continue
+		}
+		result.Corrections = append(result.Corrections, FieldCorrection{
+			Field:   "notable_quotes",
+			Problem: fmt.Sprintf("dropped %q: not said by the report", q),
+			FixedBy: "validator",
+		})
+	}
+	result.NotableQuotes = kept
+}
+
+// ─── Handlers ───────────────────────────────────────────
+
+func (a *App) handleGetSpeakerLabels(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !a.memberExists(id) {
+		writeJSON(w, 404, map[string]string{"error": "member not found"})
+		return
+	}
+	labels, err := loadSpeakerLabels(a.db, id)
+	if err != nil {
+		a.log.Printf("Failed to load speaker labels for %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"labels": labels})
+}
+
+// handlePutSpeakerLabels replaces a member's saved speaker labels.
+func (a *App) handlePutSpeakerLabels(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var body struct {
+		Labels map[string]string `json:"labels"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return
+	}
+	if err := validateSpeakerLabels(body.Labels); err != nil {
+		writeJSON(w, 400, map[string]string{"error": err.Error()})
+		return
+	}
+	if !a.memberExists(id) {
+		writeJSON(w, 404, map[string]string{"error": "member not found"})
+		return
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM speaker_labels WHERE member_id = ?", id); err != nil {
+		a.log.Printf("Failed to clear speaker labels for %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to save speaker labels"})
+		return
+	}
+	if err := saveSpeakerLabels(tx, id, body.Labels); err != nil {
+		a.log.Printf("Failed to save speaker labels for %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to save speaker labels"})
+		return
+	}
+	labels, err := loadSpeakerLabels(tx, id)
+	if err != nil || tx.Commit() != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"labels": labels})
+}
+
+func (a *App) memberExists(id string) bool {
+	var n int
+	a.db.QueryRow("SELECT COUNT(*) FROM team_members WHERE id = ? AND deleted_at IS NULL", id).Scan(&n)
+	return n > 0
+}
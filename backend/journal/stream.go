@@ -0,0 +1,131 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ─── Streaming Providers ────────────────────────────────
+
+// StreamingProvider is implemented by providers that can relay text as the
+// model produces it. onDelta is called for every chunk; returning an error
+// from it aborts the stream. The full text is returned once the model is done.
+type StreamingProvider interface {
+	Provider
+	Stream(req CompletionRequest, onDelta func(string) error) (string, error)
+}
+
+// streamCompletion streams from p when it supports it, and otherwise falls
+// back to a blocking call delivered as a single delta.
+func streamCompletion(p Provider, req CompletionRequest, onDelta func(string) error) (string, error) {
+	if sp, ok := p.(StreamingProvider); ok {
+		return sp.Stream(req, onDelta)
+	}
+	text, err := p.Complete(req)
+	if err != nil {
+		return "", err
+	}
+	if err := onDelta(text); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// Stream follows the same fallback rules as Complete, but only while nothing
+// has been relayed yet — once a provider has produced tokens, switching to
+// another one would splice two different answers together.
+func (f *fallbackProvider) Stream(req CompletionRequest, onDelta func(string) error) (string, error) {
+	var err error
+	for i, p := range f.chain {
+		if i > 0 {
+			req.Model = ""
+		}
+		emitted := false
+		var text string
+		text, err = streamCompletion(p, req, func(s string) error {
+			emitted = true
+			return onDelta(s)
+		})
+		if err == nil {
+			return text, nil
+		}
+		if emitted || !shouldFallback(err) {
+			return "", err
+		}
+		f.log.Printf("[AI] %s stream failed (%v), trying next provider", p.Name(), err)
+	}
+	return "", err
+}
+
+// readSSEData calls fn with the payload of every "data:" line in an
+// event stream until the body ends or fn returns an error.
+func readSSEData(r io.Reader, fn func(data string) error) error {
+	return readLines(r, func(line string) error {
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			return nil
+		}
+		return fn(strings.TrimSpace(data))
+	})
+}
+
+func readLines(r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ─── SSE Writer ─────────────────────────────────────────
+
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// newSSEWriter sends the event-stream headers. It fails when the underlying
+// ResponseWriter cannot flush, since the client would then see nothing until
+// the very end.
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming unsupported")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(200)
+	flusher.Flush()
+	return &sseWriter{w: w, flusher: flusher}, nil
+}
+
+// send writes one event with v encoded as JSON.
+func (s *sseWriter) send(event string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// token relays a chunk of model output.
+func (s *sseWriter) token(text string) error {
+	return s.send("token", map[string]string{"text": text})
+}
+
+// fail reports an error to the client; the stream ends after it.
+func (s *sseWriter) fail(msg string) {
+	s.send("error", map[string]string{"error": msg})
+}
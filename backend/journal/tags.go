@@ -0,0 +1,372 @@
+package journal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The tag taxonomy lives in the tags table. Extraction offers the model the
+// active (non-archived) tags only; archived tags stay on the entries that
+// already have them. Tag names are unique regardless of case.
+
+// defaultTags seeds the tags table, and stands in for it before the
+// database is open.
+var defaultTags = []string{
+	"career growth", "blockers", "wins", "feedback given", "feedback received",
+	"cross-team", "technical debt", "hiring", "process", "personal", "morale",
+	"autonomy", "project update", "conflict", "learning",
+}
+
+type Tag struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	Archived    bool   `json:"archived"`
+	EntryCount  int    `json:"entry_count"`
+}
+
+// entryHasTag matches entries (aliased e) carrying the tag bound to the
+// placeholder, ignoring case.
+const entryHasTag = "EXISTS (SELECT 1 FROM json_each(e.tags) WHERE json_each.value = ? COLLATE NOCASE)"
+
+const tagSelect = `
+	SELECT t.id, t.name, t.description, t.color, t.archived,
+		(SELECT COUNT(*) FROM entries e WHERE e.deleted_at IS NULL
+			AND EXISTS (SELECT 1 FROM json_each(e.tags) WHERE json_each.value = t.name COLLATE NOCASE))
+	FROM tags t`
+
+func scanTag(row interface{ Scan(...any) error }) (Tag, error) {
+	var t Tag
+	err := row.Scan(&t.ID, &t.Name, &t.Description, &t.Color, &t.Archived, &t.EntryCount)
+	return t, err
+}
+
+func loadTags(q dbtx, includeArchived bool) ([]Tag, error) {
+	where := " WHERE t.archived = 0"
+	if includeArchived {
+		where = ""
+	}
+	rows, err := q.Query(tagSelect + where + " ORDER BY t.position, t.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags := []Tag{}
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// activeTags is the taxonomy extraction works with. It falls back to the
+// defaults if the table can't be read.
+func (a *App) activeTags() []Tag {
+	if a.db != nil {
+		tags, err := loadTags(a.db, false)
+		if err == nil {
+			return tags
+		}
+		a.log.Printf("Failed to load tags, using defaults: %v", err)
+	}
+	tags := make([]Tag, len(defaultTags))
+	for i, name := range defaultTags {
+		tags[i] = Tag{Name: name}
+	}
+	return tags
+}
+
+func tagNames(tags []Tag) []string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// clearExtractionCache drops cached extractions, which were validated
+// against the taxonomy as it was.
+func (a *App) clearExtractionCache() {
+	if err := a.cache.clear(extractCacheCategory); err != nil {
+		a.log.Printf("Failed to clear extraction cache: %v", err)
+	}
+}
+
+// rewriteEntryTags replaces tag from with to on every entry that has it,
+// trashed ones included, dropping the duplicate if the entry already has
+// to. Entries outside the trash get a revision with reason.
+func (a *App) rewriteEntryTags(tx *sql.Tx, from, to, reason string) (int, error) {
+	type tagged struct {
+		id      string
+		tags    []string
+		trashed bool
+	}
+	rows, err := tx.Query("SELECT e.id, COALESCE(e.tags, '[]'), e.deleted_at IS NOT NULL FROM entries e WHERE "+entryHasTag, from)
+	if err != nil {
+		return 0, err
+	}
+	var entries []tagged
+	for rows.Next() {
+		var e tagged
+		var raw string
+		if err := rows.Scan(&e.id, &raw, &e.trashed); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		e.tags = parseJSONArray(raw)
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, e := range entries {
+		updated := []string{}
+		seen := map[string]bool{}
+		for _, t := range e.tags {
+			if strings.EqualFold(t, from) {
+				t = to
+			}
+			if !seen[strings.ToLower(t)] {
+				seen[strings.ToLower(t)] = true
+				updated = append(updated, t)
+			}
+		}
+
+		var before Entry
+		if !e.trashed {
+			if before, err = a.readEntry(tx, e.id); err != nil {
+				return 0, err
+			}
+		}
+		if _, err := tx.Exec("UPDATE entries SET tags = ?, updated_at = ? WHERE id = ?", jsonStringify(updated), now, e.id); err != nil {
+			return 0, fmt.Errorf("entry %s: %w", e.id, err)
+		}
+		if !e.trashed {
+			if err := a.recordRevision(tx, before, reason); err != nil {
+				return 0, fmt.Errorf("entry %s: %w", e.id, err)
+			}
+		}
+	}
+	return len(entries), nil
+}
+
+// tagNameTaken reports whether another tag already has name.
+func tagNameTaken(q dbtx, name, exceptID string) bool {
+	var n int
+	q.QueryRow("SELECT COUNT(*) FROM tags WHERE name = ? COLLATE NOCASE AND id != ?", name, exceptID).Scan(&n)
+	return n > 0
+}
+
+// ─── Handlers ───────────────────────────────────────────
+
+// handleGetTags lists the taxonomy in display order, with how many entries
+// use each tag. Archived tags are left out unless include_archived=true.
+func (a *App) handleGetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := loadTags(a.db, r.URL.Query().Get("include_archived") == "true")
+	if err != nil {
+		a.log.Printf("Failed to load tags: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, tags)
+}
+
+func (a *App) handleCreateTag(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Color       string `json:"color"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return
+	}
+	name := strings.Join(strings.Fields(body.Name), " ")
+	if name == "" {
+		writeJSON(w, 400, map[string]string{"error": "name is required"})
+		return
+	}
+	if tagNameTaken(a.db, name, "") {
+		writeJSON(w, 409, map[string]string{"error": fmt.Sprintf("a tag named %q already exists", name)})
+		return
+	}
+
+	id := fmt.Sprintf("tag-%d", time.Now().UnixMilli())
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := a.db.Exec(`
+		INSERT INTO tags (id, name, description, color, archived, position, created_at, updated_at)
+		VALUES (?, ?, ?, ?, 0, (SELECT COALESCE(MAX(position), 0) + 1 FROM tags), ?, ?)`,
+		id, name, strings.TrimSpace(body.Description), body.Color, now, now)
+	if err != nil {
+		a.log.Printf("Failed to create tag %q: %v", name, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to create tag"})
+		return
+	}
+	a.clearExtractionCache()
+
+	t, err := scanTag(a.db.QueryRow(tagSelect+" WHERE t.id = ?", id))
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "failed to read created tag"})
+		return
+	}
+	writeJSON(w, 201, t)
+}
+
+// handleUpdateTag changes any of name, description, color and archived.
+// Renaming rewrites the tag on every entry that has it.
+func (a *App) handleUpdateTag(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var body struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Color       *string `json:"color"`
+		Archived    *bool   `json:"archived"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid json"})
+		return
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+
+	t, err := scanTag(tx.QueryRow(tagSelect+" WHERE t.id = ?", id))
+	if err != nil {
+		writeJSON(w, 404, map[string]string{"error": "tag not found"})
+		return
+	}
+
+	if body.Name != nil {
+		name := strings.Join(strings.Fields(*body.Name), " ")
+		if name == "" {
+			writeJSON(w, 400, map[string]string{"error": "name must not be empty"})
+			return
+		}
+		if tagNameTaken(tx, name, id) {
+			writeJSON(w, 409, map[string]string{"error": fmt.Sprintf("a tag named %q already exists; merge into it instead", name)})
+			return
+		}
+		if name != t.Name {
+			if _, err := a.rewriteEntryTags(tx, t.Name, name, fmt.Sprintf("tag renamed to %q", name)); err != nil {
+				a.log.Printf("Failed to rename tag %s on entries: %v", id, err)
+				writeJSON(w, 500, map[string]string{"error": "failed to rename tag"})
+				return
+			}
+			t.Name = name
+		}
+	}
+	if body.Description != nil {
+		t.Description = strings.TrimSpace(*body.Description)
+	}
+	if body.Color != nil {
+		t.Color = *body.Color
+	}
+	if body.Archived != nil {
+		t.Archived = *body.Archived
+	}
+
+	_, err = tx.Exec("UPDATE tags SET name = ?, description = ?, color = ?, archived = ?, updated_at = ? WHERE id = ?",
+		t.Name, t.Description, t.Color, t.Archived, time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		a.log.Printf("Failed to update tag %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to update tag"})
+		return
+	}
+	if t, err = scanTag(tx.QueryRow(tagSelect+" WHERE t.id = ?", id)); err != nil || tx.Commit() != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	a.clearExtractionCache()
+	writeJSON(w, 200, t)
+}
+
+// handleDeleteTag deletes a tag no entry uses. Tags in use have to be
+// archived or merged instead, so entries never carry a tag that silently
+// vanished from the taxonomy.
+func (a *App) handleDeleteTag(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var name string
+	if err := a.db.QueryRow("SELECT name FROM tags WHERE id = ?", id).Scan(&name); err != nil {
+		writeJSON(w, 404, map[string]string{"error": "tag not found"})
+		return
+	}
+	var used int
+	a.db.QueryRow("SELECT COUNT(*) FROM entries e WHERE "+entryHasTag, name).Scan(&used)
+	if used > 0 {
+		writeJSON(w, 409, map[string]string{"error": fmt.Sprintf("%q is used by %d entries; archive it or merge it into another tag", name, used)})
+		return
+	}
+	if _, err := a.db.Exec("DELETE FROM tags WHERE id = ?", id); err != nil {
+		a.log.Printf("Failed to delete tag %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to delete tag"})
+		return
+	}
+	a.clearExtractionCache()
+	writeJSON(w, 200, map[string]bool{"deleted": true})
+}
+
+// handleMergeTag folds a tag into another: every entry with it gets the
+// target tag instead, and the merged tag is deleted.
+func (a *App) handleMergeTag(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var body struct {
+		Into string `json:"into"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Into == "" {
+		writeJSON(w, 400, map[string]string{"error": "into (the id of the tag to merge into) is required"})
+		return
+	}
+	if body.Into == id {
+		writeJSON(w, 400, map[string]string{"error": "cannot merge a tag into itself"})
+		return
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var from, into string
+	if err := tx.QueryRow("SELECT name FROM tags WHERE id = ?", id).Scan(&from); err != nil {
+		writeJSON(w, 404, map[string]string{"error": "tag not found"})
+		return
+	}
+	if err := tx.QueryRow("SELECT name FROM tags WHERE id = ?", body.Into).Scan(&into); err != nil {
+		writeJSON(w, 404, map[string]string{"error": "tag to merge into not found"})
+		return
+	}
+
+	n, err := a.rewriteEntryTags(tx, from, into, fmt.Sprintf("tag %q merged into %q", from, into))
+	if err == nil {
+		_, err = tx.Exec("DELETE FROM tags WHERE id = ?", id)
+	}
+	if err != nil {
+		a.log.Printf("Failed to merge tag %s into %s: %v", id, body.Into, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to merge tag"})
+		return
+	}
+	t, err := scanTag(tx.QueryRow(tagSelect+" WHERE t.id = ?", body.Into))
+	if err != nil || tx.Commit() != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	a.clearExtractionCache()
+	writeJSON(w, 200, map[string]any{"tag": t, "entries_updated": n})
+}
@@ -0,0 +1,53 @@
+-- Schema and data as written by the original InitDB, before versioned
+-- migrations and before the jira_account_id / prep_notes columns existed.
+CREATE TABLE team_members (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	role TEXT NOT NULL,
+	color TEXT NOT NULL
+);
+
+CREATE TABLE entries (
+	id TEXT PRIMARY KEY,
+	member_id TEXT NOT NULL REFERENCES team_members(id),
+	date TEXT NOT NULL,
+	summary TEXT,
+	morale_score INTEGER,
+	growth_score INTEGER,
+	morale_rationale TEXT,
+	growth_rationale TEXT,
+	tags TEXT,
+	action_items_mine TEXT,
+	action_items_theirs TEXT,
+	notable_quotes TEXT,
+	blockers TEXT,
+	wins TEXT,
+	private_note TEXT,
+	transcript TEXT,
+	created_at TEXT,
+	updated_at TEXT
+);
+
+CREATE TABLE cache (
+	key TEXT NOT NULL,
+	category TEXT NOT NULL,
+	value TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (key, category)
+);
+
+INSERT INTO team_members (id, name, role, color) VALUES
+	('member-1', 'Sam Rivera', 'Engineer', '#E07A5F'),
+	('member-2', 'Jo Li', 'Senior Engineer', '#3D405B');
+
+INSERT INTO entries (id, member_id, date, summary, morale_score, growth_score,
+	morale_rationale, growth_rationale, tags, action_items_mine, action_items_theirs,
+	notable_quotes, blockers, wins, private_note, transcript, created_at, updated_at)
+VALUES
+	('entry-1', 'member-1', '2025-01-06T10:00:00Z', 'Talked about the on-call rotation.', 3, 4,
+	 'Tired after a noisy week.', 'Took ownership of the runbook.',
+	 '["process","morale"]',
+	 '[{"text":"Raise on-call load with the team","completed":false}]',
+	 '[{"text":"Draft runbook changes","completed":true}]',
+	 '["I want fewer pages at 3am"]', '["Noisy alerts"]', '["Shipped the runbook"]',
+	 'Keep an eye on burnout.', 'Sam: the pages are brutal.', '2025-01-06T10:30:00Z', '2025-01-06T10:30:00Z');
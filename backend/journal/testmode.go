@@ -0,0 +1,24 @@
+package journal
+
+import "os"
+
+// In test mode the App runs against a throwaway database in a new temp
+// directory, with the mock provider unless AIProvider names another, and
+// without scheduled backups. It's meant for UI work and end-to-end tests that
+// shouldn't touch the real journal or a paid API.
+
+// applyTestMode points cfg at a new temp directory, which the App removes
+// on Close.
+func applyTestMode(cfg *Config) (string, error) {
+	dir, err := os.MkdirTemp("", "people-journal-test-")
+	if err != nil {
+		return "", err
+	}
+	cfg.DataDir = dir
+	cfg.BackupDir = ""
+	cfg.BackupIntervalHours = 0
+	if cfg.AIProvider == "" {
+		cfg.AIProvider = "mock"
+	}
+	return dir, nil
+}
@@ -0,0 +1,106 @@
+package journal
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"people-journal/transcript"
+)
+
+// maxTranscriptUpload caps the memory a multipart upload may use before the
+// rest spills to disk. The body itself is limited by corsMiddleware.
+const maxTranscriptUpload = 10 << 20
+
+type parsedTranscript struct {
+	Format     transcript.Format `json:"format"`
+	Speakers   []string          `json:"speakers"`
+	Turns      []transcript.Turn `json:"turns"`
+	Transcript string            `json:"transcript"`
+	Roles      map[string]string `json:"roles,omitempty"`
+	Extraction *ExtractionResult `json:"extraction,omitempty"`
+}
+
+// handleParseTranscript normalizes an uploaded transcript export. Multipart
+// fields: file (required), format to skip detection, member_id to report who
+// each speaker is from the member's saved labels, and member_name (plus
+// optional model) to run the normalized text straight through extraction.
+func (a *App) handleParseTranscript(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(maxTranscriptUpload); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "expected a multipart upload with a file field"})
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeJSON(w, 400, map[string]string{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeJSON(w, 400, map[string]string{"error": "failed to read upload"})
+		return
+	}
+
+	var parsed transcript.Transcript
+	if format := r.FormValue("format"); format != "" {
+		parsed, err = transcript.ParseAs(transcript.Format(format), data)
+	} else {
+		parsed, err = transcript.Parse(header.Filename, data)
+	}
+	if errors.Is(err, transcript.ErrEmpty) {
+		writeJSON(w, 422, map[string]string{"error": "no transcript text found in " + header.Filename})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 400, map[string]string{"error": err.Error()})
+		return
+	}
+
+	res := parsedTranscript{
+		Format:     parsed.Format,
+		Speakers:   parsed.Speakers(),
+		Turns:      parsed.Turns,
+		Transcript: parsed.Text(),
+	}
+
+	memberID, memberName := r.FormValue("member_id"), r.FormValue("member_name")
+	if memberID != "" {
+		known, err := loadSpeakerLabels(a.db, memberID)
+		if err != nil {
+			a.log.Printf("Failed to load speaker labels for %s: %v", memberID, err)
+		}
+		name := memberName
+		if name == "" {
+			a.db.QueryRow("SELECT name FROM team_members WHERE id = ? AND deleted_at IS NULL", memberID).Scan(&name)
+		}
+		res.Roles = resolveSpeakers(res.Speakers, name, known)
+	}
+	if memberName == "" {
+		writeJSON(w, 200, res)
+		return
+	}
+
+	body := extractRequest{Transcript: res.Transcript, MemberName: memberName, MemberID: memberID, Model: r.FormValue("model")}
+	a.attributeSpeakers(&body)
+	key := body.cacheKey()
+	if result, ok := a.cachedExtraction(key); ok {
+		a.recordCacheHit(purposeExtract)
+		res.Extraction = &result
+		writeJSON(w, 200, res)
+		return
+	}
+	provider, err := a.aiProvider(r.Context(), purposeExtract)
+	if err != nil {
+		writeJSON(w, providerErrorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+	result, err := a.runExtraction(provider, body, key, nil, nil)
+	if err != nil {
+		a.log.Println("Extraction of uploaded transcript failed:", err)
+		writeExtractionError(w, err)
+		return
+	}
+	res.Extraction = &result
+	writeJSON(w, 200, res)
+}
@@ -0,0 +1,304 @@
+package journal
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Deleting a member or entry only sets deleted_at. Trashed rows are hidden
+// everywhere else, can be restored from /api/trash, and are purged for good
+// once they've been in the trash longer than TRASH_RETENTION_DAYS.
+const defaultTrashRetentionDays = 30
+
+// deletedAtFormat is RFC 3339 with fixed nanoseconds. A member's entries are
+// trashed with the member's exact deleted_at, so it must not collide with an
+// entry deleted on its own a moment earlier.
+const deletedAtFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+func deletionTime() string {
+	return time.Now().UTC().Format(deletedAtFormat)
+}
+
+type TrashedMember struct {
+	TeamMember
+	DeletedAt  string `json:"deleted_at"`
+	EntryCount int    `json:"entry_count"`
+}
+
+type TrashedEntry struct {
+	Entry
+	MemberName string `json:"member_name"`
+	DeletedAt  string `json:"deleted_at"`
+}
+
+// ─── Purging ────────────────────────────────────────────
+
+// purgeEntries permanently deletes the entries matching where, along with
+// their action items and revisions.
+func purgeEntries(tx dbtx, where string, args ...any) (int64, error) {
+	ids := "SELECT id FROM entries WHERE " + where
+	if _, err := tx.Exec("DELETE FROM action_items WHERE entry_id IN ("+ids+")", args...); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM entry_revisions WHERE entry_id IN ("+ids+")", args...); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec("DELETE FROM entries WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// purgeMembers permanently deletes the team members matching where, all of
+// their entries and their speaker labels.
+func purgeMembers(tx dbtx, where string, args ...any) (int64, error) {
+	members := "SELECT id FROM team_members WHERE " + where
+	if _, err := purgeEntries(tx, "member_id IN ("+members+")", args...); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM speaker_labels WHERE member_id IN ("+members+")", args...); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec("DELETE FROM team_members WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// purgeExpiredTrash deletes everything that was trashed before the retention
+// window.
+func (a *App) purgeExpiredTrash() error {
+	days := a.cfg.TrashRetentionDays
+	if days == 0 {
+		return nil
+	}
+	cutoff := time.Now().UTC().AddDate(0, 0, -days).Format(deletedAtFormat)
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	members, err := purgeMembers(tx, "deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if err != nil {
+		return fmt.Errorf("purge members: %w", err)
+	}
+	entries, err := purgeEntries(tx, "deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if err != nil {
+		return fmt.Errorf("purge entries: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if members > 0 || entries > 0 {
+		a.log.Printf("Purged %d team members and %d entries deleted more than %d days ago", members, entries, days)
+	}
+	return nil
+}
+
+// startTrashPurger purges expired trash now and then every hour.
+func (a *App) startTrashPurger() {
+	go func() {
+		for {
+			if err := a.purgeExpiredTrash(); err != nil {
+				a.log.Printf("Failed to purge trash: %v", err)
+			}
+			if !a.sleep(time.Hour) {
+				return
+			}
+		}
+	}()
+}
+
+// ─── Handlers ───────────────────────────────────────────
+
+// handleGetTrash lists trashed members and entries, newest first. Entries
+// that were trashed along with their member are counted under the member
+// rather than listed.
+func (a *App) handleGetTrash(w http.ResponseWriter, r *http.Request) {
+	members := []TrashedMember{}
+	rows, err := a.db.Query(`
+		SELECT m.id, m.name, m.role, m.color, m.jira_account_id, m.prep_notes, m.deleted_at,
+			(SELECT COUNT(*) FROM entries e WHERE e.member_id = m.id AND e.deleted_at = m.deleted_at)
+		FROM team_members m
+		WHERE m.deleted_at IS NOT NULL
+		ORDER BY m.deleted_at DESC`)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m TrashedMember
+		tm, err := scanTeamMember(scanAppend(rows, &m.DeletedAt, &m.EntryCount))
+		if err != nil {
+			a.log.Printf("Failed to scan trashed member: %v", err)
+			continue
+		}
+		m.TeamMember = tm
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+
+	entries := []TrashedEntry{}
+	entryRows, err := a.db.Query(`
+		SELECT ` + entryColsAs("e") + `, COALESCE(m.name, ''), e.deleted_at
+		FROM entries e
+		LEFT JOIN team_members m ON m.id = e.member_id
+		WHERE e.deleted_at IS NOT NULL
+			AND (m.deleted_at IS NULL OR m.deleted_at != e.deleted_at)
+		ORDER BY e.deleted_at DESC`)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer entryRows.Close()
+	for entryRows.Next() {
+		var te TrashedEntry
+		e, err := a.scanEntry(scanAppend(entryRows, &te.MemberName, &te.DeletedAt))
+		if err != nil {
+			a.log.Printf("Failed to scan trashed entry: %v", err)
+			continue
+		}
+		te.Entry = e
+		entries = append(entries, te)
+	}
+	if err := entryRows.Err(); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+
+	writeJSON(w, 200, map[string]any{
+		"members":        members,
+		"entries":        entries,
+		"retention_days": a.cfg.TrashRetentionDays,
+	})
+}
+
+func (a *App) handleRestoreTrashedMember(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var deletedAt string
+	if err := tx.QueryRow("SELECT deleted_at FROM team_members WHERE id = ? AND deleted_at IS NOT NULL", id).Scan(&deletedAt); err != nil {
+		writeJSON(w, 404, map[string]string{"error": "member not in trash"})
+		return
+	}
+	if _, err := tx.Exec("UPDATE entries SET deleted_at = NULL WHERE member_id = ? AND deleted_at = ?", id, deletedAt); err != nil {
+		a.log.Printf("Failed to restore entries for member %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to restore member"})
+		return
+	}
+	if _, err := tx.Exec("UPDATE team_members SET deleted_at = NULL WHERE id = ?", id); err != nil {
+		a.log.Printf("Failed to restore member %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to restore member"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+
+	m, err := scanTeamMember(a.db.QueryRow("SELECT id, name, role, color, jira_account_id, prep_notes FROM team_members WHERE id = ?", id))
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "failed to read restored member"})
+		return
+	}
+	writeJSON(w, 200, m)
+}
+
+func (a *App) handleRestoreTrashedEntry(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var memberTrashed bool
+	err := a.db.QueryRow(`
+		SELECT COALESCE(m.deleted_at IS NOT NULL, 0)
+		FROM entries e
+		LEFT JOIN team_members m ON m.id = e.member_id
+		WHERE e.id = ? AND e.deleted_at IS NOT NULL`, id).Scan(&memberTrashed)
+	if err != nil {
+		writeJSON(w, 404, map[string]string{"error": "entry not in trash"})
+		return
+	}
+	if memberTrashed {
+		writeJSON(w, 409, map[string]string{"error": "this entry's team member is in the trash; restore them first"})
+		return
+	}
+
+	if _, err := a.db.Exec("UPDATE entries SET deleted_at = NULL WHERE id = ?", id); err != nil {
+		a.log.Printf("Failed to restore entry %s: %v", id, err)
+		writeJSON(w, 500, map[string]string{"error": "failed to restore entry"})
+		return
+	}
+
+	e, err := a.readEntry(a.db, id)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "failed to read restored entry"})
+		return
+	}
+	writeJSON(w, 200, e)
+}
+
+func (a *App) handlePurgeTrashedMember(w http.ResponseWriter, r *http.Request) {
+	a.purgeTrash(w, func(tx dbtx) (int64, error) {
+		return purgeMembers(tx, "id = ? AND deleted_at IS NOT NULL", r.PathValue("id"))
+	}, "member not in trash")
+}
+
+func (a *App) handlePurgeTrashedEntry(w http.ResponseWriter, r *http.Request) {
+	a.purgeTrash(w, func(tx dbtx) (int64, error) {
+		return purgeEntries(tx, "id = ? AND deleted_at IS NOT NULL", r.PathValue("id"))
+	}, "entry not in trash")
+}
+
+// handleEmptyTrash permanently deletes everything in the trash.
+func (a *App) handleEmptyTrash(w http.ResponseWriter, r *http.Request) {
+	a.purgeTrash(w, func(tx dbtx) (int64, error) {
+		members, err := purgeMembers(tx, "deleted_at IS NOT NULL")
+		if err != nil {
+			return 0, err
+		}
+		entries, err := purgeEntries(tx, "deleted_at IS NOT NULL")
+		return members + entries, err
+	}, "")
+}
+
+// purgeTrash runs purge in a transaction and writes the response. A purge
+// that deletes nothing is a 404 with notFound, unless notFound is empty.
+func (a *App) purgeTrash(w http.ResponseWriter, purge func(tx dbtx) (int64, error), notFound string) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer tx.Rollback()
+
+	n, err := purge(tx)
+	if err != nil {
+		a.log.Printf("Failed to purge trash: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "failed to purge"})
+		return
+	}
+	if n == 0 && notFound != "" {
+		writeJSON(w, 404, map[string]string{"error": notFound})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	writeJSON(w, 200, map[string]bool{"purged": true})
+}
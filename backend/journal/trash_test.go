@@ -0,0 +1,149 @@
+package journal
+
+import "testing"
+
+// rowCount counts the rows in table matching where.
+func rowCount(t *testing.T, s *testServer, table, where string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := s.app.db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE "+where, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// wantTrash checks how many members and loose entries /api/trash lists.
+func wantTrash(members, entries int) checkFunc {
+	return checks(wantLen(members, "members"), wantLen(entries, "entries"))
+}
+
+func TestTrashRestoreMember(t *testing.T) {
+	s := newTestServer(t)
+	entry := func(summary string) map[string]any {
+		return map[string]any{"member_id": "$sam", "date": "2024-05-01T15:00:00Z", "summary": summary}
+	}
+	s.run(t, []apiStep{
+		{name: "create member", method: "POST", path: "/api/team", body: map[string]string{"name": "Sam"}, status: 201, save: "sam"},
+		{name: "create early entry", method: "POST", path: "/api/entries", body: entry("Deleted on its own."), status: 201, save: "early"},
+		{name: "create kept entry", method: "POST", path: "/api/entries", body: entry("Deleted with Sam."), status: 201, save: "kept"},
+		{name: "delete early entry", method: "DELETE", path: "/api/entries/$early", status: 200},
+		{name: "delete member", method: "DELETE", path: "/api/team/$sam", status: 200},
+		{name: "trash lists the member once", method: "GET", path: "/api/trash", status: 200,
+			check: checks(wantTrash(1, 1), func(t *testing.T, s *testServer, got any) {
+				members, _ := field(got, "members").([]any)
+				entries, _ := field(got, "entries").([]any)
+				if len(members) != 1 || len(entries) != 1 {
+					return
+				}
+				if n := field(members[0], "entry_count"); n != 1.0 {
+					t.Errorf("entry_count = %v, want 1", n)
+				}
+				if id := field(entries[0], "id"); id != s.vars["early"] {
+					t.Errorf("loose entry = %v, want %s", id, s.vars["early"])
+				}
+			})},
+		{name: "entry can't be restored before its member", method: "POST", path: "/api/trash/entries/$kept/restore", status: 409},
+		{name: "restore member", method: "POST", path: "/api/trash/team/$sam/restore", status: 200, check: wantField("Sam", "name")},
+		{name: "member is back", method: "GET", path: "/api/team", status: 200, check: wantLen(1)},
+		{name: "entry deleted with the member is back", method: "GET", path: "/api/entries/$kept", status: 200},
+		{name: "entry deleted on its own is not", method: "GET", path: "/api/entries/$early", status: 404},
+		{name: "trash keeps the early entry", method: "GET", path: "/api/trash", status: 200, check: wantTrash(0, 1)},
+		{name: "restore member twice", method: "POST", path: "/api/trash/team/$sam/restore", status: 404},
+		{name: "restore early entry", method: "POST", path: "/api/trash/entries/$early/restore", status: 200,
+			check: wantField("Deleted on its own.", "summary")},
+		{name: "trash is empty", method: "GET", path: "/api/trash", status: 200, check: wantTrash(0, 0)},
+	})
+}
+
+func TestTrashPurge(t *testing.T) {
+	s := newTestServer(t)
+	entry := map[string]any{
+		"member_id": "$sam", "date": "2024-05-01T15:00:00Z", "summary": "Talked about the launch.",
+		"action_items_mine":   []map[string]any{{"text": "Share the rollout doc"}},
+		"action_items_theirs": []map[string]any{{"text": "Write the retro"}},
+	}
+	s.run(t, []apiStep{
+		{name: "create member", method: "POST", path: "/api/team", body: map[string]string{"name": "Sam"}, status: 201, save: "sam"},
+		{name: "speaker labels", method: "PUT", path: "/api/team/$sam/speakers", body: map[string]any{"labels": map[string]string{"Dana": "manager"}}, status: 200},
+		{name: "create first entry", method: "POST", path: "/api/entries", body: entry, status: 201, save: "first"},
+		{name: "create second entry", method: "POST", path: "/api/entries", body: entry, status: 201, save: "second"},
+		{name: "edit first entry", method: "PUT", path: "/api/entries/$first", body: map[string]any{"summary": "Launch went well."}, status: 200},
+		{name: "edit second entry", method: "PUT", path: "/api/entries/$second", body: map[string]any{"summary": "Launch went well."}, status: 200},
+	})
+	first, second, sam := s.vars["first"], s.vars["second"], s.vars["sam"]
+	for _, id := range []string{first, second} {
+		if rowCount(t, s, "action_items", "entry_id = ?", id) != 2 || rowCount(t, s, "entry_revisions", "entry_id = ?", id) != 1 {
+			t.Fatalf("entry %s wasn't set up with action items and a revision", id)
+		}
+	}
+	if rowCount(t, s, "speaker_labels", "member_id = ?", sam) != 1 {
+		t.Fatal("speaker labels weren't saved")
+	}
+
+	// Purging an entry takes its action items and revisions with it.
+	s.run(t, []apiStep{
+		{name: "purge live entry", method: "DELETE", path: "/api/trash/entries/$first", status: 404},
+		{name: "delete first entry", method: "DELETE", path: "/api/entries/$first", status: 200},
+		{name: "purge first entry", method: "DELETE", path: "/api/trash/entries/$first", status: 200},
+		{name: "purge first entry twice", method: "DELETE", path: "/api/trash/entries/$first", status: 404},
+		{name: "restore purged entry", method: "POST", path: "/api/trash/entries/$first/restore", status: 404},
+	})
+	wantGone(t, map[string]int{
+		"entries":         rowCount(t, s, "entries", "id = ?", first),
+		"action_items":    rowCount(t, s, "action_items", "entry_id = ?", first),
+		"entry_revisions": rowCount(t, s, "entry_revisions", "entry_id = ?", first),
+	})
+	if rowCount(t, s, "action_items", "entry_id = ?", second) != 2 {
+		t.Error("purging one entry touched another's action items")
+	}
+
+	// Purging a member takes their entries and speaker labels with it.
+	s.run(t, []apiStep{
+		{name: "purge live member", method: "DELETE", path: "/api/trash/team/$sam", status: 404},
+		{name: "delete member", method: "DELETE", path: "/api/team/$sam", status: 200},
+		{name: "purge member", method: "DELETE", path: "/api/trash/team/$sam", status: 200},
+		{name: "trash is empty", method: "GET", path: "/api/trash", status: 200, check: wantTrash(0, 0)},
+		{name: "restore purged member", method: "POST", path: "/api/trash/team/$sam/restore", status: 404},
+	})
+	wantGone(t, map[string]int{
+		"team_members":    rowCount(t, s, "team_members", "id = ?", sam),
+		"speaker_labels":  rowCount(t, s, "speaker_labels", "member_id = ?", sam),
+		"entries":         rowCount(t, s, "entries", "member_id = ?", sam),
+		"action_items":    rowCount(t, s, "action_items", "member_id = ? OR entry_id = ?", sam, second),
+		"entry_revisions": rowCount(t, s, "entry_revisions", "entry_id = ?", second),
+	})
+}
+
+func TestEmptyTrash(t *testing.T) {
+	s := newTestServer(t)
+	seedEntry(t, s)
+	s.run(t, []apiStep{
+		{name: "create member", method: "POST", path: "/api/team", body: map[string]string{"name": "Dana"}, status: 201, save: "dana"},
+		{name: "speaker labels", method: "PUT", path: "/api/team/$dana/speakers", body: map[string]any{"labels": map[string]string{"Dana": "member"}}, status: 200},
+		{name: "delete member", method: "DELETE", path: "/api/team/$dana", status: 200},
+		{name: "delete entry", method: "DELETE", path: "/api/entries/entry-1", status: 200},
+		{name: "empty trash", method: "DELETE", path: "/api/trash", status: 200},
+		{name: "empty trash again", method: "DELETE", path: "/api/trash", status: 200},
+		{name: "trash is empty", method: "GET", path: "/api/trash", status: 200, check: wantTrash(0, 0)},
+		{name: "live member is kept", method: "GET", path: "/api/team", status: 200, check: checks(wantLen(1), func(t *testing.T, s *testServer, got any) {
+			if list, _ := got.([]any); len(list) == 1 && field(list[0], "id") != "member-1" {
+				t.Errorf("kept %v, want member-1", field(list[0], "id"))
+			}
+		})},
+	})
+	wantGone(t, map[string]int{
+		"entries":        rowCount(t, s, "entries", "1 = 1"),
+		"action_items":   rowCount(t, s, "action_items", "1 = 1"),
+		"speaker_labels": rowCount(t, s, "speaker_labels", "1 = 1"),
+	})
+}
+
+// wantGone reports every table that still has rows left by a purge.
+func wantGone(t *testing.T, left map[string]int) {
+	t.Helper()
+	for table, n := range left {
+		if n != 0 {
+			t.Errorf("%d rows left in %s", n, table)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Every model call and every cache hit that saved one is recorded in
+// ai_calls. Handlers get their provider from aiProvider, which wraps it so
+// each call is timed, costed and checked against AI_MONTHLY_BUDGET_USD.
+
+const (
+	purposeExtract = "extract"
+	purposePrep    = "prep"
+)
+
+// callUsage is filled in by providers that report token counts. A request
+// that isn't metered has no callUsage and reporting is a no-op.
+type callUsage struct {
+	Provider     string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+}
+
+// report records the provider, model and token counts of a finished call.
+// Counts add up, since a fallback chain or a stream may report in parts.
+func (r CompletionRequest) report(provider, model string, input, output int) {
+	if r.usage == nil {
+		return
+	}
+	r.usage.Provider = provider
+	r.usage.Model = model
+	r.usage.InputTokens += input
+	r.usage.OutputTokens += output
+}
+
+// ─── Pricing ────────────────────────────────────────────
+
+// modelPrices are USD per million input and output tokens, matched by model
+// name prefix. Models not listed, and the local provider, cost nothing
+// unless AI_PRICE_INPUT_PER_MTOK and AI_PRICE_OUTPUT_PER_MTOK are set.
+var modelPrices = []struct {
+	prefix        string
+	input, output float64
+}{
+	{"claude-opus-4", 15, 75},
+	{"claude-sonnet-4", 3, 15},
+	{"claude-haiku-4", 1, 5},
+	{"claude-3-5-haiku", 0.8, 4},
+	{"gpt-4o-mini", 0.15, 0.6},
+	{"gpt-4o", 2.5, 10},
+	{"gpt-4.1-mini", 0.4, 1.6},
+	{"gpt-4.1", 2, 8},
+}
+
+func (a *App) callCost(u callUsage) float64 {
+	var in, out float64
+	if a.cfg.PriceInputPerMTok != nil && a.cfg.PriceOutputPerMTok != nil {
+		in, out = *a.cfg.PriceInputPerMTok, *a.cfg.PriceOutputPerMTok
+	} else {
+		if u.Provider == "local" {
+			return 0
+		}
+		for _, p := range modelPrices {
+			if strings.HasPrefix(u.Model, p.prefix) {
+				in, out = p.input, p.output
+				break
+			}
+		}
+	}
+	return (float64(u.InputTokens)*in + float64(u.OutputTokens)*out) / 1e6
+}
+
+// ─── Budget ─────────────────────────────────────────────
+
+var errBudgetExceeded = errors.New("monthly AI budget reached")
+
+// monthlySpend is the cost of this calendar month's calls (UTC).
+func (a *App) monthlySpend() float64 {
+	start := time.Now().UTC().Format("2006-01") + "-01"
+	var spent float64
+	a.db.QueryRow("SELECT COALESCE(SUM(cost_usd), 0) FROM ai_calls WHERE created_at >= ?", start).Scan(&spent)
+	return spent
+}
+
+func (a *App) checkBudget() error {
+	budget := a.cfg.MonthlyBudgetUSD
+	if budget == nil {
+		return nil
+	}
+	if spent := a.monthlySpend(); spent >= *budget {
+		return fmt.Errorf("%w: $%.2f of $%.2f spent this month", errBudgetExceeded, spent, *budget)
+	}
+	return nil
+}
+
+// ─── Recording ──────────────────────────────────────────
+
+type aiCall struct {
+	usage     callUsage
+	purpose   string
+	latency   time.Duration
+	cacheHit  bool
+	err       error
+	createdAt time.Time
+}
+
+func (a *App) recordAICall(c aiCall) {
+	var errText *string
+	if c.err != nil {
+		s := c.err.Error()
+		errText = &s
+	}
+	_, err := a.db.Exec(`
+		INSERT INTO ai_calls (created_at, provider, model, purpose, input_tokens, output_tokens, latency_ms, cache_hit, cost_usd, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.createdAt.UTC().Format(time.RFC3339), c.usage.Provider, c.usage.Model, c.purpose,
+		c.usage.InputTokens, c.usage.OutputTokens, c.latency.Milliseconds(), c.cacheHit, a.callCost(c.usage), errText)
+	if err != nil {
+		a.log.Printf("Failed to record AI call: %v", err)
+	}
+}
+
+// recordCacheHit records a request answered from the cache, so usage shows
+// what the cache saved.
+func (a *App) recordCacheHit(purpose string) {
+	a.recordAICall(aiCall{purpose: purpose, cacheHit: true, createdAt: time.Now()})
+}
+
+// meteredProvider records every call made through it, and runs each under
+// ctx unless the request has its own.
+type meteredProvider struct {
+	Provider
+	app     *App
+	ctx     context.Context
+	purpose string
+}
+
+// aiProvider is the App's provider for a handler: bound to the incoming
+// request's ctx, metered under purpose, and failing with errBudgetExceeded
+// once the monthly budget is spent.
+func (a *App) aiProvider(ctx context.Context, purpose string) (Provider, error) {
+	if a.provider == nil {
+		return nil, a.providerErr
+	}
+	if err := a.checkBudget(); err != nil {
+		return nil, err
+	}
+	return &meteredProvider{Provider: a.provider, app: a, ctx: ctx, purpose: purpose}, nil
+}
+
+func (m *meteredProvider) call(req CompletionRequest, fn func(CompletionRequest) (string, error)) (string, error) {
+	// A chunked extraction makes many calls; stop as soon as the budget is gone
+	if err := m.app.checkBudget(); err != nil {
+		return "", err
+	}
+	if req.ctx == nil {
+		req.ctx = m.ctx
+	}
+	usage := &callUsage{Provider: m.Provider.Name(), Model: req.Model}
+	req.usage = usage
+	start := time.Now()
+	text, err := fn(req)
+	m.app.recordAICall(aiCall{usage: *usage, purpose: m.purpose, latency: time.Since(start), err: err, createdAt: start})
+	return text, err
+}
+
+func (m *meteredProvider) Complete(req CompletionRequest) (string, error) {
+	return m.call(req, m.Provider.Complete)
+}
+
+func (m *meteredProvider) Stream(req CompletionRequest, onDelta func(string) error) (string, error) {
+	return m.call(req, func(req CompletionRequest) (string, error) {
+		return streamCompletion(m.Provider, req, onDelta)
+	})
+}
+
+// providerErrorStatus is the HTTP status for a failure to get or use a
+// provider.
+func providerErrorStatus(err error) int {
+	if errors.Is(err, errBudgetExceeded) {
+		return 429
+	}
+	return 500
+}
+
+// ─── Handlers ───────────────────────────────────────────
+
+type UsageRow struct {
+	Day          string  `json:"day,omitempty"`
+	Purpose      string  `json:"purpose,omitempty"`
+	Calls        int     `json:"calls"`
+	CacheHits    int     `json:"cache_hits"`
+	Errors       int     `json:"errors"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	AvgLatencyMS float64 `json:"avg_latency_ms"`
+}
+
+type UsageReport struct {
+	From       string     `json:"from"`
+	To         string     `json:"to"`
+	Days       []UsageRow `json:"days"`
+	Total      UsageRow   `json:"total"`
+	MonthSpend float64    `json:"month_spend_usd"`
+	Budget     *float64   `json:"monthly_budget_usd"`
+}
+
+const usageColumns = `
+	COUNT(*) FILTER (WHERE NOT cache_hit), COUNT(*) FILTER (WHERE cache_hit),
+	COUNT(error), COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0),
+	COALESCE(SUM(cost_usd), 0), COALESCE(AVG(latency_ms) FILTER (WHERE NOT cache_hit), 0)`
+
+// handleGetUsage aggregates ai_calls by day and purpose between from and to
+// (YYYY-MM-DD, inclusive; the last 30 days by default). Calls count model
+// calls only; cache_hits are requests the cache answered instead.
+func (a *App) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	to := q.Get("to")
+	if to == "" {
+		to = time.Now().UTC().Format("2006-01-02")
+	}
+	from := q.Get("from")
+	if from == "" {
+		t, _ := time.Parse("2006-01-02", to)
+		from = t.AddDate(0, 0, -29).Format("2006-01-02")
+	}
+	for _, d := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			writeJSON(w, 400, map[string]string{"error": "from and to must be dates (YYYY-MM-DD)"})
+			return
+		}
+	}
+
+	where := " WHERE created_at >= ? AND created_at <= ?"
+	args := []any{from, to + "T23:59:59Z"}
+	if v := q.Get("purpose"); v != "" {
+		where += " AND purpose = ?"
+		args = append(args, v)
+	}
+
+	rows, err := a.db.Query("SELECT substr(created_at, 1, 10) AS day, purpose,"+usageColumns+
+		" FROM ai_calls"+where+" GROUP BY day, purpose ORDER BY day, purpose", args...)
+	if err != nil {
+		a.log.Printf("Failed to aggregate AI usage: %v", err)
+		writeJSON(w, 500, map[string]string{"error": "db error"})
+		return
+	}
+	defer rows.Close()
+
+	report := UsageReport{From: from, To: to, Days: []UsageRow{}, MonthSpend: a.monthlySpend()}
+	for rows.Next() {
+		var u UsageRow
+		if err := rows.Scan(&u.Day, &u.Purpose, &u.Calls, &u.CacheHits, &u.Errors,
+			&u.InputTokens, &u.OutputTokens, &u.CostUSD, &u.AvgLatencyMS); err != nil {
+			a.log.Printf("Failed to scan AI usage: %v", err)
+			continue
+		}
+		report.Days = append(report.Days, u)
+	}
+	t := &report.Total
+	if err := a.db.QueryRow("SELECT"+usageColumns+" FROM ai_calls"+where, args...).Scan(&t.Calls, &t.CacheHits, &t.Errors,
+		&t.InputTokens, &t.OutputTokens, &t.CostUSD, &t.AvgLatencyMS); err != nil {
+		a.log.Printf("Failed to total AI usage: %v", err)
+	}
+	report.Budget = a.cfg.MonthlyBudgetUSD
+	writeJSON(w, 200, report)
+}
@@ -0,0 +1,361 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ExtractionResult is the validated shape of an extraction. Scores stay nil
+// when the model didn't give a usable value; every list is non-nil.
+type ExtractionResult struct {
+	Summary           string            `json:"summary"`
+	Tags              []string          `json:"tags"`
+	ActionItemsMine   []string          `json:"action_items_mine"`
+	ActionItemsTheirs []string          `json:"action_items_theirs"`
+	MoraleScore       *int              `json:"morale_score"`
+	MoraleRationale   string            `json:"morale_rationale"`
+	GrowthScore       *int              `json:"growth_score"`
+	GrowthRationale   string            `json:"growth_rationale"`
+	NotableQuotes     []string          `json:"notable_quotes"`
+	Blockers          []string          `json:"blockers"`
+	Wins              []string          `json:"wins"`
+	CustomFields      map[string]any    `json:"custom_fields,omitempty"`
+	Corrections       []FieldCorrection `json:"corrections,omitempty"`
+	Redactions        []Redaction       `json:"redactions,omitempty"`
+}
+
+// FieldCorrection records a field that didn't match the schema. FixedBy is
+// "model" when the repair round-trip fixed it, "validator" when it was coerced
+// locally (clamped, dropped, unwrapped) and "none" when it was left empty.
+type FieldCorrection struct {
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+	FixedBy string `json:"fixed_by"`
+}
+
+// fieldIssue is a schema violation found by validateExtraction. fixed is set
+// when the returned result already holds a coerced value for the field.
+type fieldIssue struct {
+	field   string
+	problem string
+	fixed   bool
+}
+
+func (i fieldIssue) String() string {
+	return i.field + ": " + i.problem
+}
+
+// stripCodeFences removes the markdown fences models sometimes wrap JSON in.
+func stripCodeFences(text string) string {
+	clean := strings.TrimSpace(text)
+	clean = strings.TrimPrefix(clean, "```json")
+	clean = strings.TrimPrefix(clean, "```")
+	clean = strings.TrimSuffix(clean, "```")
+	return strings.TrimSpace(clean)
+}
+
+// validateExtraction parses model output and checks it against the schema.
+// The returned result always holds the best coerced value for each field;
+// issues lists every violation found along the way. An error means the text
+// wasn't a JSON object at all.
+func (a *App) validateExtraction(text string) (ExtractionResult, []fieldIssue, error) {
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(stripCodeFences(text)), &raw); err != nil {
+		return ExtractionResult{}, nil, fmt.Errorf("response is not a JSON object: %w", err)
+	}
+
+	v := &extractionValidator{raw: raw, allowedTags: a.activeTags()}
+	res := ExtractionResult{
+		Summary:           v.text("summary"),
+		Tags:              v.tags("tags"),
+		ActionItemsMine:   v.list("action_items_mine"),
+		ActionItemsTheirs: v.list("action_items_theirs"),
+		MoraleScore:       v.score("morale_score"),
+		MoraleRationale:   v.text("morale_rationale"),
+		GrowthScore:       v.score("growth_score"),
+		GrowthRationale:   v.text("growth_rationale"),
+		NotableQuotes:     v.list("notable_quotes"),
+		Blockers:          v.list("blockers"),
+		Wins:              v.list("wins"),
+		CustomFields:      v.customFields("custom_fields", a.activeCustomFields()),
+	}
+	return res, v.issues, nil
+}
+
+type extractionValidator struct {
+	raw         map[string]any
+	allowedTags []Tag
+	issues      []fieldIssue
+}
+
+func (v *extractionValidator) issue(field string, fixed bool, format string, args ...any) {
+	v.issues = append(v.issues, fieldIssue{field: field, problem: fmt.Sprintf(format, args...), fixed: fixed})
+}
+
+func (v *extractionValidator) text(field string) string {
+	switch val := v.raw[field].(type) {
+	case string:
+		return strings.TrimSpace(val)
+	case nil:
+		v.issue(field, false, "missing, must be a string")
+		return ""
+	case []any:
+		items := coerceList(val)
+		v.issue(field, true, "must be a string, got an array")
+		return strings.Join(items, " ")
+	default:
+		v.issue(field, true, "must be a string, got %s", jsonType(val))
+		return fmt.Sprint(val)
+	}
+}
+
+func (v *extractionValidator) score(field string) *int {
+	var f float64
+	switch val := v.raw[field].(type) {
+	case float64:
+		f = val
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			v.issue(field, false, "must be an integer from 1 to 5, got %q", val)
+			return nil
+		}
+		v.issue(field, true, "must be an integer, got string %q", val)
+		f = parsed
+	case nil:
+		v.issue(field, false, "missing, must be an integer from 1 to 5")
+		return nil
+	default:
+		v.issue(field, false, "must be an integer from 1 to 5, got %s", jsonType(val))
+		return nil
+	}
+
+	n := int(math.Round(f))
+	if float64(n) != f {
+		v.issue(field, true, "must be an integer, got %v", f)
+	}
+	if n < 1 || n > 5 {
+		v.issue(field, true, "must be from 1 to 5, got %d", n)
+		n = min(max(n, 1), 5)
+	}
+	return &n
+}
+
+func (v *extractionValidator) list(field string) []string {
+	switch val := v.raw[field].(type) {
+	case []any:
+		for _, item := range val {
+			if _, ok := item.(string); !ok {
+				v.issue(field, true, "items must be strings, got %s", jsonType(item))
+				break
+			}
+		}
+		return coerceList(val)
+	case string:
+		v.issue(field, true, "must be an array of strings, got a string")
+		if s := strings.TrimSpace(val); s != "" {
+			return []string{s}
+		}
+		return []string{}
+	case nil:
+		v.issue(field, true, "missing, must be an array of strings")
+		return []string{}
+	default:
+		v.issue(field, true, "must be an array of strings, got %s", jsonType(val))
+		return []string{}
+	}
+}
+
+func (v *extractionValidator) tags(field string) []string {
+	allowed := map[string]string{}
+	for _, t := range v.allowedTags {
+		allowed[strings.ToLower(t.Name)] = t.Name
+	}
+
+	var out []string
+	seen := map[string]bool{}
+	for _, t := range v.list(field) {
+		canonical, ok := allowed[strings.ToLower(strings.TrimSpace(t))]
+		if !ok {
+			v.issue(field, true, "unknown tag %q, not in the tag list", t)
+			continue
+		}
+		if !seen[canonical] {
+			seen[canonical] = true
+			out = append(out, canonical)
+		}
+	}
+	if out == nil {
+		out = []string{}
+	}
+	return out
+}
+
+// customFields validates the object holding the custom field values, one
+// key per field, with each value checked like the built-in field of the same
+// type, except that a score may be null. Empty values are left out. It
+// returns nil when no fields are defined.
+func (v *extractionValidator) customFields(field string, defs []CustomField) map[string]any {
+	if len(defs) == 0 {
+		return nil
+	}
+	obj, ok := v.raw[field].(map[string]any)
+	if !ok {
+		if v.raw[field] == nil {
+			v.issue(field, false, "missing, must be an object")
+		} else {
+			v.issue(field, false, "must be an object, got %s", jsonType(v.raw[field]))
+		}
+		return map[string]any{}
+	}
+
+	sub := &extractionValidator{raw: obj}
+	out := map[string]any{}
+	for _, f := range defs {
+		switch f.Type {
+		case fieldText:
+			if s := sub.text(f.Name); s != "" {
+				out[f.Name] = s
+			}
+		case fieldList:
+			if items := sub.list(f.Name); len(items) > 0 {
+				out[f.Name] = items
+			}
+		case fieldScore:
+			// null is how the prompt asks for "not discussed"
+			if val, present := obj[f.Name]; present && val == nil {
+				continue
+			}
+			if n := sub.score(f.Name); n != nil {
+				out[f.Name] = *n
+			}
+		}
+	}
+	for _, i := range sub.issues {
+		i.field = field + "." + i.field
+		v.issues = append(v.issues, i)
+	}
+	return out
+}
+
+// jsonType names the JSON type of a decoded value for error messages.
+func jsonType(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case string:
+		return "a string"
+	default:
+		return "null"
+	}
+}
+
+// coerceList flattens array items into strings. Objects such as
+// {"text": "..."} or {"task": "...", "owner": "..."} are reduced to their
+// most text-like field.
+func coerceList(items []any) []string {
+	out := []string{}
+	for _, item := range items {
+		var s string
+		switch val := item.(type) {
+		case string:
+			s = val
+		case map[string]any:
+			for _, k := range []string{"text", "description", "task", "item", "quote", "title"} {
+				if str, ok := val[k].(string); ok {
+					s = str
+					break
+				}
+			}
+		case nil:
+		default:
+			s = fmt.Sprint(val)
+		}
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// buildRepairPrompt asks the model to fix its previous output, listing what
+// was wrong with it.
+func buildRepairPrompt(original, response string, problems []string) string {
+	return fmt.Sprintf(`%s
+
+---
+
+Your previous response to the request above did not match the required JSON format. Problems found:
+
+- %s
+
+Your previous response was:
+
+%s
+
+Respond again with the corrected JSON object only (no markdown, no backticks, no preamble). Scores must be integers from 1 to 5, tags must come only from the listed tags, and every list field must be an array of plain strings.`,
+		original, strings.Join(problems, "\n- "), response)
+}
+
+// validateWithRepair validates model output and, when it fails, gives the
+// model one chance to repair it. Whatever the repair leaves wrong is coerced
+// locally. The returned Corrections cover every field that had to change.
+func (a *App) validateWithRepair(provider Provider, req CompletionRequest, text string) (ExtractionResult, error) {
+	res, issues, parseErr := a.validateExtraction(text)
+	if parseErr == nil && len(issues) == 0 {
+		return res, nil
+	}
+	if parseErr != nil {
+		issues = []fieldIssue{{field: "response", problem: parseErr.Error()}}
+	}
+
+	problems := make([]string, len(issues))
+	for i, issue := range issues {
+		problems[i] = issue.String()
+	}
+
+	repairReq := req
+	repairReq.Prompt = buildRepairPrompt(req.Prompt, text, problems)
+	repaired, err := provider.Complete(repairReq)
+
+	var after []fieldIssue
+	if err == nil {
+		var repairedRes ExtractionResult
+		repairedRes, after, err = a.validateExtraction(repaired)
+		if err == nil {
+			res = repairedRes
+		}
+	}
+	if err != nil {
+		if parseErr != nil {
+			return ExtractionResult{}, fmt.Errorf("%v; repair failed: %w", parseErr, err)
+		}
+		a.log.Printf("[AI] Extraction repair failed, keeping coerced original: %v", err)
+		after, issues = issues, nil
+	}
+
+	remaining := map[string]bool{}
+	for _, i := range after {
+		remaining[i.field] = true
+		fixedBy := "validator"
+		if !i.fixed {
+			fixedBy = "none"
+		}
+		res.Corrections = append(res.Corrections, FieldCorrection{Field: i.field, Problem: i.problem, FixedBy: fixedBy})
+	}
+	for _, i := range issues {
+		if !remaining[i.field] {
+			res.Corrections = append(res.Corrections, FieldCorrection{Field: i.field, Problem: i.problem, FixedBy: "model"})
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,219 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// extractionJSON is a valid extraction with fields replaced by overrides. A
+// nil override removes the field.
+func extractionJSON(overrides map[string]any) string {
+	res := map[string]any{
+		"summary":             "Talked about the launch.",
+		"tags":                []string{"wins"},
+		"action_items_mine":   []string{"Share the rollout doc"},
+		"action_items_theirs": []string{"Write the postmortem"},
+		"morale_score":        4,
+		"morale_rationale":    "Upbeat.",
+		"growth_score":        3,
+		"growth_rationale":    "Steady.",
+		"notable_quotes":      []string{},
+		"blockers":            []string{},
+		"wins":                []string{"Launch went out"},
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(res, k)
+		} else {
+			res[k] = v
+		}
+	}
+	b, _ := json.Marshal(res)
+	return string(b)
+}
+
+func TestValidateWithRepair(t *testing.T) {
+	const repairMarker = "did not match the required JSON format"
+	score := func(n *int) string {
+		if n == nil {
+			return "nil"
+		}
+		return fmt.Sprint(*n)
+	}
+
+	tests := []struct {
+		name     string
+		original string
+		repair   mockRule
+		// prompts is how many calls the mock should see, the original
+		// included.
+		prompts     int
+		wantErr     string
+		corrections map[string]string
+		check       func(t *testing.T, res ExtractionResult)
+	}{
+		{
+			name:     "valid output isn't repaired",
+			original: extractionJSON(nil),
+			prompts:  1,
+			check: func(t *testing.T, res ExtractionResult) {
+				if res.Summary != "Talked about the launch." || score(res.MoraleScore) != "4" || fmt.Sprint(res.Tags) != "[wins]" {
+					t.Errorf("result = %+v", res)
+				}
+			},
+		},
+		{
+			name: "wrong types are coerced",
+			original: "```json\n" + extractionJSON(map[string]any{
+				"summary":           []string{"Talked about", "the launch."},
+				"morale_score":      " 4 ",
+				"growth_score":      2.6,
+				"action_items_mine": []any{map[string]any{"task": "Share the rollout doc", "owner": "me"}, nil},
+				"wins":              "Launch went out",
+				"blockers":          nil,
+			}) + "\n```",
+			prompts: 2,
+			corrections: map[string]string{
+				"summary": "validator", "morale_score": "validator", "growth_score": "validator",
+				"action_items_mine": "validator", "wins": "validator", "blockers": "validator",
+			},
+			check: func(t *testing.T, res ExtractionResult) {
+				got := fmt.Sprintf("%q %s %s %q %q %q", res.Summary, score(res.MoraleScore), score(res.GrowthScore),
+					res.ActionItemsMine, res.Wins, res.Blockers)
+				want := `"Talked about the launch." 4 3 ["Share the rollout doc"] ["Launch went out"] []`
+				if got != want {
+					t.Errorf("got  %s\nwant %s", got, want)
+				}
+			},
+		},
+		{
+			name:        "scores are clamped to 1-5",
+			original:    extractionJSON(map[string]any{"morale_score": 7, "growth_score": -2}),
+			prompts:     2,
+			corrections: map[string]string{"morale_score": "validator", "growth_score": "validator"},
+			check: func(t *testing.T, res ExtractionResult) {
+				if score(res.MoraleScore) != "5" || score(res.GrowthScore) != "1" {
+					t.Errorf("scores = %s, %s, want 5, 1", score(res.MoraleScore), score(res.GrowthScore))
+				}
+			},
+		},
+		{
+			name:        "unknown tags are dropped",
+			original:    extractionJSON(map[string]any{"tags": []string{"Hiring", "astrology", " hiring ", "WINS"}}),
+			prompts:     2,
+			corrections: map[string]string{"tags": "validator"},
+			check: func(t *testing.T, res ExtractionResult) {
+				if fmt.Sprint(res.Tags) != "[hiring wins]" {
+					t.Errorf("tags = %q, want [hiring wins]", res.Tags)
+				}
+			},
+		},
+		{
+			name:        "one repair fixes what coercion can't",
+			original:    extractionJSON(map[string]any{"morale_score": "high", "morale_rationale": nil}),
+			repair:      mockRule{Reply: extractionJSON(map[string]any{"morale_score": 2})},
+			prompts:     2,
+			corrections: map[string]string{"morale_score": "model", "morale_rationale": "model"},
+			check: func(t *testing.T, res ExtractionResult) {
+				if score(res.MoraleScore) != "2" || res.MoraleRationale != "Upbeat." {
+					t.Errorf("morale = %s %q", score(res.MoraleScore), res.MoraleRationale)
+				}
+			},
+		},
+		{
+			name:        "what the repair leaves wrong is left empty",
+			original:    extractionJSON(map[string]any{"morale_score": "high"}),
+			repair:      mockRule{Reply: extractionJSON(map[string]any{"morale_score": "very high"})},
+			prompts:     2,
+			corrections: map[string]string{"morale_score": "none"},
+			check: func(t *testing.T, res ExtractionResult) {
+				if res.MoraleScore != nil {
+					t.Errorf("morale = %d, want nil", *res.MoraleScore)
+				}
+			},
+		},
+		{
+			name:        "a failed repair call keeps the coerced original",
+			original:    extractionJSON(map[string]any{"morale_score": 9}),
+			repair:      mockRule{Status: 503, Reply: "overloaded"},
+			prompts:     2,
+			corrections: map[string]string{"morale_score": "validator"},
+			check: func(t *testing.T, res ExtractionResult) {
+				if score(res.MoraleScore) != "5" {
+					t.Errorf("morale = %s, want 5", score(res.MoraleScore))
+				}
+			},
+		},
+		{
+			name:        "unparseable output is repaired",
+			original:    "Sure! Here is the extraction you asked for.",
+			repair:      mockRule{Reply: extractionJSON(nil)},
+			prompts:     2,
+			corrections: map[string]string{"response": "model"},
+		},
+		{
+			name:     "unparseable output fails when the repair does too",
+			original: "Sure! Here is the extraction you asked for.",
+			repair:   mockRule{Reply: "Sorry, here it is: {summary: oops"},
+			prompts:  2,
+			wantErr:  "response is not a JSON object",
+		},
+		{
+			name:     "unparseable output fails when the repair call does",
+			original: "I can't help with that.",
+			repair:   mockRule{Status: 500, Reply: "boom"},
+			prompts:  2,
+			wantErr:  "repair failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := testApp(t, func(c *Config) { c.AIProvider = "mock" })
+			mock := a.provider.(*mockProvider)
+			repair := tt.repair
+			repair.Contains = repairMarker
+			if repair.Reply == "" && repair.Status == 0 {
+				// Without a scripted repair the model repeats itself.
+				repair.Reply = tt.original
+			}
+			mock.script(repair, mockRule{Contains: `"action_items_theirs"`, Reply: tt.original})
+
+			req := a.extractionCompletion(extractRequest{MemberName: "Sam", Transcript: sampleTranscript})
+			text, err := mock.Complete(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := a.validateWithRepair(mock, req, text)
+
+			prompts := mock.prompts()
+			if len(prompts) != tt.prompts {
+				t.Errorf("mock was called %d times, want %d", len(prompts), tt.prompts)
+			}
+			if len(prompts) > 1 && !strings.Contains(prompts[1], req.Prompt) {
+				t.Error("the repair prompt doesn't repeat the original request")
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]string{}
+			for _, c := range res.Corrections {
+				got[c.Field] = c.FixedBy
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.corrections) {
+				t.Errorf("corrections = %v, want %v\n%+v", got, tt.corrections, res.Corrections)
+			}
+			if tt.check != nil {
+				tt.check(t, res)
+			}
+		})
+	}
+}
@@ -1,74 +1,109 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"flag"
 	"fmt"
-	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"syscall"
 
 	"github.com/joho/godotenv"
+
+	"people-journal/journal"
 )
 
-func main() {
-	godotenv.Load("../.env")
+// settingFlags are the settings that can also be given on the command line,
+// by flag name. They override the config file and the environment.
+var settingFlags = map[string]string{
+	"port":        "port",
+	"data-dir":    "data_dir",
+	"ai-provider": "ai.provider",
+}
 
-	if jiraConfigured() {
-		fmt.Printf("JIRA integration: enabled (%s)\n", os.Getenv("JIRA_BASE_URL"))
-	} else {
-		fmt.Printf("JIRA integration: disabled — JIRA_BASE_URL=%q JIRA_EMAIL=%q JIRA_API_TOKEN=(set=%v)\n",
-			os.Getenv("JIRA_BASE_URL"), os.Getenv("JIRA_EMAIL"), os.Getenv("JIRA_API_TOKEN") != "")
+func main() {
+	configPath := flag.String("config", "", "TOML config `file` (default $CONFIG_FILE)")
+	envFile := flag.String("env-file", "../.env", "`file` of environment variables to load before reading the environment")
+	for name, key := range settingFlags {
+		flag.String(name, "", "overrides the "+key+" setting")
 	}
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [encrypt | rotate-key]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-	InitDB()
-	defer DB.Close()
-
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("GET /api/team", handleGetTeam)
-	mux.HandleFunc("POST /api/team", handleCreateTeamMember)
-	mux.HandleFunc("PUT /api/team/{id}", handleUpdateTeamMember)
-	mux.HandleFunc("PUT /api/team/{id}/prep-notes", handleUpdatePrepNotes)
-	mux.HandleFunc("DELETE /api/team/{id}", handleDeleteTeamMember)
-
-	mux.HandleFunc("GET /api/entries", handleGetEntries)
-	mux.HandleFunc("GET /api/entries/{id}", handleGetEntry)
-	mux.HandleFunc("POST /api/entries", handleCreateEntry)
-	mux.HandleFunc("PUT /api/entries/{id}", handleUpdateEntry)
-	mux.HandleFunc("DELETE /api/entries/{id}", handleDeleteEntry)
-
-	mux.HandleFunc("GET /api/config", handleGetConfig)
-	mux.HandleFunc("POST /api/extract", handleExtract)
-	mux.HandleFunc("POST /api/prep", handlePrep)
+	cfg, err := loadConfig(*configPath, *envFile)
+	if err != nil {
+		fmt.Printf("Invalid config:\n%v\n", err)
+		os.Exit(1)
+	}
 
-	handler := corsMiddleware(mux)
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(cfg, flag.Arg(0)))
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3001"
+	app, err := journal.New(cfg)
+	if err != nil {
+		fmt.Println("Startup failed:", err)
+		os.Exit(1)
 	}
+	defer app.Close()
 
-	fmt.Printf("Backend running on http://localhost:%s\n", port)
-	if err := http.ListenAndServe(":"+port, handler); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := app.Run(ctx); err != nil {
 		fmt.Println("Server error:", err)
+		app.Close()
 		os.Exit(1)
 	}
 }
 
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(204)
-			return
+// loadConfig builds the config from the config file, then the environment
+// with envFile loaded into it, then the flags. A missing envFile is only an
+// error when it was given explicitly.
+func loadConfig(configPath, envFile string) (journal.Config, error) {
+	if err := godotenv.Load(envFile); err != nil && isFlagSet("env-file") {
+		return journal.Config{}, fmt.Errorf("load %s: %w", envFile, err)
+	}
+	cfg, err := journal.LoadConfig(cmp.Or(configPath, os.Getenv("CONFIG_FILE")))
+	if err != nil {
+		return cfg, err
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if key, ok := settingFlags[f.Name]; ok && err == nil {
+			if err = cfg.Set(key, f.Value.String()); err != nil {
+				err = fmt.Errorf("-%s: %w", f.Name, err)
+			}
 		}
+	})
+	return cfg, err
+}
 
-		// Limit request body size to 10 MB
-		if r.Body != nil {
-			r.Body = http.MaxBytesReader(w, r.Body, 10<<20)
-		}
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
 
-		next.ServeHTTP(w, r)
-	})
+// runCommand runs a maintenance command against the database instead of
+// starting the server, and returns the exit code.
+func runCommand(cfg journal.Config, name string) int {
+	if !slices.Contains(journal.Commands, name) {
+		fmt.Fprintf(os.Stderr, "Unknown command %q. Commands: encrypt, rotate-key\n", name)
+		return 2
+	}
+	app, err := journal.New(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Startup failed:", err)
+		return 1
+	}
+	defer app.Close()
+	if err := app.RunCommand(name); err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", name, err)
+		return 1
+	}
+	return 0
 }
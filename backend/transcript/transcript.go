@@ -0,0 +1,484 @@
+// Package transcript normalizes meeting transcript exports into speaker
+// turns. It understands WebVTT (including Zoom's and Teams' speaker labels),
+// SRT, YouTube/Google Meet .sbv captions, Google Meet transcripts saved as
+// Docs text, and Otter plain-text exports. Anything else is treated as an
+// already-readable transcript.
+package transcript
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	WebVTT Format = "webvtt"
+	Zoom   Format = "zoom"
+	SRT    Format = "srt"
+	SBV    Format = "sbv"
+	Meet   Format = "meet"
+	Otter  Format = "otter"
+	Plain  Format = "plain"
+)
+
+// Formats lists every format Parse can produce, in detection order.
+var Formats = []Format{WebVTT, Zoom, SRT, SBV, Meet, Otter, Plain}
+
+// ErrEmpty is returned when a transcript has no spoken text.
+var ErrEmpty = errors.New("transcript has no text")
+
+// Turn is one speaker's uninterrupted stretch of talk. Speaker is empty when
+// the source doesn't name speakers, and Start is empty when it has no
+// timestamps.
+type Turn struct {
+	Speaker string `json:"speaker,omitempty"`
+	Start   string `json:"start,omitempty"`
+	Text    string `json:"text"`
+}
+
+type Transcript struct {
+	Format Format `json:"format"`
+	Turns  []Turn `json:"turns"`
+}
+
+// Text renders the transcript in the canonical form the rest of the app
+// reads: one "Speaker: text" line per turn, or a plain paragraph when the
+// speaker is unknown, with a blank line between turns. Timestamps are left
+// out.
+func (t Transcript) Text() string {
+	parts := make([]string, len(t.Turns))
+	for i, turn := range t.Turns {
+		if turn.Speaker != "" {
+			parts[i] = turn.Speaker + ": " + turn.Text
+		} else {
+			parts[i] = turn.Text
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Speakers returns the distinct speaker labels in order of first appearance.
+func (t Transcript) Speakers() []string {
+	seen := map[string]bool{}
+	speakers := []string{}
+	for _, turn := range t.Turns {
+		if turn.Speaker != "" && !seen[turn.Speaker] {
+			seen[turn.Speaker] = true
+			speakers = append(speakers, turn.Speaker)
+		}
+	}
+	return speakers
+}
+
+// Parse detects the format of data from its contents, using filename's
+// extension as a hint, and normalizes it.
+func Parse(filename string, data []byte) (Transcript, error) {
+	text := cleanInput(data)
+	return parse(detect(filename, text), text)
+}
+
+// ParseAs normalizes data as the given format, skipping detection. WebVTT
+// and Zoom are parsed the same way.
+func ParseAs(format Format, data []byte) (Transcript, error) {
+	for _, f := range Formats {
+		if f == format {
+			return parse(format, cleanInput(data))
+		}
+	}
+	return Transcript{}, fmt.Errorf("unknown transcript format %q", format)
+}
+
+func parse(format Format, text string) (Transcript, error) {
+	var turns []Turn
+	switch format {
+	case WebVTT, Zoom:
+		var cues []cue
+		cues, format = parseVTT(text)
+		turns = mergeCues(cues)
+	case SRT:
+		turns = mergeCues(parseSRT(text))
+	case SBV:
+		turns = mergeCues(parseSBV(text))
+	case Otter:
+		turns = parseOtter(text)
+	case Meet:
+		turns = parseLabelled(stripMeetBoilerplate(text))
+	default:
+		turns = parseLabelled(text)
+	}
+	if len(turns) == 0 {
+		return Transcript{}, ErrEmpty
+	}
+	return Transcript{Format: format, Turns: turns}, nil
+}
+
+func cleanInput(data []byte) string {
+	s := strings.TrimPrefix(string(data), "\uFEFF")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
+}
+
+// ─── Detection ──────────────────────────────────────────
+
+var (
+	srtTiming    = regexp.MustCompile(`(?m)^\d{1,2}:\d{2}:\d{2},\d{1,3}\s*-->`)
+	sbvTiming    = regexp.MustCompile(`(?m)^\d{1,2}:\d{2}:\d{2}\.\d{1,3},\d{1,2}:\d{2}:\d{2}\.\d{1,3}\s*$`)
+	otterHeader  = regexp.MustCompile(`^(\S.{0,60}?)\s{2,}((?:\d{1,2}:)?\d{1,2}:\d{2})\s*$`)
+	bareTime     = regexp.MustCompile(`^\s*\d{1,2}:\d{2}:\d{2}\s*$`)
+	speakerLabel = regexp.MustCompile(`^([\p{L}][\p{L}\d .'\-]{0,40}):\s+(.*)$`)
+	leadingTime  = regexp.MustCompile(`^\s*[\[(]?\d{1,2}:\d{2}(?::\d{2})?(?:[.,]\d+)?[\])]?\s+`)
+)
+
+func detect(filename, text string) Format {
+	ext := strings.ToLower(path.Ext(filename))
+	switch {
+	case strings.HasPrefix(text, "WEBVTT") || ext == ".vtt":
+		return WebVTT
+	case ext == ".srt" || srtTiming.MatchString(text):
+		return SRT
+	case ext == ".sbv" || sbvTiming.MatchString(text):
+		return SBV
+	}
+
+	lines := strings.Split(text, "\n")
+	otter, timestamps, labels := 0, 0, 0
+	for _, l := range lines {
+		switch {
+		case otterHeader.MatchString(l):
+			otter++
+		case bareTime.MatchString(l):
+			timestamps++
+		case speakerLabel.MatchString(strings.TrimSpace(l)):
+			labels++
+		}
+	}
+	switch {
+	case otter > 0 && otter >= labels:
+		return Otter
+	case timestamps > 0 && labels > 0:
+		return Meet
+	}
+	return Plain
+}
+
+// ─── Caption formats ────────────────────────────────────
+
+// cue is one caption with its start time and the speaker it names, if any.
+type cue struct {
+	start   time.Duration
+	speaker string
+	text    string
+}
+
+var (
+	voiceTag = regexp.MustCompile(`<v(?:\.[^\s>]+)*\s+([^>]+)>`)
+	anyTag   = regexp.MustCompile(`</?[^>]+>`)
+	blankRun = regexp.MustCompile(`\n\s*\n`)
+)
+
+// parseVTT reads WebVTT cues. Speakers come from <v Name> voice spans (Teams)
+// or a "Name: " prefix on the cue text (Zoom); the format is reported as Zoom
+// when most cues use the prefix.
+func parseVTT(text string) ([]cue, Format) {
+	var cues []cue
+	prefixed := 0
+	for i, block := range blocks(text) {
+		if i == 0 && strings.HasPrefix(block[0], "WEBVTT") {
+			continue
+		}
+		if first := strings.Fields(block[0]); len(first) > 0 && (first[0] == "NOTE" || first[0] == "STYLE" || first[0] == "REGION") {
+			continue
+		}
+		start, body, ok := timedCue(block)
+		if !ok {
+			continue
+		}
+		raw := strings.Join(body, " ")
+		c := cue{start: start}
+		if m := voiceTag.FindStringSubmatch(raw); m != nil {
+			c.speaker = strings.TrimSpace(m[1])
+		}
+		c.text = cleanCaption(raw)
+		if c.speaker == "" {
+			if speaker, rest, ok := splitLabel(c.text); ok {
+				c.speaker, c.text = speaker, rest
+				prefixed++
+			}
+		}
+		if c.text != "" {
+			cues = append(cues, c)
+		}
+	}
+	if prefixed > 0 && prefixed*2 >= len(cues) {
+		return cues, Zoom
+	}
+	return cues, WebVTT
+}
+
+func parseSRT(text string) []cue {
+	var cues []cue
+	for _, block := range blocks(text) {
+		start, body, ok := timedCue(block)
+		if !ok {
+			continue
+		}
+		if c := captionCue(start, strings.Join(body, " ")); c.text != "" {
+			cues = append(cues, c)
+		}
+	}
+	return cues
+}
+
+func parseSBV(text string) []cue {
+	var cues []cue
+	for _, block := range blocks(text) {
+		if !sbvTiming.MatchString(block[0]) {
+			continue
+		}
+		start, ok := parseTimestamp(strings.SplitN(block[0], ",", 2)[0])
+		if !ok {
+			continue
+		}
+		if c := captionCue(start, strings.Join(block[1:], " ")); c.text != "" {
+			cues = append(cues, c)
+		}
+	}
+	return cues
+}
+
+func captionCue(start time.Duration, raw string) cue {
+	c := cue{start: start, text: cleanCaption(raw)}
+	if speaker, rest, ok := splitLabel(c.text); ok {
+		c.speaker, c.text = speaker, rest
+	}
+	return c
+}
+
+// blocks splits caption text into blank-line separated blocks of non-empty
+// lines.
+func blocks(text string) [][]string {
+	var out [][]string
+	for _, b := range blankRun.Split(strings.TrimSpace(text), -1) {
+		var lines []string
+		for _, l := range strings.Split(b, "\n") {
+			if l = strings.TrimSpace(l); l != "" {
+				lines = append(lines, l)
+			}
+		}
+		if len(lines) > 0 {
+			out = append(out, lines)
+		}
+	}
+	return out
+}
+
+// timedCue finds the "start --> end" line of a WebVTT or SRT cue, skipping
+// any identifier before it, and returns the start time and the text lines.
+func timedCue(block []string) (time.Duration, []string, bool) {
+	for i, l := range block {
+		if before, _, ok := strings.Cut(l, "-->"); ok {
+			start, ok := parseTimestamp(strings.TrimSpace(before))
+			return start, block[i+1:], ok
+		}
+	}
+	return 0, nil, false
+}
+
+// cleanCaption strips markup and the ">>" / "-" speaker-change markers some
+// captioners add.
+func cleanCaption(s string) string {
+	s = html.UnescapeString(anyTag.ReplaceAllString(s, ""))
+	s = strings.TrimSpace(s)
+	s = strings.TrimSpace(strings.TrimPrefix(s, ">>"))
+	s = strings.TrimSpace(strings.TrimPrefix(s, "- "))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// paragraphGap is how long a pause between unattributed captions has to be
+// to start a new paragraph.
+const paragraphGap = 3 * time.Second
+
+// mergeCues joins consecutive cues into turns. A cue without a speaker
+// continues the current turn, except that unattributed captions are broken
+// into paragraphs at long pauses. Captions repeated verbatim, as rolling
+// auto-captions do, are dropped.
+func mergeCues(cues []cue) []Turn {
+	var turns []Turn
+	var last cue
+	for i, c := range cues {
+		if i > 0 && c.text == last.text {
+			continue
+		}
+		n := len(turns)
+		switch {
+		case n > 0 && (c.speaker == turns[n-1].Speaker || c.speaker == "") &&
+			!(turns[n-1].Speaker == "" && c.start-last.start > paragraphGap):
+			turns[n-1].Text += " " + c.text
+		default:
+			turns = append(turns, Turn{Speaker: c.speaker, Start: formatTimestamp(c.start), Text: c.text})
+		}
+		last = c
+	}
+	return turns
+}
+
+// ─── Text formats ───────────────────────────────────────
+
+// parseOtter reads Otter's text export, where each turn starts with a
+// "Name  0:03" header line followed by its paragraph.
+func parseOtter(text string) []Turn {
+	var turns []Turn
+	for _, l := range strings.Split(text, "\n") {
+		if m := otterHeader.FindStringSubmatch(l); m != nil {
+			start := ""
+			if d, ok := parseTimestamp(m[2]); ok {
+				start = formatTimestamp(d)
+			}
+			turns = append(turns, Turn{Speaker: strings.TrimSpace(m[1]), Start: start})
+			continue
+		}
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "Transcribed by https://otter.ai") {
+			continue
+		}
+		if len(turns) == 0 {
+			turns = append(turns, Turn{})
+		}
+		turns[len(turns)-1].Text = joinText(turns[len(turns)-1].Text, l)
+	}
+	return compact(turns)
+}
+
+// stripMeetBoilerplate drops the title and attendee list Google Meet puts
+// above the transcript, and the notices it adds at the end.
+func stripMeetBoilerplate(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		if i > 20 {
+			break
+		}
+		if strings.EqualFold(strings.TrimSpace(l), "transcript") {
+			lines = lines[i+1:]
+			break
+		}
+	}
+	var kept []string
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		if strings.HasPrefix(t, "Meeting ended after") || strings.HasPrefix(t, "This editable transcript was computer generated") {
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// parseLabelled reads text where turns start with "Name: ", optionally after
+// a timestamp. Timestamps on lines of their own set the start of the turns
+// that follow. Lines without a label continue the current turn; if no line
+// has a label, blank lines separate paragraphs instead.
+func parseLabelled(text string) []Turn {
+	lines := strings.Split(text, "\n")
+	labelled := false
+	for _, l := range lines {
+		if _, _, ok := splitLabel(leadingTime.ReplaceAllString(strings.TrimSpace(l), "")); ok {
+			labelled = true
+			break
+		}
+	}
+
+	var turns []Turn
+	start := ""
+	paragraph := true
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			paragraph = !labelled
+			continue
+		}
+		if bareTime.MatchString(l) {
+			if d, ok := parseTimestamp(l); ok {
+				start = formatTimestamp(d)
+			}
+			continue
+		}
+		if ts := leadingTime.FindString(l); ts != "" {
+			if d, ok := parseTimestamp(strings.Trim(strings.TrimSpace(ts), "[]()")); ok {
+				start = formatTimestamp(d)
+			}
+			l = strings.TrimSpace(l[len(ts):])
+		}
+		if speaker, rest, ok := splitLabel(l); labelled && ok {
+			turns = append(turns, Turn{Speaker: speaker, Start: start, Text: rest})
+			start = ""
+			continue
+		}
+		if len(turns) == 0 || paragraph {
+			turns = append(turns, Turn{Start: start})
+			start = ""
+		}
+		paragraph = false
+		turns[len(turns)-1].Text = joinText(turns[len(turns)-1].Text, l)
+	}
+	return compact(turns)
+}
+
+// ─── Helpers ────────────────────────────────────────────
+
+func splitLabel(s string) (speaker, rest string, ok bool) {
+	m := speakerLabel.FindStringSubmatch(s)
+	if m == nil {
+		return "", s, false
+	}
+	return strings.TrimSpace(m[1]), strings.TrimSpace(m[2]), true
+}
+
+func joinText(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " " + b
+}
+
+// compact drops turns left without text.
+func compact(turns []Turn) []Turn {
+	out := turns[:0]
+	for _, t := range turns {
+		if t.Text = strings.TrimSpace(t.Text); t.Text != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// parseTimestamp reads "h:mm:ss.mmm", "hh:mm:ss,mmm", "mm:ss.mmm" or "m:ss".
+func parseTimestamp(s string) (time.Duration, bool) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	secs := 0.0
+	for i, p := range parts {
+		if i < len(parts)-1 && strings.ContainsAny(p, ".,") {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(strings.Replace(p, ",", ".", 1), 64)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		secs = secs*60 + n
+	}
+	return time.Duration(secs * float64(time.Second)), true
+}
+
+func formatTimestamp(d time.Duration) string {
+	s := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
@@ -0,0 +1,131 @@
+package transcript
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name     string
+		filename string
+		input    string
+		format   Format
+		turns    []Turn
+	}{
+		{
+			name:     "zoom vtt",
+			filename: "GMT20240312-150000_Recording.transcript.vtt",
+			input: "WEBVTT\n\n1\n00:00:01.120 --> 00:00:03.500\nDana Kim: Hey, how was the week?\n\n" +
+				"2\n00:00:03.900 --> 00:00:06.000\nSam Ortiz: Pretty good.\n\n" +
+				"3\n00:00:06.100 --> 00:00:09.000\nSam Ortiz: The migration finally shipped.\n",
+			format: Zoom,
+			turns: []Turn{
+				{Speaker: "Dana Kim", Start: "00:00:01", Text: "Hey, how was the week?"},
+				{Speaker: "Sam Ortiz", Start: "00:00:03", Text: "Pretty good. The migration finally shipped."},
+			},
+		},
+		{
+			name:     "teams voice spans",
+			filename: "meeting.vtt",
+			input: "WEBVTT\n\nNOTE exported by Teams\n\n" +
+				"a1b2/12-0\n00:01:02.000 --> 00:01:04.000\n<v Dana Kim>Any blockers?</v>\n\n" +
+				"a1b2/13-0\n00:01:05.000 --> 00:01:08.000\n<v Sam Ortiz>Waiting on &amp; chasing infra.</v>\n",
+			format: WebVTT,
+			turns: []Turn{
+				{Speaker: "Dana Kim", Start: "00:01:02", Text: "Any blockers?"},
+				{Speaker: "Sam Ortiz", Start: "00:01:05", Text: "Waiting on & chasing infra."},
+			},
+		},
+		{
+			name:     "srt without speakers",
+			filename: "captions.srt",
+			input: "1\n00:00:01,000 --> 00:00:02,000\nSo the plan is\n\n" +
+				"2\n00:00:02,000 --> 00:00:03,000\n<i>to ship Friday.</i>\n\n" +
+				"3\n00:00:10,000 --> 00:00:12,000\nSounds good.\n",
+			format: SRT,
+			turns: []Turn{
+				{Start: "00:00:01", Text: "So the plan is to ship Friday."},
+				{Start: "00:00:10", Text: "Sounds good."},
+			},
+		},
+		{
+			name:   "sbv",
+			input:  "0:00:00.000,0:00:02.000\n>> Dana: Welcome back.\n\n0:00:02.500,0:00:04.000\nSam: Thanks!\n",
+			format: SBV,
+			turns: []Turn{
+				{Speaker: "Dana", Start: "00:00:00", Text: "Welcome back."},
+				{Speaker: "Sam", Start: "00:00:02", Text: "Thanks!"},
+			},
+		},
+		{
+			name: "google meet docs text",
+			input: "Dana / Sam 1:1 - Transcript\nAttendees\nDana Kim, Sam Ortiz\nTranscript\n00:00:00\n\n" +
+				"Dana Kim: How's the on-call rotation going?\nSam Ortiz: Busy week.\nA lot of pages at night.\n00:05:00\n\n" +
+				"Dana Kim: Let's fix that.\nMeeting ended after 00:06:12 👋\n",
+			format: Meet,
+			turns: []Turn{
+				{Speaker: "Dana Kim", Start: "00:00:00", Text: "How's the on-call rotation going?"},
+				{Speaker: "Sam Ortiz", Text: "Busy week. A lot of pages at night."},
+				{Speaker: "Dana Kim", Start: "00:05:00", Text: "Let's fix that."},
+			},
+		},
+		{
+			name: "otter",
+			input: "Dana Kim  0:03\nHow are you feeling about the promo packet?\n\n" +
+				"Sam Ortiz  0:09\nNervous, honestly.\nBut it's coming together.\n\n" +
+				"Transcribed by https://otter.ai\n",
+			format: Otter,
+			turns: []Turn{
+				{Speaker: "Dana Kim", Start: "00:00:03", Text: "How are you feeling about the promo packet?"},
+				{Speaker: "Sam Ortiz", Start: "00:00:09", Text: "Nervous, honestly. But it's coming together."},
+			},
+		},
+		{
+			name:   "plain pasted text",
+			input:  "[00:12:04] Dana: Anything else?\r\nSam: Nope, that's it.\r\n",
+			format: Plain,
+			turns: []Turn{
+				{Speaker: "Dana", Start: "00:12:04", Text: "Anything else?"},
+				{Speaker: "Sam", Text: "Nope, that's it."},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.filename, []byte(tc.input))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got.Format != tc.format {
+				t.Errorf("format = %q, want %q", got.Format, tc.format)
+			}
+			if !reflect.DeepEqual(got.Turns, tc.turns) {
+				t.Errorf("turns =\n%#v\nwant\n%#v", got.Turns, tc.turns)
+			}
+		})
+	}
+}
+
+func TestTextIsCanonical(t *testing.T) {
+	tr, err := Parse("call.vtt", []byte("WEBVTT\n\n00:00.000 --> 00:01.000\nDana: Hi\n\n00:01.000 --> 00:02.000\nSam: Hello\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Dana: Hi\n\nSam: Hello"; tr.Text() != want {
+		t.Errorf("Text() = %q, want %q", tr.Text(), want)
+	}
+	if got := tr.Speakers(); !reflect.DeepEqual(got, []string{"Dana", "Sam"}) {
+		t.Errorf("Speakers() = %v", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse("empty.vtt", []byte("WEBVTT\n\n")); err != ErrEmpty {
+		t.Errorf("empty vtt: err = %v, want ErrEmpty", err)
+	}
+	if _, err := ParseAs("docx", []byte("hi")); err == nil {
+		t.Error("ParseAs with an unknown format should fail")
+	}
+}
@@ -1,3 +1,5 @@
+import { TAGS } from "./constants";
+
 const IS_TAURI = Boolean(window.__TAURI_INTERNALS__);
 
 let invoke;
@@ -92,6 +94,11 @@ export function fetchConfig() {
   return request("/api/config");
 }
 
+export function fetchTags() {
+  if (IS_TAURI) return Promise.resolve(TAGS.map(name => ({ name })));
+  return request("/api/tags");
+}
+
 export function updatePrepNotes(memberId, prepNotes) {
   if (IS_TAURI) return invoke("update_prep_notes", { memberId, prepNotes });
   return request(`/api/team/${memberId}/prep-notes`, {
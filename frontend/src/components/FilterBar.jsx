@@ -1,5 +1,5 @@
 import TagPill from "./TagPill";
-import { TAGS } from "../constants";
+import useTags from "../useTags";
 
 const DATE_RANGES = [
   { label: "30d", value: "30" },
@@ -9,6 +9,7 @@ const DATE_RANGES = [
 ];
 
 export default function FilterBar({ selectedTags, onTagsChange, dateRange, onDateRangeChange, color }) {
+  const tagOptions = useTags();
   const toggleTag = (tag) => {
     if (selectedTags.includes(tag)) {
       onTagsChange(selectedTags.filter(t => t !== tag));
@@ -20,7 +21,7 @@ export default function FilterBar({ selectedTags, onTagsChange, dateRange, onDat
   return (
     <div>
       <div style={{ display: "flex", gap: 5, flexWrap: "wrap", marginBottom: 8 }}>
-        {TAGS.map(tag => (
+        {tagOptions.map(tag => (
           <TagPill key={tag} tag={tag} color={color}
             active={selectedTags.includes(tag)}
             onClick={() => toggleTag(tag)}
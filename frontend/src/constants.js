@@ -1,3 +1,4 @@
+// Built-in tag list, used until /api/tags answers (and in the desktop app)
 export const TAGS = [
   "career growth", "blockers", "wins", "feedback given", "feedback received",
   "cross-team", "technical debt", "hiring", "process", "personal", "morale",
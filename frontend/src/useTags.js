@@ -0,0 +1,25 @@
+import { useEffect, useState } from "react";
+import { fetchTags } from "./api";
+import { TAGS } from "./constants";
+
+let tagsRequest;
+
+// Names of the active tags, fetched once and shared. Falls back to the
+// built-in list until the backend answers or if it can't.
+export default function useTags() {
+  const [tags, setTags] = useState(TAGS);
+
+  useEffect(() => {
+    tagsRequest ||= fetchTags()
+      .then(list => list.map(t => t.name))
+      .catch(() => {
+        tagsRequest = undefined;
+        return TAGS;
+      });
+    let cancelled = false;
+    tagsRequest.then(names => { if (!cancelled) setTags(names); });
+    return () => { cancelled = true; };
+  }, []);
+
+  return tags;
+}
@@ -3,9 +3,10 @@ import PulseBar from "../components/PulseBar";
 import PulseSelector from "../components/PulseSelector";
 import EditableList from "../components/EditableList";
 import TagPill from "../components/TagPill";
-import { TAGS } from "../constants";
+import useTags from "../useTags";
 
 export default function EntryDetail({ entry, member, onBack, onDelete, onUpdate }) {
+  const tagOptions = useTags();
   const date = new Date(entry.date);
   const [editingSection, setEditingSection] = useState(null);
   const [editData, setEditData] = useState({});
@@ -169,7 +170,7 @@ export default function EntryDetail({ entry, member, onBack, onDelete, onUpdate
           {editingSection === "tags" ? (
             <>
               <div style={{ display: "flex", gap: 6, flexWrap: "wrap" }}>
-                {TAGS.map(tag => (
+                {tagOptions.map(tag => (
                   <TagPill key={tag} tag={tag} color={member.color}
                     active={editData.tags?.includes(tag)}
                     onClick={() => {
@@ -230,6 +231,19 @@ export default function EntryDetail({ entry, member, onBack, onDelete, onUpdate
           )}
         </div>
 
+        {/* Custom fields */}
+        {Object.keys(entry.custom_fields || {}).length > 0 && (
+          <div style={{ background: "white", borderRadius: 12, padding: 20, border: "1px solid rgba(0,0,0,0.06)", marginBottom: 12 }}>
+            <h3 style={{ fontSize: 11, color: "#aaa", textTransform: "uppercase", letterSpacing: 1.5, margin: "0 0 8px" }}>Custom Fields</h3>
+            {Object.entries(entry.custom_fields).map(([name, value]) => (
+              <p key={name} style={{ fontSize: 14, color: "#444", margin: "4px 0", lineHeight: 1.5 }}>
+                <span style={{ color: "#999" }}>{name.replace(/_/g, " ")}:</span>{" "}
+                {Array.isArray(value) ? value.join("; ") : typeof value === "number" ? `${value}/5` : value}
+              </p>
+            ))}
+          </div>
+        )}
+
         {/* Action Items */}
         <div style={{ background: "white", borderRadius: 12, padding: 20, border: "1px solid rgba(0,0,0,0.06)", marginBottom: 12 }}>
           <div style={{ display: "flex", justifyContent: "space-between", alignItems: "center", marginBottom: 8 }}>
@@ -2,9 +2,10 @@ import { useState } from "react";
 import PulseSelector from "../components/PulseSelector";
 import TagPill from "../components/TagPill";
 import EditableList from "../components/EditableList";
-import { TAGS } from "../constants";
+import useTags from "../useTags";
 
 export default function ReviewEntry({ member, extractedData, onSave, onBack }) {
+  const tagOptions = useTags();
   const [data, setData] = useState(extractedData);
   const [privateNote, setPrivateNote] = useState("");
 
@@ -94,7 +95,7 @@ export default function ReviewEntry({ member, extractedData, onSave, onBack }) {
         <div style={{ marginBottom: 20 }}>
           <span style={{ fontSize: 11, color: "#aaa", textTransform: "uppercase", letterSpacing: 1 }}>Tags</span>
           <div style={{ display: "flex", gap: 6, flexWrap: "wrap", marginTop: 8 }}>
-            {TAGS.map(tag => (
+            {tagOptions.map(tag => (
               <TagPill key={tag} tag={tag} color={member.color}
                 active={data.tags?.includes(tag)}
                 onClick={() => {